@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of gmd check with the exit-code output.
+const (
+	checkUpToDate = 0
+	checkFailed   = 1
+	checkUpdates  = 2
+)
+
+var (
+	checkSelector    selector
+	checkOutput      string
+	checkConcurrency int
+	checkCmd         = &cobra.Command{
+		Use:   "check [container...]",
+		Short: "Check the containers for image updates",
+		Long: `Check the containers for image updates without starting the TUI.
+
+The digest of the local image of every selected container is compared to the
+digest published by the registry. The result is printed as a table, as JSON,
+or only reported by the exit code with --output exit-code:
+0 when all the images are up to date, 1 when a check failed, 2 when updates are available.
+
+When the tag of an image is a version, such as 1.25.3 or 3.19-alpine, the newer
+tags of the same shape are reported too. They are an update for the containers
+following them with the gmd.update.track label.
+
+The containers whose gmd.update labels disable the updates (gmd.update.enable=false
+or gmd.update.policy=pin) are skipped.
+
+The credentials of the private registries are read from the Docker configuration
+(~/.docker/config.json or $DOCKER_CONFIG) and its credential helpers, as set up by
+docker login. The checks refused by a registry are reported as "auth required".`,
+		SilenceUsage: true,
+		RunE:         runCheck,
+	}
+)
+
+func init() {
+	checkSelector.addFlags(checkCmd)
+	checkCmd.Flags().StringVarP(&checkOutput, "output", "o", "table", "Output format: table, json or exit-code")
+	checkCmd.Flags().IntVarP(&checkConcurrency, "concurrency", "j", 4, "Number of checks run at the same time")
+	rootCmd.AddCommand(checkCmd)
+}
+
+// checkResult is the result of the update check of a container.
+type checkResult struct {
+	Host           string             `json:"host"`
+	Container      string             `json:"container"`
+	ID             string             `json:"id"`
+	Image          string             `json:"image"`
+	LocalDigest    string             `json:"local_digest,omitempty"`
+	RemoteDigest   string             `json:"remote_digest,omitempty"`
+	PlatformDigest string             `json:"platform_digest,omitempty"`
+	Platform       string             `json:"platform,omitempty"`
+	Matched        client.DigestMatch `json:"matched,omitempty"`
+	Policy         string             `json:"policy"`
+	Update         bool               `json:"update"`
+	Tags           *client.TagUpdates `json:"tags,omitempty"`
+	Target         string             `json:"target,omitempty"`
+	Skipped        bool               `json:"skipped,omitempty"`
+	AuthRequired   bool               `json:"auth_required,omitempty"`
+	Error          string             `json:"error,omitempty"`
+}
+
+// available reports whether the container can be updated: its image has a
+// newer digest, or its policy tracks a newer tag.
+func (r checkResult) available() bool {
+	return r.Update || r.Target != ""
+}
+
+// targetImage returns the image to update the container to.
+func (r checkResult) targetImage() string {
+	if r.Target != "" {
+		return client.WithTag(r.Image, r.Target)
+	}
+	return r.Image
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	if checkOutput != "table" && checkOutput != "json" && checkOutput != "exit-code" {
+		return fmt.Errorf("unknown output format %q", checkOutput)
+	}
+
+	clients, err := hostClients()
+	if err != nil {
+		return err
+	}
+
+	results, err := checkContainers(clients, checkSelector, args, checkConcurrency)
+	if err != nil {
+		return err
+	}
+
+	code := checkUpToDate
+	for _, r := range results {
+		if r.Error != "" {
+			code = checkFailed
+			break
+		}
+		if r.available() {
+			code = checkUpdates
+		}
+	}
+
+	switch checkOutput {
+	case "json":
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case "exit-code":
+		if code != checkUpToDate {
+			cmd.SilenceErrors = true
+			return &exitError{code: code}
+		}
+		return nil
+	default:
+		if len(results) == 0 {
+			fmt.Fprintln(cmd.ErrOrStderr(), "no container selected")
+			return nil
+		}
+		printChecks(cmd.OutOrStdout(), results, len(clients) > 1)
+		return nil
+	}
+}
+
+// checkContainers checks the selected containers of all the hosts, at most
+// concurrency at the same time. The results are sorted by host and container.
+func checkContainers(clients []client.Client, s selector, names []string, concurrency int) ([]checkResult, error) {
+	targets, err := selectContainers(clients, s, names)
+	if err != nil {
+		return nil, err
+	}
+	return checkTargets(targets, concurrency), nil
+}
+
+// checkTargets checks the containers, at most concurrency at the same time.
+// The containers whose update policy disables the checks are skipped.
+// The results are in the order of the containers.
+func checkTargets(targets []target, concurrency int) []checkResult {
+	results := make([]checkResult, len(targets))
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		r := &results[i]
+		*r = checkResult{
+			Host:      t.host,
+			Container: t.name,
+			ID:        t.id,
+			Image:     t.image,
+			Policy:    t.policy.String(),
+		}
+		if t.policy.Invalid != "" {
+			r.Error = t.policy.Invalid
+			continue
+		}
+		if !t.policy.Checked() {
+			r.Skipped = true
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			check, err := t.cli.CheckUpdate(t.id)
+			if check.Image != "" {
+				r.Image = check.Image
+			}
+			r.LocalDigest = check.LocalDigest
+			r.RemoteDigest = check.RemoteDigest
+			r.PlatformDigest = check.PlatformDigest
+			r.Platform = check.Platform
+			r.Matched = check.Matched
+			r.Update = check.Update
+			if check.Tags.Level() != "" {
+				r.Tags = &check.Tags
+				r.Target = check.Tags.Target(t.policy.Track)
+			}
+			if err != nil {
+				r.Error = err.Error()
+				r.AuthRequired = errors.Is(err, client.ErrAuthRequired)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// printChecks prints the results as a table.
+func printChecks(out io.Writer, results []checkResult, showHost bool) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	if showHost {
+		fmt.Fprint(w, "HOST\t")
+	}
+	fmt.Fprintln(w, "CONTAINER\tIMAGE\tPOLICY\tLOCAL DIGEST\tREMOTE DIGEST\tNEWER TAG\tSTATUS\tERROR")
+	for _, r := range results {
+		status := "up to date"
+		switch {
+		case r.AuthRequired:
+			status = "auth required"
+		case r.Error != "":
+			status = "error"
+		case r.Skipped:
+			status = "skipped"
+		case r.Update:
+			status = "update available"
+		case r.Target != "":
+			status = "newer tag available"
+		}
+		newer := "-"
+		if r.Tags != nil {
+			newer = fmt.Sprintf("%s (%s)", r.Tags.Target(types.TrackMajor), r.Tags.Level())
+		}
+		if showHost {
+			fmt.Fprintf(w, "%s\t", r.Host)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Container, r.Image, r.Policy, shortDigest(r.LocalDigest), shortDigest(r.RemoteDigest), newer, status, r.Error)
+	}
+}
+
+// shortDigest returns the algorithm and the first 12 characters of a digest, or "-".
+func shortDigest(digest string) string {
+	if digest == "" {
+		return "-"
+	}
+	if len(digest) > len("sha256:")+12 {
+		return digest[:len("sha256:")+12]
+	}
+	return digest
+}
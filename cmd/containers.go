@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/spf13/cobra"
+)
+
+// selector selects the containers a headless command works on.
+// Without names and labels, all the containers are selected.
+type selector struct {
+	labels  []string // labels are "key" or "key=value" selectors, all must match.
+	running bool     // running restricts the selection to the running containers.
+	optIn   bool     // optIn restricts the selection to the containers opted in the unattended updates.
+}
+
+// addFlags adds the selection flags to the command.
+func (s *selector) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&s.labels, "label", "l", nil, "Select the containers with this label (key or key=value), can be repeated")
+	cmd.Flags().BoolVar(&s.running, "running", false, "Select the running containers only")
+}
+
+// match reports whether the container matches the labels and the state of the selector.
+func (s selector) match(c container.Summary) bool {
+	if s.running && c.State != container.StateRunning {
+		return false
+	}
+	if s.optIn && !types.UpdatePolicyOf(c.Labels).OptedIn() {
+		return false
+	}
+	for _, l := range s.labels {
+		k, v, hasValue := strings.Cut(l, "=")
+		value, ok := c.Labels[k]
+		if !ok || (hasValue && value != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// containers returns the containers of the host matching the selector, sorted by name.
+// When names are given, only the containers with these names or IDs are
+// returned and each name must match a container of one of the hosts.
+func (s selector) containers(cli client.Client, names []string, found map[string]bool) ([]container.Summary, error) {
+	list, err := cli.ContainerList()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the containers of %s: %w", cli.Endpoint().Name(), err)
+	}
+
+	selected := make([]container.Summary, 0, len(list))
+	for _, c := range list {
+		if !s.match(c) {
+			continue
+		}
+		if len(names) > 0 {
+			name := containerName(c)
+			matched := false
+			for _, n := range names {
+				n = strings.TrimPrefix(n, "/")
+				if n == name || n == c.ID || (len(n) >= 12 && strings.HasPrefix(c.ID, n)) {
+					found[n] = true
+					matched = true
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		selected = append(selected, c)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		return containerName(selected[i]) < containerName(selected[j])
+	})
+	return selected, nil
+}
+
+// target is a container selected by a headless command.
+type target struct {
+	cli    client.Client
+	host   string
+	id     string
+	name   string             // name is the name of the container.
+	label  string             // label is the name of the container, prefixed by its host when several hosts are selected.
+	image  string             // image is the image reference of the container.
+	policy types.UpdatePolicy // policy is the update policy set by the labels of the container.
+}
+
+// selectContainers returns the containers of all the hosts matching the selector,
+// sorted by host and name. Each name must match a container of one of the hosts.
+func selectContainers(clients []client.Client, s selector, names []string) ([]target, error) {
+	found := make(map[string]bool)
+	var targets []target
+	for _, cli := range clients {
+		containers, err := s.containers(cli, names, found)
+		if err != nil {
+			return nil, err
+		}
+		host := cli.Endpoint().Name()
+		for _, c := range containers {
+			name := containerName(c)
+			label := name
+			if len(clients) > 1 {
+				label = host + "/" + name
+			}
+			targets = append(targets, target{
+				cli:    cli,
+				host:   host,
+				id:     c.ID,
+				name:   name,
+				label:  label,
+				image:  c.Image,
+				policy: types.UpdatePolicyOf(c.Labels),
+			})
+		}
+	}
+	if err := missing(names, found); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].host < targets[j].host
+	})
+	return targets, nil
+}
+
+// missing returns an error listing the names which did not match any container.
+func missing(names []string, found map[string]bool) error {
+	var unknown []string
+	for _, n := range names {
+		if !found[strings.TrimPrefix(n, "/")] {
+			unknown = append(unknown, n)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("no such container: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// containerName returns the name of the container without its leading slash.
+func containerName(c container.Summary) string {
+	if len(c.Names) == 0 {
+		return c.ID[:12]
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// hostClients returns a client for every selected Docker host.
+func hostClients() ([]client.Client, error) {
+	endpoints, err := dockerEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	clients := make([]client.Client, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		cli, err := client.NewClient(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to %s: %w", endpoint, err)
+		}
+		clients = append(clients, cli)
+	}
+	return clients, nil
+}
+
+// exitError makes the program exit with the given code without printing anything.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kernaxis/gmd/history"
+	"github.com/kernaxis/gmd/tui/controllers/containerupdate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyOutput  string
+	historyLimit   int
+	historySince   time.Duration
+	historyFailed  bool
+	historyDetails bool
+	historyCmd     = &cobra.Command{
+		Use:   "history [container...]",
+		Short: "Show the history of the container updates",
+		Long: `Show the updates of the containers run by gmd, from the TUI, gmd update and gmd watch,
+newest first.
+
+Every update is recorded with the previous and the new image, its steps and
+their timings, its result, and the user who ran it. The history is kept in
+$XDG_STATE_HOME/gmd/history.jsonl (~/.local/state/gmd/history.jsonl by default),
+one JSON record per line.`,
+		SilenceUsage: true,
+		RunE:         runHistory,
+	}
+)
+
+func init() {
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", "table", "Output format: table or json")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "Number of updates shown, 0 for all")
+	historyCmd.Flags().DurationVar(&historySince, "since", 0, "Only show the updates of this last period, such as 72h")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "Only show the failed and rolled back updates")
+	historyCmd.Flags().BoolVarP(&historyDetails, "details", "v", false, "Show the steps of the updates")
+	rootCmd.AddCommand(historyCmd)
+}
+
+// configureHistory records the updates in the history file of the user state directory.
+func configureHistory() {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return
+	}
+	containerupdate.History = history.New(path)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if historyOutput != "table" && historyOutput != "json" {
+		return fmt.Errorf("unknown output format %q", historyOutput)
+	}
+	if containerupdate.History == nil {
+		return fmt.Errorf("no history: unable to find the user state directory")
+	}
+
+	records, err := containerupdate.History.Load()
+	if err != nil {
+		return err
+	}
+	records = filterHistory(records, args)
+
+	if historyOutput == "json" {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if records == nil {
+			records = []history.Record{}
+		}
+		return enc.Encode(records)
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(cmd.ErrOrStderr(), "no update recorded")
+		return nil
+	}
+	printHistory(cmd.OutOrStdout(), records)
+	return nil
+}
+
+// filterHistory returns the records selected by the flags and the container
+// names, newest first.
+func filterHistory(records []history.Record, names []string) []history.Record {
+	var selected []history.Record
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		switch {
+		case len(names) > 0 && !slices.Contains(names, r.Container):
+			continue
+		case historySince > 0 && time.Since(r.Started) > historySince:
+			continue
+		case historyFailed && r.Result == history.Updated:
+			continue
+		}
+		selected = append(selected, r)
+		if historyLimit > 0 && len(selected) == historyLimit {
+			break
+		}
+	}
+	return selected
+}
+
+// printHistory prints the records as a table, each followed by its steps with --details.
+func printHistory(out io.Writer, records []history.Record) {
+	showHost := slices.ContainsFunc(records, func(r history.Record) bool { return r.Host != records[0].Host })
+
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	if showHost {
+		fmt.Fprint(w, "HOST\t")
+	}
+	fmt.Fprintln(w, "STARTED\tCONTAINER\tFROM\tTO\tRESULT\tDURATION\tOPERATOR\tSOURCE")
+	for _, r := range records {
+		if showHost {
+			fmt.Fprintf(w, "%s\t", r.Host)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Started.Local().Format("2006-01-02 15:04:05"), r.Container,
+			imageLabel(r.Old), imageLabel(r.New), r.Result,
+			r.Duration().Round(100*time.Millisecond), r.Operator, r.Source)
+	}
+	w.Flush()
+
+	// the steps are inserted after the table is aligned, to keep them out of its columns
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	fmt.Fprintln(out, lines[0])
+	for i, r := range records {
+		fmt.Fprintln(out, lines[i+1])
+		if !historyDetails {
+			continue
+		}
+		if r.Strategy != "" {
+			fmt.Fprintf(out, "    strategy %s", r.Strategy)
+			if r.Result == history.Updated {
+				fmt.Fprintf(out, ", downtime %s", r.Downtime.Round(time.Millisecond))
+			}
+			fmt.Fprintln(out)
+		}
+		for _, s := range r.Steps {
+			duration := s.Finished.Sub(s.Started).Round(10 * time.Millisecond)
+			if s.Error != "" {
+				fmt.Fprintf(out, "    ✗ %s %s (%s): %s\n", s.Label, s.Target, duration, s.Error)
+				continue
+			}
+			fmt.Fprintf(out, "    ✓ %s %s (%s)\n", s.Label, s.Target, duration)
+		}
+		if r.Error != "" {
+			fmt.Fprintf(out, "    error: %s\n", r.Error)
+		}
+	}
+}
+
+// imageLabel returns the reference and the short digest of an image of the history, or "-".
+func imageLabel(v history.ImageVersion) string {
+	if v.Ref == "" {
+		return "-"
+	}
+	digest := v.Digest
+	if digest == "" {
+		digest = v.ID
+	}
+	if digest == "" {
+		return v.Ref
+	}
+	return fmt.Sprintf("%s (%s)", v.Ref, strings.TrimPrefix(shortDigest(digest), "sha256:"))
+}
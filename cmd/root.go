@@ -5,9 +5,19 @@ package cmd
 
 import (
 	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
 	"github.com/kernaxis/gmd/tui"
+	"github.com/kernaxis/gmd/tui/controllers/containerupdate"
 	"github.com/spf13/cobra"
 )
 
@@ -15,14 +25,43 @@ var version = ""
 var buildDate = ""
 
 var (
-	debugfile string
-	rootCmd   = &cobra.Command{
+	debugfile   string
+	dockerHosts []string
+	contexts    []string
+	tlsEnabled  bool
+	tlsVerify   bool
+	tlsCACert   string
+	tlsCert     string
+	tlsKey      string
+	cacheTTL    time.Duration
+	parallelism int
+	strategy    string
+	keepImages  int
+	keepFor     time.Duration
+	rootCmd     = &cobra.Command{
 		Use:     "gmd",
 		Short:   "TUI to manage docker objects",
 		Long:    `The Definitive TUI to manage docker objects with ease.`,
 		Version: version + " (" + buildDate + ")",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// the commands without the TUI print their results, the log goes to the debug file only
+			if cmd != cmd.Root() {
+				if err := debugLog(); err != nil {
+					return err
+				}
+			}
+			configureRegistryCache()
+			configureHistory()
+			containerupdate.Retention = history.Retention{Keep: keepImages, MaxAge: keepFor}
+			return configureStrategy()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return tui.Start(debugfile)
+			endpoints, err := dockerEndpoints()
+			if err != nil {
+				return err
+			}
+			containerupdate.Parallelism = parallelism
+			return tui.Start(debugfile, endpoints)
 		},
 	}
 )
@@ -30,10 +69,134 @@ var (
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		os.Exit(1)
 	}
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&debugfile, "debug", "d", "", "Create a debug file at the chosen location")
+	rootCmd.PersistentFlags().StringSliceVarP(&dockerHosts, "host", "H", nil, "Daemon socket(s) to connect to (unix://, tcp://, ssh://user@host), can be repeated")
+	rootCmd.PersistentFlags().StringSliceVarP(&contexts, "context", "c", nil, "Name of the docker context(s) to use, can be repeated")
+	rootCmd.PersistentFlags().BoolVar(&tlsEnabled, "tls", false, "Use TLS to connect to a tcp:// host")
+	rootCmd.PersistentFlags().BoolVar(&tlsVerify, "tlsverify", false, "Use TLS and verify the remote daemon certificate")
+	rootCmd.PersistentFlags().StringVar(&tlsCACert, "tlscacert", "", "Trust certs signed only by this CA")
+	rootCmd.PersistentFlags().StringVar(&tlsCert, "tlscert", "", "Path to TLS certificate file")
+	rootCmd.PersistentFlags().StringVar(&tlsKey, "tlskey", "", "Path to TLS key file")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", client.DefaultCacheTTL, "Time the registry lookups are reused, 0 to always query the registries")
+	rootCmd.PersistentFlags().StringVar(&strategy, "strategy", string(types.StrategyStopFirst), "Update strategy of the containers without a gmd.update.strategy label: stop-first or start-first")
+	rootCmd.PersistentFlags().IntVar(&keepImages, "keep-images", containerupdate.Retention.Keep, "Number of previous images kept per repository after the updates, -1 to keep them all")
+	rootCmd.PersistentFlags().DurationVar(&keepFor, "keep-for", 0, "Also keep the previous images replaced for less than this period, such as 168h, beyond --keep-images")
+	rootCmd.Flags().IntVar(&parallelism, "parallel", 1, "Number of containers recreated at the same time by a batch update")
+}
+
+// debugLog sends the log of the commands without the TUI to the debug file, or discards it.
+func debugLog() error {
+	if debugfile == "" {
+		log.SetOutput(io.Discard)
+		return nil
+	}
+	f, err := os.OpenFile(debugfile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open debug file %s: %w", debugfile, err)
+	}
+	log.SetOutput(f)
+	return nil
+}
+
+// configureRegistryCache sets up the registry cache persisted in the user
+// cache directory, or in memory only when the directory is unknown.
+func configureRegistryCache() {
+	path, err := client.DefaultRegistryCachePath()
+	if err != nil {
+		path = ""
+	}
+	client.ConfigureRegistryCache(cacheTTL, path)
+}
+
+// configureStrategy sets the update strategy of the containers without a gmd.update.strategy label.
+func configureStrategy() error {
+	s, err := types.ParseUpdateStrategy(strategy)
+	if err != nil {
+		return err
+	}
+	containerupdate.Strategy = s
+	return nil
+}
+
+// dockerEndpoints returns the docker endpoints selected by the connection flags.
+// Without any host or context, the endpoint resolved from the environment is returned.
+func dockerEndpoints() ([]client.Endpoint, error) {
+	tls := tlsConfig()
+
+	if len(dockerHosts) == 0 && len(contexts) == 0 {
+		endpoint, err := client.ResolveEndpoint("", "", tls)
+		if err != nil {
+			return nil, err
+		}
+		return []client.Endpoint{endpoint}, nil
+	}
+
+	endpoints := make([]client.Endpoint, 0, len(dockerHosts)+len(contexts))
+	names := make(map[string]struct{})
+
+	add := func(host, context string) error {
+		endpoint, err := client.ResolveEndpoint(host, context, tls)
+		if err != nil {
+			return err
+		}
+		if _, ok := names[endpoint.Name()]; ok {
+			return fmt.Errorf("duplicate docker host %s", endpoint.Name())
+		}
+		names[endpoint.Name()] = struct{}{}
+		endpoints = append(endpoints, endpoint)
+		return nil
+	}
+
+	for _, host := range dockerHosts {
+		if err := add(host, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, context := range contexts {
+		if err := add("", context); err != nil {
+			return nil, err
+		}
+	}
+
+	return endpoints, nil
+}
+
+// tlsConfig returns the TLS configuration selected by the tls flags, or nil if TLS is not requested.
+func tlsConfig() *client.TLSConfig {
+	var tls *client.TLSConfig
+
+	if tlsEnabled || tlsVerify || tlsCACert != "" || tlsCert != "" || tlsKey != "" {
+		certPath := os.Getenv("DOCKER_CERT_PATH")
+		if certPath == "" {
+			certPath = client.ConfigDir()
+		}
+		tls = &client.TLSConfig{
+			CAFile:     flagOrDefault(tlsCACert, filepath.Join(certPath, "ca.pem")),
+			CertFile:   flagOrDefault(tlsCert, filepath.Join(certPath, "cert.pem")),
+			KeyFile:    flagOrDefault(tlsKey, filepath.Join(certPath, "key.pem")),
+			SkipVerify: !tlsVerify,
+		}
+	}
+
+	return tls
+}
+
+// flagOrDefault returns value if set, or def when the file it points to exists.
+func flagOrDefault(value, def string) string {
+	if value != "" {
+		return value
+	}
+	if _, err := os.Stat(def); err == nil {
+		return def
+	}
+	return ""
 }
@@ -1,67 +1,192 @@
 package cmd
 
 import (
-	"context"
+	"errors"
 	"fmt"
-	"log"
-	"runtime"
+	"io"
+	"strings"
+	"time"
 
-	"github.com/creativeprojects/go-selfupdate"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/tui/controllers/containerupdate"
 	"github.com/spf13/cobra"
 )
 
+var (
+	updateSelector selector
+	updateAll      bool
+	updateDryRun   bool
+	updateForce    bool
+	updateSelf     bool
+	updateCmd      = &cobra.Command{
+		Use:   "update [container...]",
+		Short: "Update the containers to the latest version of their image",
+		Long: `Update the containers to the latest version of their image without starting the TUI.
+
+The containers are selected by name, with --label, or all of them with --all.
+The image of every selected container is checked and, when the registry has a
+newer one, pulled and the container is recreated from it. A container following
+newer tags with the gmd.update.track label is updated to the newest one. A container whose
+update fails is rolled back to its previous version. The containers whose
+gmd.update labels disable the updates (gmd.update.enable=false or
+gmd.update.policy=pin) are skipped.
+
+The command exits with 1 when an update or a check failed.
+To update gmd itself, use gmd self-update.`,
+		SilenceUsage: true,
+		RunE:         runUpdate,
+	}
+)
+
 func init() {
+	updateSelector.addFlags(updateCmd)
+	updateCmd.Flags().BoolVarP(&updateAll, "all", "a", false, "Select all the containers")
+	updateCmd.Flags().BoolVarP(&updateDryRun, "dry-run", "n", false, "Only print the containers which would be updated")
+	updateCmd.Flags().BoolVarP(&updateForce, "force", "f", false, "Recreate the containers even when their image is up to date")
+	// gmd update used to update gmd itself
+	updateCmd.Flags().BoolVar(&updateSelf, "self", false, "Update gmd to its latest release")
+	updateCmd.Flags().MarkDeprecated("self", "use gmd self-update instead, --self will be removed in the next release")
 	rootCmd.AddCommand(updateCmd)
 }
 
-var updateCmd = &cobra.Command{
-	Use:   "update",
-	Short: "Update gmd",
-	Run: func(cmd *cobra.Command, args []string) {
+func runUpdate(cmd *cobra.Command, args []string) error {
+	if updateSelf {
+		return selfUpdate(version)
+	}
+	if len(args) == 0 && len(updateSelector.labels) == 0 && !updateAll {
+		return errors.New("no container selected: give container names, --label or --all\n" +
+			"gmd update now updates the containers, to update gmd itself run gmd self-update")
+	}
+	if updateAll && (len(args) > 0 || len(updateSelector.labels) > 0) {
+		return errors.New("--all cannot be used with container names or --label")
+	}
 
-		err := update(version)
-		if err != nil {
-			log.Fatal(err)
+	containerupdate.Source = "update"
+
+	clients, err := hostClients()
+	if err != nil {
+		return err
+	}
+
+	targets, err := selectContainers(clients, updateSelector, args)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		fmt.Fprintln(cmd.ErrOrStderr(), "no container selected")
+		return nil
+	}
+
+	out := cmd.OutOrStdout()
+	var updated, upToDate, skipped, failed int
+	for _, t := range targets {
+		if t.policy.Invalid != "" {
+			fmt.Fprintf(out, "%s: %s\n", t.label, t.policy.Invalid)
+			failed++
+			continue
+		}
+		if !t.policy.Checked() {
+			fmt.Fprintf(out, "%s: skipped, update policy %s\n", t.label, t.policy.Name())
+			skipped++
+			continue
 		}
 
-	},
-}
+		r := checkTargets([]target{t}, 1)[0]
+		switch {
+		case r.Error != "" && !updateForce:
+			fmt.Fprintf(out, "%s: check failed: %s\n", t.label, r.Error)
+			failed++
+			continue
+		case r.Error == "" && !r.available() && !updateForce:
+			fmt.Fprintf(out, "%s: %s is up to date\n", t.label, r.Image)
+			upToDate++
+			continue
+		}
 
-func update(version string) error {
+		image := r.targetImage()
+		if updateDryRun {
+			switch {
+			case r.Target != "":
+				fmt.Fprintf(out, "%s: would update %s to %s\n", t.label, r.Image, image)
+			case r.Update:
+				fmt.Fprintf(out, "%s: would update %s (%s → %s)\n", t.label, r.Image, shortDigest(r.LocalDigest), shortDigest(r.RemoteDigest))
+			default:
+				fmt.Fprintf(out, "%s: would recreate %s\n", t.label, r.Image)
+			}
+			updated++
+			continue
+		}
 
-	exe, err := selfupdate.ExecutablePath()
-	if err != nil {
-		return fmt.Errorf("error occurred while getting path to executable: %w", err)
+		inspect, err := t.cli.ContainerInspect(t.id)
+		if err != nil {
+			fmt.Fprintf(out, "%s: update failed: %v\n", t.label, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "%s: updating %s\n", t.label, image)
+		reporter := &plainReporter{out: out, name: t.label, layers: make(map[string]string)}
+		c := containerupdate.WithImage(types.Container{InspectResponse: inspect}, image)
+		if err := containerupdate.Update(t.cli, c, reporter); err != nil {
+			fmt.Fprintf(out, "%s: update failed: %v\n", t.label, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(out, "%s: updated\n", t.label)
+		updated++
 	}
 
-	updaterConfig := selfupdate.Config{
-		Validator: &selfupdate.ChecksumValidator{UniqueFilename: "checksums.txt"},
+	action := "updated"
+	if updateDryRun {
+		action = "to update"
 	}
+	fmt.Fprintf(out, "%d %s, %d up to date, %d skipped, %d failed\n", updated, action, upToDate, skipped, failed)
 
-	updater, err := selfupdate.NewUpdater(updaterConfig)
-	if err != nil {
-		return fmt.Errorf("error occurred while creating updater: %w", err)
+	if failed > 0 {
+		cmd.SilenceErrors = true
+		return &exitError{code: 1}
 	}
+	return nil
+}
 
-	fmt.Println("→ Checking for latest version...")
-	latest, found, err := updater.DetectLatest(context.Background(), selfupdate.ParseSlug("kernaxis/gmd"))
-	if err != nil {
-		return fmt.Errorf("an error occurred while detecting version: %w", err)
+// plainReporter prints the progress of an update as plain lines, prefixed by the container name.
+// The pull progress bars are left out, only the status changes of the layers are printed.
+type plainReporter struct {
+	out    io.Writer
+	name   string
+	layers map[string]string // layers is a map of layer IDs to their last printed status.
+}
+
+func (r *plainReporter) Pull(layer, status, progress string) {
+	if progress != "" || r.layers[layer] == status {
+		return
 	}
-	if !found {
-		return fmt.Errorf("latest version for %s/%s could not be found", runtime.GOOS, runtime.GOARCH)
+	r.layers[layer] = status
+	if layer == "" {
+		fmt.Fprintf(r.out, "%s: %s\n", r.name, status)
+		return
 	}
+	fmt.Fprintf(r.out, "%s: %s: %s\n", r.name, layer, status)
+}
 
-	if latest.LessOrEqual(version) {
-		fmt.Println("✔ Already up to date:", latest.Version())
-		return nil
+func (r *plainReporter) Step(label, name string) func(err error) {
+	label = strings.ToLower(label[:1]) + label[1:]
+	return func(err error) {
+		if err != nil {
+			fmt.Fprintf(r.out, "%s: %s %s: failed: %v\n", r.name, label, name, err)
+			return
+		}
+		fmt.Fprintf(r.out, "%s: %s %s: done\n", r.name, label, name)
 	}
+}
 
-	fmt.Println("→ New version available:", latest.Version())
+func (r *plainReporter) Error(text string) {
+	fmt.Fprintf(r.out, "%s: %s\n", r.name, text)
+}
 
-	if err := updater.UpdateTo(context.Background(), latest, exe); err != nil {
-		return fmt.Errorf("error occurred while updating binary: %w", err)
-	}
-	fmt.Printf("✔ Successfully updated to version %s\n", latest.Version())
-	return nil
+func (r *plainReporter) Warning(text string) {
+	fmt.Fprintf(r.out, "%s: warning: %s\n", r.name, text)
+}
+
+func (r *plainReporter) Downtime(d time.Duration) {
+	fmt.Fprintf(r.out, "%s: downtime %s\n", r.name, d.Round(time.Millisecond))
 }
@@ -0,0 +1,352 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/schedule"
+	"github.com/kernaxis/gmd/tui/controllers/containerupdate"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchSelector    selector
+	watchAll         bool
+	watchSchedule    string
+	watchWindows     []string
+	watchOnce        bool
+	watchDryRun      bool
+	watchConcurrency int
+	watchLogFormat   string
+	watchLogLevel    string
+	watchCmd         = &cobra.Command{
+		Use:   "watch [container...]",
+		Short: "Check and update the containers on a schedule",
+		Long: `Check the containers for image updates on a schedule and update them, without the TUI.
+
+The watched containers are the ones opted in with the label gmd.update.enable=true
+or gmd.update.policy=auto, or the ones selected by name, with --label, or all
+of them with --all. The labels of the containers set their update policy:
+gmd.update.enable=false and gmd.update.policy=pin disable the checks,
+gmd.update.policy=notify only logs the available updates, and
+gmd.update.schedule gives a container its own schedule.
+
+The schedule is an interval ("6h", "@every 30m"), a shortcut (@hourly, @daily,
+@weekly) or a cron expression ("0 4 * * *"), in the local time zone.
+With --window, the updates are only applied inside the maintenance windows,
+such as "mon-fri 02:00-04:00" or "sat,sun 22:00-06:00". The updates found
+outside the windows are applied when the next window opens.
+
+The results are logged to stderr as structured logs, in text or JSON.`,
+		SilenceUsage: true,
+		RunE:         runWatch,
+	}
+)
+
+func init() {
+	watchSelector.addFlags(watchCmd)
+	watchCmd.Flags().BoolVarP(&watchAll, "all", "a", false, "Watch all the containers")
+	watchCmd.Flags().StringVarP(&watchSchedule, "schedule", "s", "24h", "Interval or cron expression of the checks")
+	watchCmd.Flags().StringArrayVarP(&watchWindows, "window", "w", nil, "Maintenance window allowing the updates ([days] HH:MM-HH:MM), can be repeated")
+	watchCmd.Flags().BoolVar(&watchOnce, "once", false, "Run a single check and exit")
+	watchCmd.Flags().BoolVarP(&watchDryRun, "dry-run", "n", false, "Only log the updates which would be applied")
+	watchCmd.Flags().IntVarP(&watchConcurrency, "concurrency", "j", 4, "Number of checks run at the same time")
+	watchCmd.Flags().StringVar(&watchLogFormat, "log-format", "text", "Log format: text or json")
+	watchCmd.Flags().StringVar(&watchLogLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	rootCmd.AddCommand(watchCmd)
+}
+
+// watcher runs the scheduled checks and updates.
+type watcher struct {
+	logger   *slog.Logger
+	clients  map[string]client.Client // clients is a map of host names to their client.
+	hosts    []client.Client
+	selector selector
+	names    []string
+	windows  []schedule.Window
+	dryRun   bool
+	due      map[string]time.Time // due is a map of container keys to the next run of their own schedule, nil to ignore these schedules.
+	pending  map[string]bool      // pending is the set of container keys whose update waits for a maintenance window, nil to not wait for them.
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if watchAll && (len(args) > 0 || len(watchSelector.labels) > 0) {
+		return errors.New("--all cannot be used with container names or --label")
+	}
+
+	sched, err := schedule.Parse(watchSchedule)
+	if err != nil {
+		return err
+	}
+	windows := make([]schedule.Window, 0, len(watchWindows))
+	for _, text := range watchWindows {
+		w, err := schedule.ParseWindow(text)
+		if err != nil {
+			return err
+		}
+		windows = append(windows, w)
+	}
+	logger, err := newLogger(cmd.ErrOrStderr(), watchLogFormat, watchLogLevel)
+	if err != nil {
+		return err
+	}
+	containerupdate.Source = "watch"
+
+	clients, err := hostClients()
+	if err != nil {
+		return err
+	}
+
+	w := watcher{
+		logger:   logger,
+		clients:  make(map[string]client.Client, len(clients)),
+		hosts:    clients,
+		selector: watchSelector,
+		names:    args,
+		windows:  windows,
+		dryRun:   watchDryRun,
+	}
+	if !watchOnce {
+		w.due = make(map[string]time.Time)
+		w.pending = make(map[string]bool)
+	}
+	for _, cli := range clients {
+		w.clients[cli.Endpoint().Name()] = cli
+	}
+	if len(args) == 0 && len(watchSelector.labels) == 0 && !watchAll {
+		w.selector.optIn = true
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info("watch started", "schedule", watchSchedule, "windows", strings.Join(watchWindows, "; "), "dry_run", watchDryRun)
+	var nextGlobal time.Time
+	for {
+		global := !time.Now().Before(nextGlobal)
+		w.run(ctx, global)
+		if watchOnce {
+			return nil
+		}
+
+		if global {
+			nextGlobal = sched.Next(time.Now())
+			if nextGlobal.IsZero() {
+				return fmt.Errorf("schedule %q never runs", watchSchedule)
+			}
+		}
+		next := nextGlobal
+		for _, due := range w.due {
+			if due.Before(next) {
+				next = due
+			}
+		}
+		// the updates waiting for a window are applied once it opens
+		if len(w.pending) > 0 {
+			if open := schedule.NextOpen(w.windows, time.Now()); !open.IsZero() && open.Before(next) {
+				next = open
+			}
+		}
+		logger.Info("next check", "at", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Info("watch stopped")
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// run checks the watched containers and updates the outdated ones. The
+// containers with their own schedule are handled when it is due, the other
+// ones on the runs of the global schedule. The containers whose update waits
+// for a maintenance window are handled as soon as one is open.
+func (w watcher) run(ctx context.Context, global bool) {
+	started := time.Now()
+	selected, err := selectContainers(w.hosts, w.selector, w.names)
+	if err != nil {
+		w.logger.Error("check failed", "error", err)
+		return
+	}
+
+	var failed int
+	targets := make([]target, 0, len(selected))
+	keys := make(map[string]bool, len(selected))
+	for _, t := range selected {
+		logger := w.logger.With("host", t.host, "container", t.name, "image", t.image)
+		k := t.host + "/" + t.id
+		keys[k] = true
+		waiting := w.pending[k] && schedule.Open(w.windows, started)
+		switch {
+		case t.policy.Invalid != "":
+			logger.Warn("invalid update policy", "error", t.policy.Invalid)
+			failed++
+		case !t.policy.Checked():
+			logger.Debug("skipped", "policy", t.policy.Name())
+		case t.policy.Schedule != "" && w.due != nil:
+			due, ok := w.due[k]
+			if (ok && !started.Before(due)) || waiting {
+				targets = append(targets, t)
+			}
+			if !ok || !started.Before(due) {
+				sched, _ := schedule.Parse(t.policy.Schedule)
+				w.due[k] = sched.Next(started)
+				logger.Debug("container schedule", "schedule", t.policy.Schedule, "next", w.due[k].Format(time.RFC3339))
+			}
+		case global || waiting:
+			targets = append(targets, t)
+		}
+	}
+	// forget the schedules and the pending updates of the removed containers
+	for k := range w.due {
+		if !keys[k] {
+			delete(w.due, k)
+		}
+	}
+	for k := range w.pending {
+		if !keys[k] {
+			delete(w.pending, k)
+		}
+	}
+	if len(targets) == 0 && !global {
+		return
+	}
+
+	results := checkTargets(targets, watchConcurrency)
+	var updates, updated int
+	for i, r := range results {
+		logger := w.logger.With("host", r.Host, "container", r.Container, "image", r.Image)
+		k := targets[i].host + "/" + targets[i].id
+		delete(w.pending, k)
+		switch {
+		case r.Error != "":
+			logger.Warn("check failed", "error", r.Error)
+			failed++
+			continue
+		case !r.available():
+			logger.Debug("up to date", "digest", r.LocalDigest)
+			continue
+		}
+
+		updates++
+		logger = logger.With("local_digest", r.LocalDigest, "remote_digest", r.RemoteDigest)
+		if r.Target != "" {
+			logger = logger.With("target", r.targetImage())
+		}
+		if ctx.Err() != nil {
+			logger.Info("update skipped, watch stopping")
+			continue
+		}
+		if !targets[i].policy.Unattended() {
+			logger.Info("update available", "policy", targets[i].policy.Name())
+			continue
+		}
+		if !schedule.Open(w.windows, time.Now()) {
+			logger.Info("update available, waiting for a maintenance window")
+			if w.pending != nil {
+				w.pending[k] = true
+			}
+			continue
+		}
+		if w.dryRun {
+			logger.Info("update available")
+			continue
+		}
+
+		if err := w.update(r, logger); err != nil {
+			logger.Error("update failed", "error", err)
+			failed++
+			continue
+		}
+		logger.Info("container updated")
+		updated++
+	}
+
+	w.logger.Info("check finished",
+		"containers", len(results),
+		"global", global,
+		"updates", updates,
+		"updated", updated,
+		"failed", failed,
+		"duration", time.Since(started).Round(time.Millisecond).String())
+}
+
+// update runs the update pipeline on the container of the check result.
+func (w watcher) update(r checkResult, logger *slog.Logger) error {
+	cli := w.clients[r.Host]
+	inspect, err := cli.ContainerInspect(r.ID)
+	if err != nil {
+		return err
+	}
+	logger.Info("updating container")
+	c := containerupdate.WithImage(types.Container{InspectResponse: inspect}, r.targetImage())
+	return containerupdate.Update(cli, c, &logReporter{logger: logger, layers: make(map[string]string)})
+}
+
+// newLogger returns the structured logger writing to out.
+func newLogger(out io.Writer, format, level string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: l}
+
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(out, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(out, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// logReporter logs the progress of an update, the pull and the steps at the debug level.
+type logReporter struct {
+	logger *slog.Logger
+	layers map[string]string // layers is a map of layer IDs to their last logged status.
+}
+
+func (r *logReporter) Pull(layer, status, progress string) {
+	if progress != "" || r.layers[layer] == status {
+		return
+	}
+	r.layers[layer] = status
+	r.logger.Debug("pull", "layer", layer, "status", status)
+}
+
+func (r *logReporter) Step(label, name string) func(err error) {
+	started := time.Now()
+	return func(err error) {
+		if err != nil {
+			r.logger.Warn("step failed", "step", label, "target", name, "error", err)
+			return
+		}
+		r.logger.Debug("step done", "step", label, "target", name, "duration", time.Since(started).Round(time.Millisecond).String())
+	}
+}
+
+func (r *logReporter) Error(text string) {
+	r.logger.Warn(text)
+}
+
+func (r *logReporter) Warning(text string) {
+	r.logger.Warn(text)
+}
+
+func (r *logReporter) Downtime(d time.Duration) {
+	r.logger.Info("downtime", "duration", d.Round(time.Millisecond).String())
+}
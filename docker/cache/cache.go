@@ -1,9 +1,10 @@
-// Package cache provides a cache of Docker containers and images.
+// Package cache provides a cache of Docker containers, images, volumes and networks.
 // It also provides a mechanism to receive events from Docker and
 // update the cache accordingly.
 package cache
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/docker/docker/api/types/events"
@@ -11,12 +12,15 @@ import (
 	"github.com/kernaxis/gmd/docker/types"
 )
 
-// Cache represents a cache of Docker containers and images.
+// Cache represents a cache of Docker containers, images, volumes and networks.
 type Cache struct {
-	cli               *client.Client              // cli is the Docker client used to interact with the Docker daemon.
+	host              string                      // host is the name of the Docker host the cache is bound to.
+	cli               client.Client               // cli is the Docker client used to interact with the Docker daemon.
 	mu                sync.RWMutex                // mu is a read-write mutex used to protect access to the cache.
 	images            map[string]*types.Image     // images is a map of image IDs to their corresponding corresponding Image objects.
 	containers        map[string]*types.Container // containers is a map of container IDs to their respective Container objects.
+	volumes           map[string]*types.Volume    // volumes is a map of volume names to their respective Volume objects.
+	networks          map[string]*types.Network   // networks is a map of network IDs to their respective Network objects.
 	ievents           <-chan events.Message       // ieEvents is a channel of events received from Docker.
 	ierrors           <-chan error                // ierrors is a channel of errors received from Docker.
 	events            chan Event                  // events is a channel of events generated by the cache, such as when the cache is updated or when a container is deleted.
@@ -24,11 +28,14 @@ type Cache struct {
 }
 
 // NewCache returns a new Cache object.
-func NewCache(cli *client.Client) *Cache {
+func NewCache(cli client.Client) *Cache {
 	c := &Cache{
+		host:              cli.Endpoint().Name(),
 		cli:               cli,
 		images:            make(map[string]*types.Image),
 		containers:        make(map[string]*types.Container),
+		volumes:           make(map[string]*types.Volume),
+		networks:          make(map[string]*types.Network),
 		events:            make(chan Event, 20),
 		containerDeletion: make(chan string, 20),
 	}
@@ -36,28 +43,53 @@ func NewCache(cli *client.Client) *Cache {
 	return c
 }
 
+// Host returns the name of the Docker host the cache is bound to.
+func (c *Cache) Host() string {
+	return c.host
+}
+
+// Client returns the Docker client used by the cache.
+func (c *Cache) Client() client.Client {
+	return c.cli
+}
+
 // LoadAndStart loads the cache with the current state of the Docker daemon
 // and starts listening for events.
+// It returns an error if the daemon cannot be reached.
 func (c *Cache) LoadAndStart() error {
 	c.ievents, c.ierrors = c.cli.StartEvents()
 
-	imgs := c.snapshotImages()
+	imgs, err := c.snapshotImages()
+	if err != nil {
+		return fmt.Errorf("unable to load images from %s: %w", c.host, err)
+	}
 	c.mu.Lock()
 	for _, img := range imgs {
 		c.images[img.ID] = img
 	}
 	c.mu.Unlock()
 
-	c.events <- Event{EventType: ImagesLoadedEventType}
+	c.sendEvent(Event{EventType: ImagesLoadedEventType})
 
-	conts := c.snapshotContainers()
+	conts, err := c.snapshotContainers()
+	if err != nil {
+		return fmt.Errorf("unable to load containers from %s: %w", c.host, err)
+	}
 	c.mu.Lock()
 	for _, cont := range conts {
 		c.containers[cont.ID] = cont
 	}
 	c.mu.Unlock()
 
-	c.events <- Event{EventType: ContainersLoadedEventType}
+	c.sendEvent(Event{EventType: ContainersLoadedEventType})
+
+	if err := c.RefreshVolumes(); err != nil {
+		return fmt.Errorf("unable to load volumes from %s: %w", c.host, err)
+	}
+
+	if err := c.RefreshNetworks(); err != nil {
+		return fmt.Errorf("unable to load networks from %s: %w", c.host, err)
+	}
 
 	go c.listenEvents()
 	go c.containerDeleteWorker()
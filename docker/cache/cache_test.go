@@ -0,0 +1,212 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/kernaxis/gmd/docker/client/fake"
+)
+
+// start loads a cache of the engine and stops its events at the end of the test.
+func start(t *testing.T, e *fake.Engine) *Cache {
+	t.Helper()
+	c := NewCache(e)
+	done := make(chan error)
+	go func() { done <- c.LoadAndStart() }()
+	// the loaded events are sent while loading
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(e.StopEvents)
+			return c
+		case <-c.Events():
+		}
+	}
+}
+
+// waitFor handles the events of the cache until cond holds.
+func waitFor(t *testing.T, c *Cache, what string, cond func() bool) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for !cond() {
+		select {
+		case ev := <-c.Events():
+			if ev.Host != "fake" {
+				t.Errorf("event from %q, want fake", ev.Host)
+			}
+		case <-timeout:
+			t.Fatalf("timeout waiting for %s", what)
+		}
+	}
+}
+
+func TestLoadAndStart(t *testing.T) {
+	e := fake.New()
+	e.AddContainer("web", "nginx:1", true)
+	e.AddContainer("db", "postgres:16", false)
+	e.AddVolume("data", nil, 1024)
+	if _, err := e.AddNetwork("front", "bridge", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	c := start(t, e)
+
+	if got := len(c.Containers()); got != 2 {
+		t.Errorf("%d containers, want 2", got)
+	}
+	if got := len(c.Images()); got != 2 {
+		t.Errorf("%d images, want 2", got)
+	}
+	v, err := c.Volume("data")
+	if err != nil || v.UsageData == nil || v.UsageData.Size != 1024 {
+		t.Errorf("Volume(data) = %+v, %v, want its usage of 1024 bytes", v, err)
+	}
+	// bridge, host, none and front
+	if got := len(c.Networks()); got != 4 {
+		t.Errorf("%d networks, want 4", got)
+	}
+}
+
+func TestLoadAndStartFailure(t *testing.T) {
+	tests := []struct {
+		op   fake.Operation
+		want string
+	}{
+		{fake.OpImageList, "unable to load images from fake"},
+		{fake.OpContainerList, "unable to load containers from fake"},
+		{fake.OpContainerInspect, "unable to load containers from fake"},
+		{fake.OpVolumeList, "unable to load volumes from fake"},
+		{fake.OpNetworkList, "unable to load networks from fake"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.op), func(t *testing.T) {
+			e := fake.New()
+			e.AddContainer("web", "nginx:1", true)
+			e.AddVolume("data", nil, 0)
+			failure := errors.New("daemon unreachable")
+			e.FailOn(tt.op, failure)
+
+			c := NewCache(e)
+			go func() {
+				for range c.Events() {
+				}
+			}()
+			err := c.LoadAndStart()
+			if !errors.Is(err, failure) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadAndStart() = %v, want %q wrapping %v", err, tt.want, failure)
+			}
+		})
+	}
+}
+
+func TestContainerEvents(t *testing.T) {
+	e := fake.New()
+	c := start(t, e)
+
+	id := e.AddContainer("web", "nginx:1", false)
+	waitFor(t, c, "the created container", func() bool {
+		cont, err := c.Container(id)
+		return err == nil && cont.Name == "/web" && !cont.State.Running
+	})
+
+	if err := e.StartContainer(id); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, c, "the started container", func() bool {
+		cont, _ := c.Container(id)
+		return cont.State != nil && cont.State.Running
+	})
+
+	if err := e.RenameContainer(id, "front"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, c, "the renamed container", func() bool {
+		cont, _ := c.Container(id)
+		return cont.ContainerJSONBase != nil && cont.Name == "/front"
+	})
+
+	if err := e.SetLabels(id, map[string]string{"tier": "front"}); err != nil {
+		t.Fatal(err)
+	}
+	e.Emit(events.Message{Type: events.ContainerEventType, Action: events.ActionUpdate, Actor: events.Actor{ID: id}})
+	waitFor(t, c, "the updated labels", func() bool {
+		cont, _ := c.Container(id)
+		return cont.Config != nil && cont.Config.Labels["tier"] == "front"
+	})
+
+	if err := e.StopContainer(id); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.DeleteContainer(id); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, c, "the removal of the container", func() bool {
+		_, err := c.Container(id)
+		return errors.Is(err, ErrContainerNotFound)
+	})
+}
+
+func TestContainerEventInspectFailure(t *testing.T) {
+	e := fake.New()
+	id := e.AddContainer("web", "nginx:1", true)
+	c := start(t, e)
+
+	// a container which cannot be inspected anymore is left out
+	e.FailOn(fake.OpContainerInspect, errors.New("daemon unreachable"))
+	if err := e.RestartContainer(id); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, c, "the removal of the container", func() bool {
+		_, err := c.Container(id)
+		return errors.Is(err, ErrContainerNotFound)
+	})
+}
+
+func TestImageEvents(t *testing.T) {
+	e := fake.New()
+	c := start(t, e)
+
+	id := e.AddImage("nginx:1", "sha256:n1")
+	e.Emit(events.Message{Type: events.ImageEventType, Action: events.ActionPull, Actor: events.Actor{ID: id}})
+	waitFor(t, c, "the pulled image", func() bool {
+		img, err := c.Image(id)
+		return err == nil && len(img.RepoTags) == 1 && img.RepoTags[0] == "nginx:1"
+	})
+
+	if err := e.DeleteImage(t.Context(), id); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, c, "the removal of the image", func() bool {
+		_, err := c.Image(id)
+		return err != nil
+	})
+}
+
+func TestVolumeEvents(t *testing.T) {
+	e := fake.New()
+	e.AddVolume("data", nil, 2048)
+	c := start(t, e)
+
+	e.AddVolume("logs", nil, 0)
+	waitFor(t, c, "the created volume", func() bool {
+		_, err := c.Volume("logs")
+		return err == nil
+	})
+
+	if err := e.DeleteVolume(t.Context(), "logs"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, c, "the removal of the volume", func() bool {
+		_, err := c.Volume("logs")
+		return err != nil
+	})
+	if v, err := c.Volume("data"); err != nil || v.UsageData == nil || v.UsageData.Size != 2048 {
+		t.Errorf("Volume(data) = %+v, %v, want its usage kept", v, err)
+	}
+}
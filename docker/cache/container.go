@@ -68,10 +68,10 @@ func (c *Cache) refreshContainer(ev events.Message) {
 	c.mu.Unlock()
 }
 
-func (c *Cache) snapshotContainers() []*types.Container {
+func (c *Cache) snapshotContainers() ([]*types.Container, error) {
 	ctnrs, err := c.cli.ContainerList()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	containers := make([]*types.Container, len(ctnrs))
@@ -79,14 +79,14 @@ func (c *Cache) snapshotContainers() []*types.Container {
 	for i, container := range ctnrs {
 		inspect, err := c.cli.ContainerInspect(container.ID)
 		if err != nil {
-			panic(err)
+			return nil, err
 		}
 		containers[i] = &types.Container{
 			InspectResponse: inspect,
 		}
 	}
 
-	return containers
+	return containers, nil
 }
 
 func (c *Cache) containerDeleteWorker() {
@@ -100,7 +100,7 @@ func (c *Cache) containerDeleteWorker() {
 				delete(c.containers, id)
 				c.mu.Unlock()
 
-				c.events <- Event{EventType: ContainerEventType, ActorID: id}
+				c.sendEvent(Event{EventType: ContainerEventType, ActorID: id})
 				break
 			}
 			log.Printf("deleted container %s is still there: %+v", id, cont)
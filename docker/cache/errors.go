@@ -5,4 +5,6 @@ import "fmt"
 var (
 	ErrContainerNotFound = fmt.Errorf("container not found")
 	ErrImageNotFound     = fmt.Errorf("image not found")
+	ErrVolumeNotFound    = fmt.Errorf("volume not found")
+	ErrNetworkNotFound   = fmt.Errorf("network not found")
 )
@@ -14,9 +14,14 @@ const (
 	ImageEventType            EventType = EventType(events.ImageEventType)
 	ContainersLoadedEventType EventType = "containers-loaded"
 	ContainerEventType        EventType = EventType(events.ContainerEventType)
+	VolumesLoadedEventType    EventType = "volumes-loaded"
+	VolumeEventType           EventType = EventType(events.VolumeEventType)
+	NetworksLoadedEventType   EventType = "networks-loaded"
+	NetworkEventType          EventType = EventType(events.NetworkEventType)
 )
 
 type Event struct {
+	Host      string // Host is the name of the Docker host the event comes from.
 	EventType EventType
 	ActorID   string
 }
@@ -28,6 +33,12 @@ func (c *Cache) Events() <-chan Event {
 	return c.events
 }
 
+// sendEvent tags the event with the host of the cache and publishes it.
+func (c *Cache) sendEvent(ev Event) {
+	ev.Host = c.host
+	c.events <- ev
+}
+
 func (c *Cache) listenEvents() {
 	for {
 		select {
@@ -37,7 +48,7 @@ func (c *Cache) listenEvents() {
 			}
 			log.Printf("lib docker - received event: %+v", msg)
 			if ev, err := c.handleEvent(msg); err == nil {
-				c.events <- ev
+				c.sendEvent(ev)
 			}
 		case <-c.ierrors:
 			//	m.errsCh <- err
@@ -66,6 +77,30 @@ func (c *Cache) handleEvent(e events.Message) (Event, error) {
 			EventType: ImageEventType,
 			ActorID:   e.Actor.ID,
 		}, nil
+
+	case events.VolumeEventType:
+		log.Printf("lib docker - received volume event: %+v", e)
+		if e.Action == events.ActionPrune {
+			if err := c.loadVolumes(); err != nil {
+				return Event{}, err
+			}
+			return Event{EventType: VolumesLoadedEventType}, nil
+		}
+		c.refreshVolume(e)
+
+		return Event{
+			EventType: VolumeEventType,
+			ActorID:   e.Actor.ID,
+		}, nil
+
+	case events.NetworkEventType:
+		log.Printf("lib docker - received network event: %+v", e)
+		c.refreshNetwork(e)
+
+		return Event{
+			EventType: NetworkEventType,
+			ActorID:   e.Actor.ID,
+		}, nil
 	}
 
 	return Event{}, fmt.Errorf("unhandled event")
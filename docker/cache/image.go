@@ -112,10 +112,11 @@ func (c *Cache) refreshImage(id string) {
 // Next, it iterates over the list of images again and updates the
 // parents of each image by looking up the parent ID in the map.
 // Finally, it flattens the map into a slice and returns the slice.
-func (c *Cache) snapshotImages() []*types.Image {
+// It returns an error if the images cannot be listed.
+func (c *Cache) snapshotImages() ([]*types.Image, error) {
 	list, err := c.cli.ImageList()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	out := make(map[string]*types.Image)
@@ -164,5 +165,5 @@ func (c *Cache) snapshotImages() []*types.Image {
 		result = append(result, img)
 	}
 
-	return result
+	return result, nil
 }
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+// Networks returns the list of networks from the cache, sorted by name.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+func (c *Cache) Networks() []types.Network {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make([]types.Network, 0, len(c.networks))
+	for _, n := range c.networks {
+		out = append(out, *n)
+	}
+
+	slices.SortFunc(out, func(a, b types.Network) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out
+}
+
+// Network returns the network with the given ID from the cache.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+// If the network is not found, ErrNetworkNotFound is returned.
+func (c *Cache) Network(id string) (types.Network, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if n, ok := c.networks[id]; ok {
+		return *n, nil
+	}
+	return types.Network{}, ErrNetworkNotFound
+}
+
+// RefreshNetworks reloads all the networks from the daemon.
+// It sends a NetworksLoadedEventType event once done.
+func (c *Cache) RefreshNetworks() error {
+	nets, err := c.snapshotNetworks()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.networks = make(map[string]*types.Network, len(nets))
+	for _, n := range nets {
+		c.networks[n.ID] = n
+	}
+	c.mu.Unlock()
+
+	c.sendEvent(Event{EventType: NetworksLoadedEventType})
+	return nil
+}
+
+// refreshNetwork refreshes the cache with the given network event.
+// The network is inspected again and removed from the cache if it does not exist anymore.
+// When a container is connected or disconnected, the container is refreshed as
+// well, since its network settings changed, and a ContainerEventType event is sent.
+func (c *Cache) refreshNetwork(ev events.Message) {
+	if id := ev.Actor.Attributes["container"]; id != "" &&
+		(ev.Action == events.ActionConnect || ev.Action == events.ActionDisconnect) {
+		if _, err := c.Container(id); err == nil {
+			c.refreshContainer(events.Message{Type: events.ContainerEventType, Actor: events.Actor{ID: id}})
+			c.sendEvent(Event{EventType: ContainerEventType, ActorID: id})
+		}
+	}
+
+	n, err := c.cli.NetworkInspect(ev.Actor.ID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		log.Printf("refresh network %s, delete network: %v", ev.Actor.ID, err)
+		delete(c.networks, ev.Actor.ID)
+		return
+	}
+	c.networks[n.ID] = &types.Network{Inspect: n}
+}
+
+// snapshotNetworks lists the networks of the daemon and inspects each of them,
+// as the list does not report the connected containers.
+func (c *Cache) snapshotNetworks() ([]*types.Network, error) {
+	list, err := c.cli.NetworkList()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*types.Network, 0, len(list))
+	for _, summary := range list {
+		n, err := c.cli.NetworkInspect(summary.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, &types.Network{Inspect: n})
+	}
+	return out, nil
+}
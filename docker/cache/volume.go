@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+// Volumes returns the list of volumes from the cache, sorted by name.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+func (c *Cache) Volumes() []types.Volume {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := make([]types.Volume, 0, len(c.volumes))
+	for _, v := range c.volumes {
+		out = append(out, *v)
+	}
+
+	slices.SortFunc(out, func(a, b types.Volume) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out
+}
+
+// Volume returns the volume with the given name from the cache.
+// The function locks the cache for reading and returns a copy of the underlying data, so it can be safely used without taking a write lock on the cache.
+// If the volume is not found, ErrVolumeNotFound is returned.
+func (c *Cache) Volume(name string) (types.Volume, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if v, ok := c.volumes[name]; ok {
+		return *v, nil
+	}
+	return types.Volume{}, ErrVolumeNotFound
+}
+
+// VolumeUsers returns the names of the containers mounting the volume with the given name.
+// The names are derived from the mounts of the cached containers and are sorted.
+func (c *Cache) VolumeUsers(name string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var out []string
+	for _, cont := range c.containers {
+		for _, m := range cont.Mounts {
+			if m.Type == mount.TypeVolume && m.Name == name {
+				out = append(out, strings.TrimPrefix(cont.Name, "/"))
+				break
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// VolumesUnused returns the list of volumes not mounted by any container, sorted by name.
+func (c *Cache) VolumesUnused() []types.Volume {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	used := make(map[string]bool)
+	for _, cont := range c.containers {
+		for _, m := range cont.Mounts {
+			if m.Type == mount.TypeVolume {
+				used[m.Name] = true
+			}
+		}
+	}
+
+	out := make([]types.Volume, 0, len(c.volumes))
+	for _, v := range c.volumes {
+		if !used[v.Name] {
+			out = append(out, *v)
+		}
+	}
+
+	slices.SortFunc(out, func(a, b types.Volume) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out
+}
+
+// RefreshVolumes reloads all the volumes and their disk usage from the daemon.
+// It sends a VolumesLoadedEventType event once done.
+func (c *Cache) RefreshVolumes() error {
+	if err := c.loadVolumes(); err != nil {
+		return err
+	}
+	c.sendEvent(Event{EventType: VolumesLoadedEventType})
+	return nil
+}
+
+// loadVolumes replaces the volumes of the cache with a fresh snapshot.
+func (c *Cache) loadVolumes() error {
+	vols, err := c.snapshotVolumes()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.volumes = make(map[string]*types.Volume, len(vols))
+	for _, v := range vols {
+		c.volumes[v.Name] = v
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// refreshVolume refreshes the cache with the given volume event.
+// The volume is inspected again and removed from the cache if it does not exist anymore.
+// The disk usage of an existing volume is kept, as computing it is expensive.
+func (c *Cache) refreshVolume(ev events.Message) {
+	v, err := c.cli.VolumeInspect(ev.Actor.ID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		log.Printf("refresh volume %s, delete volume: %v", ev.Actor.ID, err)
+		delete(c.volumes, ev.Actor.ID)
+		return
+	}
+
+	vol := &types.Volume{Volume: v}
+	if old, ok := c.volumes[v.Name]; ok {
+		vol.UsageData = old.UsageData
+	}
+	c.volumes[v.Name] = vol
+}
+
+// snapshotVolumes lists the volumes of the daemon along with their disk usage.
+// A failure to compute the disk usage is logged and the volumes are returned without usage data.
+func (c *Cache) snapshotVolumes() ([]*types.Volume, error) {
+	list, err := c.cli.VolumeList()
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := c.cli.VolumesDiskUsage()
+	if err != nil {
+		log.Printf("volumes disk usage: %v", err)
+	}
+
+	out := make([]*types.Volume, 0, len(list))
+	for _, v := range list {
+		vol := &types.Volume{Volume: *v}
+		if u, ok := usage[v.Name]; ok {
+			vol.UsageData = &u
+		}
+		out = append(out, vol)
+	}
+	return out, nil
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/errdefs"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// ErrAuthRequired is wrapped by the errors of the registries requiring
+// credentials which are missing or refused.
+var ErrAuthRequired = errors.New("authentication required")
+
+// remoteAuth is the option authenticating the registry requests with the
+// credentials of the Docker configuration (~/.docker/config.json) and its
+// credential helpers.
+func remoteAuth() remote.Option {
+	return remote.WithAuthFromKeychain(authn.DefaultKeychain)
+}
+
+// registryAuth returns the encoded credentials sent to the daemon to pull the image,
+// empty when the Docker configuration has none for its registry.
+func registryAuth(ctx context.Context, imageRef string) (string, error) {
+	ref, err := name.ParseReference(imageRef)
+	if err != nil {
+		return "", err
+	}
+	authenticator, err := authn.DefaultKeychain.Resolve(ref.Context())
+	if err != nil {
+		return "", fmt.Errorf("unable to read the credentials of %s: %w", ref.Context().RegistryStr(), err)
+	}
+	if authenticator == authn.Anonymous {
+		return "", nil
+	}
+	config, err := authn.Authorization(ctx, authenticator)
+	if err != nil {
+		return "", fmt.Errorf("unable to read the credentials of %s: %w", ref.Context().RegistryStr(), err)
+	}
+	return registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      config.Username,
+		Password:      config.Password,
+		Auth:          config.Auth,
+		IdentityToken: config.IdentityToken,
+		RegistryToken: config.RegistryToken,
+		ServerAddress: ref.Context().RegistryStr(),
+	})
+}
+
+// authError wraps err with ErrAuthRequired when the registry of the image
+// refused the request for lack of valid credentials.
+func authError(imageRef string, err error) error {
+	if err == nil || errors.Is(err, ErrAuthRequired) || !isAuthError(err) {
+		return err
+	}
+	registry := "registry"
+	if ref, perr := name.ParseReference(imageRef); perr == nil {
+		registry = ref.Context().RegistryStr()
+	}
+	return fmt.Errorf("%w by %s: %w", ErrAuthRequired, registry, err)
+}
+
+// isAuthError reports whether the error of a registry or of the daemon is an authentication error.
+func isAuthError(err error) bool {
+	var terr *transport.Error
+	if errors.As(err, &terr) {
+		if terr.StatusCode == http.StatusUnauthorized || terr.StatusCode == http.StatusForbidden {
+			return true
+		}
+		for _, e := range terr.Errors {
+			if e.Code == transport.UnauthorizedErrorCode || e.Code == transport.DeniedErrorCode {
+				return true
+			}
+		}
+		return false
+	}
+	if errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) {
+		return true
+	}
+	// the daemon reports the refused pulls in the message only
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"unauthorized", "authentication required", "pull access denied", "no basic auth credentials", "denied: "} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,338 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// DefaultCacheTTL is the time the registry lookups are reused before being requested again.
+const DefaultCacheTTL = 15 * time.Minute
+
+const (
+	maxRegistryRequests = 4                  // maxRegistryRequests is the number of registry requests run at the same time.
+	rateLimitBackoff    = time.Hour          // rateLimitBackoff is the pause of a rate limited registry which tells no delay.
+	cacheRetention      = 7 * 24 * time.Hour // cacheRetention is the age after which the stale lookups are dropped from the cache file.
+)
+
+// ErrRateLimited is wrapped by the errors of the lookups refused because
+// their registry rate limited the previous requests.
+var ErrRateLimited = errors.New("rate limited")
+
+// registryCache caches the digests and the tags looked up in the registries.
+// The concurrent lookups of the same key share a single request, the requests
+// are bounded, and the registries announcing a rate limit are not requested
+// until it is lifted. The cache is persisted in its file, if any.
+type registryCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	path     string                // path is the cache file, empty to keep the cache in memory.
+	loaded   bool                  // loaded is set once the cache file has been read.
+	entries  map[string]cacheEntry // entries is a map of lookup keys to their last result.
+	limits   map[string]time.Time  // limits is a map of rate limited registries to the end of their limit.
+	calls    map[string]*cacheCall // calls is a map of lookup keys to their request in progress.
+	requests chan struct{}         // requests bounds the registry requests run at the same time.
+}
+
+// cacheEntry is the result of a registry lookup.
+type cacheEntry struct {
+	Digest  string    `json:"digest,omitempty"`
+	Config  string    `json:"config,omitempty"`
+	Tags    []string  `json:"tags,omitempty"`
+	Fetched time.Time `json:"fetched"`
+}
+
+// cacheCall is a registry lookup in progress, shared by the concurrent lookups of its key.
+type cacheCall struct {
+	done  chan struct{}
+	entry cacheEntry
+	err   error
+}
+
+// cacheFile is the content of the cache file.
+type cacheFile struct {
+	Entries map[string]cacheEntry `json:"entries"`
+	Limits  map[string]time.Time  `json:"limits,omitempty"`
+}
+
+// registryLookups is the cache of the registry lookups shared by all the clients.
+var registryLookups = newRegistryCache(DefaultCacheTTL, "")
+
+func newRegistryCache(ttl time.Duration, path string) *registryCache {
+	return &registryCache{
+		ttl:      ttl,
+		path:     path,
+		entries:  make(map[string]cacheEntry),
+		limits:   make(map[string]time.Time),
+		calls:    make(map[string]*cacheCall),
+		requests: make(chan struct{}, maxRegistryRequests),
+	}
+}
+
+// ConfigureRegistryCache sets the time the registry lookups are reused, 0 to
+// always request the registries, and the file persisting them across runs,
+// empty to keep them in memory. It must be called before any update check.
+func ConfigureRegistryCache(ttl time.Duration, path string) {
+	registryLookups = newRegistryCache(ttl, path)
+}
+
+// DefaultRegistryCachePath returns the cache file in the user cache directory
+// ($XDG_CACHE_HOME/gmd/registry.json on Linux).
+func DefaultRegistryCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gmd", "registry.json"), nil
+}
+
+// lookup returns the cached result of key if it is fresh, or requests it from
+// the registry with fetch. fetch gets the options reporting the rate limits of the registry.
+// When the registry is rate limited, the last result is returned even if stale.
+func (c *registryCache) lookup(key, registryName string, fetch func(opts ...remote.Option) (cacheEntry, error)) (cacheEntry, error) {
+	c.mu.Lock()
+	c.load()
+	entry, cached := c.entries[key]
+	if cached && c.ttl > 0 && time.Since(entry.Fetched) < c.ttl {
+		c.mu.Unlock()
+		return entry, nil
+	}
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.entry, call.err
+	}
+	if until, ok := c.limits[registryName]; ok && time.Now().Before(until) {
+		c.mu.Unlock()
+		if cached {
+			log.Printf("registry %s rate limited, using the lookup of %s from %s", registryName, key, entry.Fetched.Format(time.RFC3339))
+			return entry, nil
+		}
+		return cacheEntry{}, fmt.Errorf("%w by %s until %s", ErrRateLimited, registryName, until.Format(time.Kitchen))
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	c.requests <- struct{}{}
+	call.entry, call.err = fetch(c.transport(registryName))
+	<-c.requests
+
+	c.mu.Lock()
+	switch {
+	case call.err == nil:
+		call.entry.Fetched = time.Now()
+		c.entries[key] = call.entry
+		c.save()
+	case c.limited(registryName):
+		if cached {
+			log.Printf("registry %s rate limited, using the lookup of %s from %s", registryName, key, entry.Fetched.Format(time.RFC3339))
+			call.entry, call.err = entry, nil
+		} else {
+			call.err = fmt.Errorf("%w by %s: %w", ErrRateLimited, registryName, call.err)
+		}
+		c.save()
+	}
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+	return call.entry, call.err
+}
+
+// request runs fetch, a request of the registry whose result is not cached,
+// bounded with the lookups. It is refused while the registry is rate limited.
+func (c *registryCache) request(registryName string, fetch func(opts ...remote.Option) error) error {
+	c.mu.Lock()
+	c.load()
+	if until, ok := c.limits[registryName]; ok && time.Now().Before(until) {
+		c.mu.Unlock()
+		return fmt.Errorf("%w by %s until %s", ErrRateLimited, registryName, until.Format(time.Kitchen))
+	}
+	c.mu.Unlock()
+
+	c.requests <- struct{}{}
+	err := fetch(c.transport(registryName))
+	<-c.requests
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.limited(registryName) {
+		c.save()
+		if err != nil {
+			err = fmt.Errorf("%w by %s: %w", ErrRateLimited, registryName, err)
+		}
+	}
+	return err
+}
+
+// transport returns the option recording the rate limits announced by the registry.
+func (c *registryCache) transport(registryName string) remote.Option {
+	return remote.WithTransport(&rateLimitTransport{cache: c, registry: registryName, base: remote.DefaultTransport})
+}
+
+// forget drops the cached result of key.
+func (c *registryCache) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		delete(c.entries, key)
+		c.save()
+	}
+}
+
+// limited reports whether the registry is rate limited. The caller must hold c.mu.
+func (c *registryCache) limited(registryName string) bool {
+	until, ok := c.limits[registryName]
+	return ok && time.Now().Before(until)
+}
+
+// limit records the rate limit of a registry from the headers of one of its responses:
+// a 429 status or no remaining request pause the registry until the end of the limit.
+func (c *registryCache) limit(registryName string, resp *http.Response) {
+	remaining, window, ok := parseRateLimit(resp.Header.Get("RateLimit-Remaining"))
+	if resp.StatusCode != http.StatusTooManyRequests && (!ok || remaining > 0) {
+		return
+	}
+
+	delay := retryAfter(resp.Header.Get("Retry-After"))
+	if delay <= 0 {
+		delay = window
+	}
+	if delay <= 0 {
+		delay = rateLimitBackoff
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limits[registryName] = time.Now().Add(delay)
+	log.Printf("registry %s rate limited for %s", registryName, delay)
+}
+
+// parseRateLimit parses a RateLimit-Remaining header such as "76;w=21600",
+// the number of remaining requests followed by the window in seconds.
+func parseRateLimit(header string) (int, time.Duration, bool) {
+	if header == "" {
+		return 0, 0, false
+	}
+	parts := strings.Split(header, ";")
+	remaining, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+	var window time.Duration
+	for _, p := range parts[1:] {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(p), "w="); ok {
+			if s, err := strconv.Atoi(v); err == nil {
+				window = time.Duration(s) * time.Second
+			}
+		}
+	}
+	return remaining, window, true
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an HTTP date.
+// It returns 0 when the header is missing, invalid or in the past.
+func retryAfter(header string) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(s)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// load reads the cache file once. The caller must hold c.mu.
+func (c *registryCache) load() {
+	if c.loaded || c.path == "" {
+		return
+	}
+	c.loaded = true
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("unable to read the registry cache %s: %s", c.path, err)
+		}
+		return
+	}
+	var f cacheFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		log.Printf("invalid registry cache %s: %s", c.path, err)
+		return
+	}
+	for k, e := range f.Entries {
+		c.entries[k] = e
+	}
+	for r, until := range f.Limits {
+		c.limits[r] = until
+	}
+}
+
+// save writes the cache file, dropping the old lookups and the lifted limits.
+// The caller must hold c.mu.
+func (c *registryCache) save() {
+	if c.path == "" {
+		return
+	}
+	for k, e := range c.entries {
+		if time.Since(e.Fetched) > cacheRetention {
+			delete(c.entries, k)
+		}
+	}
+	for r := range c.limits {
+		if !c.limited(r) {
+			delete(c.limits, r)
+		}
+	}
+
+	data, err := json.Marshal(cacheFile{Entries: c.entries, Limits: c.limits})
+	if err != nil {
+		log.Printf("unable to encode the registry cache: %s", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		log.Printf("unable to save the registry cache: %s", err)
+		return
+	}
+	// write a temporary file first so that concurrent runs never read a partial file
+	tmp := c.path + "." + strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		log.Printf("unable to save the registry cache: %s", err)
+		return
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		log.Printf("unable to save the registry cache: %s", err)
+		os.Remove(tmp)
+	}
+}
+
+// rateLimitTransport records the rate limits announced by the responses of a registry.
+type rateLimitTransport struct {
+	cache    *registryCache
+	registry string
+	base     http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.cache.limit(t.registry, resp)
+	return resp, nil
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		header    string
+		remaining int
+		window    time.Duration
+		ok        bool
+	}{
+		{"", 0, 0, false},
+		{"100", 100, 0, true},
+		{"100;w=21600", 100, 6 * time.Hour, true},
+		{" 0 ; w=60 ", 0, time.Minute, true},
+		{"76;w=21600;source=abc", 76, 6 * time.Hour, true},
+		{"100;w=abc", 100, 0, true},
+		{"many;w=21600", 0, 0, false},
+	}
+	for _, tt := range tests {
+		remaining, window, ok := parseRateLimit(tt.header)
+		if remaining != tt.remaining || window != tt.window || ok != tt.ok {
+			t.Errorf("parseRateLimit(%q) = %d, %s, %t, want %d, %s, %t", tt.header, remaining, window, ok, tt.remaining, tt.window, tt.ok)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header   string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"120", 2 * time.Minute, 2 * time.Minute},
+		{" 30 ", 30 * time.Second, 30 * time.Second},
+		{"-5", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 58 * time.Minute, time.Hour},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.header); got < tt.min || got > tt.max {
+			t.Errorf("retryAfter(%q) = %s, want between %s and %s", tt.header, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestRequestRateLimited(t *testing.T) {
+	c := newRegistryCache(DefaultCacheTTL, "")
+	c.limits["ghcr.io"] = time.Now().Add(time.Hour)
+
+	called := false
+	err := c.request("ghcr.io", func(opts ...remote.Option) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrRateLimited) || called {
+		t.Errorf("request() = %v, called %t, want %v, not called", err, called, ErrRateLimited)
+	}
+
+	err = c.request("docker.io", func(opts ...remote.Option) error {
+		called = true
+		return nil
+	})
+	if err != nil || !called {
+		t.Errorf("request() = %v, called %t, want nil, called", err, called)
+	}
+}
@@ -2,29 +2,130 @@ package client
 
 import (
 	"context"
+	"sync"
 
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 )
 
 // Client represents a client to the Docker daemon.
 //
 // It provides a way to interact with the daemon and receive events
-// from the daemon.
-type Client struct {
+// from the daemon. The default implementation returned by NewClient talks
+// to a real Docker Engine; the fake package provides an in-memory engine
+// implementing the same interface.
+type Client interface {
+	// Endpoint returns the daemon endpoint the client is connected to.
+	Endpoint() Endpoint
+
+	// ContainerList returns a list of all containers on the docker daemon.
+	ContainerList() ([]container.Summary, error)
+	// ContainerInspect returns the configuration of the container with the given ID.
+	ContainerInspect(id string) (container.InspectResponse, error)
+	// ContainerStats streams the stats of the container with the given ID to stats until ctx is cancelled.
+	ContainerStats(ctx context.Context, id string, stats func(container.StatsResponse)) error
+	// ContainerLogs reads the logs of the container with the given ID and reports each line to the given function.
+	ContainerLogs(ctx context.Context, id string, options LogsOptions, line func(LogLine)) error
+	// Exec runs a command in the container with the given ID and attaches to its standard streams.
+	Exec(ctx context.Context, containerID string, config ExecConfig) (ExecSession, error)
+	// StartContainer starts a container with the given ID.
+	StartContainer(id string) error
+	// StopContainer stops a container with the given ID.
+	StopContainer(id string) error
+	// RestartContainer restarts a container with the given ID.
+	RestartContainer(id string) error
+	// DeleteContainer deletes a container with the given ID.
+	DeleteContainer(id string) error
+	// RenameContainer renames the container with the given ID.
+	RenameContainer(id, name string) error
+	// RecreateContainer stops, removes, recreates and starts the container with the given ID.
+	RecreateContainer(id string) (string, error)
+	// CreateContainerFromConfig creates a container based on the given inspect configuration.
+	CreateContainerFromConfig(config container.InspectResponse) (container.CreateResponse, error)
+
+	// ImageList returns a list of images on the Docker daemon.
+	ImageList() ([]image.Summary, error)
+	// ImageHistory returns the history of an image on the Docker daemon.
+	ImageHistory(imageID string) ([]image.HistoryResponseItem, error)
+	// DeleteImage deletes an image from the Docker daemon.
+	DeleteImage(ctx context.Context, imageID string) error
+	// TagImage tags the image with the given ID with ref.
+	TagImage(ctx context.Context, imageID, ref string) error
+	// PullImageWithProgress pulls an image and reports the progress to the given function.
+	PullImageWithProgress(ctx context.Context, imageRef string, progress func(map[string]interface{})) error
+
+	// VolumeList returns the list of volumes on the Docker daemon.
+	VolumeList() ([]*volume.Volume, error)
+	// VolumeInspect returns the volume with the given name.
+	VolumeInspect(name string) (volume.Volume, error)
+	// VolumesDiskUsage returns the usage data of the volumes, indexed by volume name.
+	VolumesDiskUsage() (map[string]volume.UsageData, error)
+	// DeleteVolume deletes the volume with the given name.
+	DeleteVolume(ctx context.Context, name string) error
+	// PruneVolumes deletes the anonymous volumes not used by any container,
+	// the named ones too when all is set.
+	PruneVolumes(ctx context.Context, all bool) (volume.PruneReport, error)
+
+	// NetworkList returns the list of networks on the Docker daemon.
+	NetworkList() ([]network.Summary, error)
+	// NetworkInspect returns the network with the given ID, connected containers included.
+	NetworkInspect(id string) (network.Inspect, error)
+	// CreateNetwork creates a network and returns its ID. An empty subnet lets the daemon choose.
+	CreateNetwork(ctx context.Context, name, driver, subnet string) (string, error)
+	// DeleteNetwork deletes the network with the given ID.
+	DeleteNetwork(ctx context.Context, id string) error
+	// ConnectNetwork connects a container to a network.
+	ConnectNetwork(ctx context.Context, networkID, containerID string) error
+	// DisconnectNetwork disconnects a container from a network.
+	DisconnectNetwork(ctx context.Context, networkID, containerID string) error
+
+	// CheckUpdate checks if the given container needs to be updated.
+	CheckUpdate(containerID string) (UpdateCheck, error)
+	// DiffImage compares the local image of the given container with the image published by the registry for image.
+	DiffImage(containerID, image string) (ImageDiff, error)
+
+	// StartEvents subscribes to the events of the daemon.
+	StartEvents() (<-chan events.Message, <-chan error)
+	// StopEvents cancels the subscription started by StartEvents.
+	StopEvents()
+}
+
+// dockerClient is the Client implementation backed by a Docker Engine.
+type dockerClient struct {
 	cli           client.APIClient   // cli is the underlying client to the Docker daemon.
+	endpoint      Endpoint           // endpoint is the daemon endpoint the client is connected to.
 	eventsContext context.Context    // eventsContext is the context used for listening to events from the daemon.
 	eventsCancel  context.CancelFunc // eventsCancel is the cancel function for the events context.
+	platformMu    sync.Mutex
+	platform      *v1.Platform // platform is the platform of the daemon, nil until resolved.
 }
 
-// NewClient returns a new Client object, which represents a client to the Docker daemon.
+// NewClient returns a new Client object, which represents a client to the Docker daemon
+// reachable at the given endpoint. The zero Endpoint connects using the environment.
 // If the creation of the client fails, it returns nil and an error.
-func NewClient() (*Client, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+func NewClient(endpoint Endpoint) (Client, error) {
+	opts, err := endpoint.clientOpts()
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{
-		cli: cli,
+	cli, err := client.NewClientWithOpts(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dockerClient{
+		cli:      cli,
+		endpoint: endpoint,
 	}, nil
 }
+
+// Endpoint returns the daemon endpoint the client is connected to.
+func (c *dockerClient) Endpoint() Endpoint {
+	return c.endpoint
+}
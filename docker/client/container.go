@@ -3,12 +3,15 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/network"
 	"github.com/hashicorp/go-version"
 )
 
@@ -17,96 +20,109 @@ import (
 // The returned list of containers is a slice of container.Summary objects.
 // The container.Summary objects contain only the most basic information about the container, such as its ID, name, and status.
 // The container.Summary objects are returned in a random order.
-func (c *Client) ContainerList() ([]container.Summary, error) {
+func (c *dockerClient) ContainerList() ([]container.Summary, error) {
 	return c.cli.ContainerList(context.Background(), container.ListOptions{All: true})
 }
 
 // StartContainer starts a container with the given ID.
 // It returns an error if the container could not be started.
-func (c *Client) StartContainer(id string) error {
+func (c *dockerClient) StartContainer(id string) error {
 	return c.cli.ContainerStart(context.Background(), id, container.StartOptions{})
 }
 
 // StopContainer stops a container with the given ID.
 // It returns an error if the container could not be stopped.
-func (c *Client) StopContainer(id string) error {
+func (c *dockerClient) StopContainer(id string) error {
 	return c.cli.ContainerStop(context.Background(), id, container.StopOptions{})
 }
 
 // RestartContainer restarts a container with the given ID.
 // It returns an error if the container could not be restarted.
-func (c *Client) RestartContainer(id string) error {
+func (c *dockerClient) RestartContainer(id string) error {
 	return c.cli.ContainerRestart(context.Background(), id, container.StopOptions{})
 }
 
+// RenameContainer renames the container with the given ID.
+// It returns an error if the name is already used by another container.
+func (c *dockerClient) RenameContainer(id, name string) error {
+	return c.cli.ContainerRename(context.Background(), id, name)
+}
+
 // DeleteContainer deletes a container with the given ID.
 // It returns an error if the container could not be deleted.
-func (c *Client) DeleteContainer(id string) error {
+func (c *dockerClient) DeleteContainer(id string) error {
 	dockerOpts := container.RemoveOptions{}
 	return c.cli.ContainerRemove(context.Background(), id, dockerOpts)
 }
 
 // ContainerInspect returns the configuration of the container with the given ID.
 // It returns an error if the container could not be inspected.
-func (c *Client) ContainerInspect(id string) (container.InspectResponse, error) {
+func (c *dockerClient) ContainerInspect(id string) (container.InspectResponse, error) {
 	return c.cli.ContainerInspect(context.Background(), id)
 }
 
-func (c *Client) RecreateContainer(id string) (string, error) {
+func (c *dockerClient) RecreateContainer(id string) (string, error) {
 	containerConfig, err := c.ContainerInspect(id)
 	if err != nil {
-		return "", fmt.Errorf("unable to recreate container %s : %w", err)
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
 	}
 
 	err = c.StopContainer(id)
 	if err != nil {
-		return "", fmt.Errorf("unable to recreate container %s : %w", err)
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
 	}
 
 	err = c.DeleteContainer(id)
 	if err != nil {
-		return "", fmt.Errorf("unable to recreate container %s : %w", err)
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
 	}
 
 	r, err := c.CreateContainerFromConfig(containerConfig)
 	if err != nil {
-		return "", fmt.Errorf("unable to recreate container %s : %w", err)
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
 	}
 
 	err = c.StartContainer(r.ID)
 	if err != nil {
-		return "", fmt.Errorf("unable to recreate container %s : %w", err)
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
 	}
 
 	return r.ID, nil
 }
 
-// ContainerStats returns the stats of a container with the given ID.
-// It returns an error if the container could not be inspected.
-// The returned stats are the result of a single shot stats query, and are not
-// streamed. If the container is not running, the stats will be empty.
-// If the container does not exist, an error will be returned.
-func (c *Client) ContainerStats(id string) (container.StatsResponse, error) {
-	var v container.StatsResponse
-	stats, err := c.cli.ContainerStatsOneShot(context.Background(), id)
-
+// ContainerStats streams the stats of the container with the given ID.
+// The daemon sends a sample about every second, each one is reported to stats.
+// It returns when ctx is cancelled or when the container stops, nil in both cases.
+func (c *dockerClient) ContainerStats(ctx context.Context, id string, stats func(container.StatsResponse)) error {
+	resp, err := c.cli.ContainerStats(ctx, id, true)
 	if err != nil {
-		return v, err
+		return err
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var v container.StatsResponse
+		if err := dec.Decode(&v); err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		stats(v)
 	}
-
-	dec := json.NewDecoder(stats.Body)
-	err = dec.Decode(&v)
-	return v, err
 }
 
 // CreateContainerFromConfig creates a container based on the given container configuration.
 // It returns an error if the container could not be created.
 // The given container configuration is expected to be a container.InspectResponse object.
-// The created container will have the same configuration as the given container.
+// The created container will have the same configuration as the given container,
+// without the defaults of its image so that the ones of the image of the new container apply (see PlanRecreate).
 // The function will sanitize the given container configuration to make it compatible with
 // the docker daemon API version.
+// The container is created on its primary network and connected to the other ones before being returned.
 // The function will return a container.CreateResponse object containing information about the created container.
-func (c *Client) CreateContainerFromConfig(config container.InspectResponse) (container.CreateResponse, error) {
+func (c *dockerClient) CreateContainerFromConfig(config container.InspectResponse) (container.CreateResponse, error) {
 
 	info, err := c.cli.ServerVersion(context.Background())
 	if err != nil {
@@ -116,12 +132,26 @@ func (c *Client) CreateContainerFromConfig(config container.InspectResponse) (co
 
 	sanitizeContainerJONVersion(&config, info.APIVersion)
 
-	netConfig := &network.NetworkingConfig{
-		EndpointsConfig: config.NetworkSettings.Networks,
+	var imageID string
+	if config.ContainerJSONBase != nil {
+		imageID = config.Image
+	}
+	plan := PlanRecreate(config, c.recreateImageConfig(imageID))
+
+	r, err := c.cli.ContainerCreate(context.Background(), plan.Config, plan.HostConfig, plan.Networking, nil, plan.Name)
+	if err != nil {
+		return r, err
 	}
 
-	r, err := c.cli.ContainerCreate(context.Background(), config.Config, config.HostConfig, netConfig, nil, config.Name)
-	return r, err
+	for _, name := range slices.Sorted(maps.Keys(plan.Connect)) {
+		if err := c.cli.NetworkConnect(context.Background(), name, r.ID, plan.Connect[name]); err != nil {
+			if err := c.DeleteContainer(r.ID); err != nil {
+				log.Printf("unable to remove the container %s: %v", r.ID, err)
+			}
+			return container.CreateResponse{}, fmt.Errorf("unable to connect the container to the network %s: %w", name, err)
+		}
+	}
+	return r, nil
 }
 
 func sanitizeContainerJONVersion(containerJson *container.InspectResponse, apiVersionString string) {
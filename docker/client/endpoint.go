@@ -0,0 +1,242 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+)
+
+// DefaultContextName is the name of the implicit docker context built from the environment.
+const DefaultContextName = "default"
+
+// ErrContextNotFound is returned when the requested docker context does not exist.
+var ErrContextNotFound = errors.New("docker context not found")
+
+// TLSConfig holds the certificates used to connect to a daemon over TLS.
+type TLSConfig struct {
+	CAFile     string // CAFile is the path to the certificate authority.
+	CertFile   string // CertFile is the path to the client certificate.
+	KeyFile    string // KeyFile is the path to the client key.
+	SkipVerify bool   // SkipVerify disables the verification of the daemon certificate.
+}
+
+// Endpoint describes the Docker daemon a Client connects to.
+//
+// The zero value connects to the daemon described by the DOCKER_* environment
+// variables, like the docker CLI does without any flag.
+type Endpoint struct {
+	Context string     // Context is the name of the docker context the endpoint comes from, if any.
+	Host    string     // Host is the daemon address (unix://, tcp://, ssh://, npipe://).
+	TLS     *TLSConfig // TLS holds the certificates used for tcp:// hosts, nil when TLS is not used.
+}
+
+// String returns a human readable description of the endpoint.
+func (e Endpoint) String() string {
+	host := e.Host
+	if host == "" {
+		host = os.Getenv(client.EnvOverrideHost)
+	}
+	if host == "" {
+		host = client.DefaultDockerHost
+	}
+	if e.Context != "" && e.Context != DefaultContextName {
+		return fmt.Sprintf("%s (%s)", e.Context, host)
+	}
+	return host
+}
+
+// Name returns a short name identifying the endpoint: the context name when
+// the endpoint comes from a context, the daemon hostname for remote hosts and
+// "local" otherwise. The user and a port other than the default one of the
+// scheme are kept, so that two daemons of the same machine get distinct names.
+func (e Endpoint) Name() string {
+	if e.Context != "" && e.Context != DefaultContextName {
+		return e.Context
+	}
+	host := e.Host
+	if host == "" {
+		host = os.Getenv(client.EnvOverrideHost)
+	}
+	u, err := url.Parse(host)
+	if err != nil || u.Hostname() == "" {
+		return "local"
+	}
+	name := u.Hostname()
+	if u.User != nil && u.User.Username() != "" {
+		name = u.User.Username() + "@" + name
+	}
+	if port := u.Port(); port != "" && !slices.Contains(defaultPorts[u.Scheme], port) {
+		name += ":" + port
+	}
+	return name
+}
+
+// defaultPorts are the ports left out of the endpoint names, by scheme.
+var defaultPorts = map[string][]string{
+	"ssh": {"22"},
+	"tcp": {"2375", "2376"},
+}
+
+// ResolveEndpoint returns the endpoint selected by the given host and context names.
+//
+// The resolution follows the docker CLI rules: an explicit host wins over an
+// explicit context, then DOCKER_HOST, DOCKER_CONTEXT and the current context
+// stored in the docker config file are used. When nothing is set, the default
+// context, which connects using the environment, is returned. The certificates
+// used with an explicit tcp:// host are read from the given tlsConfig, if not nil.
+func ResolveEndpoint(host, contextName string, tlsConfig *TLSConfig) (Endpoint, error) {
+	if host != "" {
+		return Endpoint{Host: host, TLS: tlsConfig}, nil
+	}
+
+	if contextName == "" && os.Getenv(client.EnvOverrideHost) != "" {
+		return Endpoint{}, nil
+	}
+
+	if contextName == "" {
+		contextName = os.Getenv("DOCKER_CONTEXT")
+	}
+	if contextName == "" {
+		contextName = currentContext()
+	}
+	if contextName == "" || contextName == DefaultContextName {
+		return Endpoint{Context: DefaultContextName}, nil
+	}
+
+	return loadContextEndpoint(contextName)
+}
+
+// clientOpts returns the docker client options to connect to the endpoint.
+func (e Endpoint) clientOpts() ([]client.Opt, error) {
+	if e.Host == "" {
+		return []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}, nil
+	}
+
+	opts := []client.Opt{client.WithVersionFromEnv(), client.WithAPIVersionNegotiation()}
+
+	helper, err := connhelper.GetConnectionHelper(e.Host)
+	if err != nil {
+		return nil, err
+	}
+	if helper != nil {
+		httpClient := &http.Client{
+			Transport: &http.Transport{DialContext: helper.Dialer},
+		}
+		return append(opts,
+			client.WithHTTPClient(httpClient),
+			client.WithHost(helper.Host),
+			client.WithDialContext(helper.Dialer),
+		), nil
+	}
+
+	if e.TLS != nil {
+		config, err := tlsconfig.Client(tlsconfig.Options{
+			CAFile:             e.TLS.CAFile,
+			CertFile:           e.TLS.CertFile,
+			KeyFile:            e.TLS.KeyFile,
+			InsecureSkipVerify: e.TLS.SkipVerify,
+			ExclusiveRootPools: true,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tls config: %w", err)
+		}
+		httpClient := &http.Client{
+			Transport: &http.Transport{TLSClientConfig: config},
+		}
+		opts = append(opts, client.WithHTTPClient(httpClient))
+	}
+
+	return append(opts, client.WithHost(e.Host)), nil
+}
+
+// contextMetadata is the content of ~/.docker/contexts/meta/<id>/meta.json.
+type contextMetadata struct {
+	Name      string
+	Endpoints map[string]struct {
+		Host          string
+		SkipTLSVerify bool
+	}
+}
+
+// loadContextEndpoint reads the docker endpoint of the named context from the context store.
+func loadContextEndpoint(name string) (Endpoint, error) {
+	id := contextID(name)
+
+	b, err := os.ReadFile(filepath.Join(ConfigDir(), "contexts", "meta", id, "meta.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Endpoint{}, fmt.Errorf("%w: %s", ErrContextNotFound, name)
+	}
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	var meta contextMetadata
+	if err := json.Unmarshal(b, &meta); err != nil {
+		return Endpoint{}, fmt.Errorf("invalid metadata for context %s: %w", name, err)
+	}
+
+	docker, ok := meta.Endpoints["docker"]
+	if !ok {
+		return Endpoint{}, fmt.Errorf("context %s has no docker endpoint", name)
+	}
+
+	e := Endpoint{Context: name, Host: docker.Host}
+
+	tlsDir := filepath.Join(ConfigDir(), "contexts", "tls", id, "docker")
+	tls := &TLSConfig{SkipVerify: docker.SkipTLSVerify}
+	for file, dest := range map[string]*string{"ca.pem": &tls.CAFile, "cert.pem": &tls.CertFile, "key.pem": &tls.KeyFile} {
+		p := filepath.Join(tlsDir, file)
+		if _, err := os.Stat(p); err == nil {
+			*dest = p
+		}
+	}
+	if tls.CAFile != "" || tls.CertFile != "" || tls.SkipVerify {
+		e.TLS = tls
+	}
+
+	return e, nil
+}
+
+// currentContext returns the current context stored in the docker config file.
+func currentContext() string {
+	b, err := os.ReadFile(filepath.Join(ConfigDir(), "config.json"))
+	if err != nil {
+		return ""
+	}
+	var config struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err := json.Unmarshal(b, &config); err != nil {
+		return ""
+	}
+	return config.CurrentContext
+}
+
+// ConfigDir returns the docker configuration directory, honoring DOCKER_CONFIG.
+func ConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".docker"
+	}
+	return filepath.Join(home, ".docker")
+}
+
+// contextID returns the directory name used by the context store for the named context.
+func contextID(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])
+}
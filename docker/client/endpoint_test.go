@@ -0,0 +1,25 @@
+package client
+
+import "testing"
+
+func TestEndpointName(t *testing.T) {
+	tests := []struct {
+		endpoint Endpoint
+		want     string
+	}{
+		{Endpoint{Host: "unix:///var/run/docker.sock"}, "local"},
+		{Endpoint{Context: "prod", Host: "ssh://a@box"}, "prod"},
+		{Endpoint{Host: "ssh://box"}, "box"},
+		{Endpoint{Host: "ssh://a@box"}, "a@box"},
+		{Endpoint{Host: "ssh://a@box:22"}, "a@box"},
+		{Endpoint{Host: "ssh://b@box:2222"}, "b@box:2222"},
+		{Endpoint{Host: "tcp://box:2375"}, "box"},
+		{Endpoint{Host: "tcp://box:2376"}, "box"},
+		{Endpoint{Host: "tcp://box:2380"}, "box:2380"},
+	}
+	for _, tt := range tests {
+		if got := tt.endpoint.Name(); got != tt.want {
+			t.Errorf("%+v: Name() = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
@@ -7,12 +7,13 @@ import (
 	"github.com/docker/docker/api/types/filters"
 )
 
-func (c *Client) StartEvents() (<-chan events.Message, <-chan error) {
+func (c *dockerClient) StartEvents() (<-chan events.Message, <-chan error) {
 
 	filters := filters.NewArgs()
 	filters.Add("type", string(events.ContainerEventType))
 	filters.Add("type", string(events.ImageEventType))
 	filters.Add("type", string(events.VolumeEventType))
+	filters.Add("type", string(events.NetworkEventType))
 
 	filters.Add("event", string(events.ActionCreate))
 	filters.Add("event", string(events.ActionStart))
@@ -31,6 +32,9 @@ func (c *Client) StartEvents() (<-chan events.Message, <-chan error) {
 	filters.Add("event", string(events.ActionPrune))
 	filters.Add("event", string(events.ActionDelete))
 
+	filters.Add("event", string(events.ActionConnect))
+	filters.Add("event", string(events.ActionDisconnect))
+
 	c.eventsContext, c.eventsCancel = context.WithCancel(context.Background())
 
 	ev, errors := c.cli.Events(c.eventsContext, events.ListOptions{
@@ -41,7 +45,7 @@ func (c *Client) StartEvents() (<-chan events.Message, <-chan error) {
 
 }
 
-func (c *Client) StopEvents() {
+func (c *dockerClient) StopEvents() {
 	c.eventsCancel()
 	<-c.eventsContext.Done()
 }
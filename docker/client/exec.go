@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// Shells are the shells tried by DetectShell, in order of preference.
+var Shells = []string{"/bin/bash", "/bin/sh", "/bin/ash"}
+
+// ErrNoShell is returned by DetectShell when none of the Shells can be run in the container.
+var ErrNoShell = errors.New("no shell found in container")
+
+// ExecConfig describes a command run in a container by Exec.
+type ExecConfig struct {
+	Cmd    []string // Cmd is the command and its arguments.
+	User   string   // User runs the command, the user of the container when empty.
+	Tty    bool     // Tty allocates a pseudo terminal, stdout and stderr are then merged.
+	Height uint     // Height is the initial height of the terminal, when Tty is set.
+	Width  uint     // Width is the initial width of the terminal, when Tty is set.
+}
+
+// ExecSession is a command running in a container.
+//
+// Reading returns the output of the command, multiplexed like the logs
+// when the session has no TTY. Writing sends data to its standard input.
+type ExecSession interface {
+	io.ReadWriteCloser
+	// CloseWrite closes the standard input of the command.
+	CloseWrite() error
+	// Resize changes the size of the terminal of the command.
+	Resize(ctx context.Context, height, width uint) error
+	// ExitCode returns the exit code of the command, once its output is read.
+	ExitCode(ctx context.Context) (int, error)
+}
+
+// Exec starts the command described by config in the container with the given ID
+// and attaches to its standard streams.
+// The returned session must be closed by the caller.
+func (c *dockerClient) Exec(ctx context.Context, containerID string, config ExecConfig) (ExecSession, error) {
+	options := container.ExecOptions{
+		User:         config.User,
+		Tty:          config.Tty,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          config.Cmd,
+	}
+	var size *[2]uint
+	if config.Tty && config.Height > 0 && config.Width > 0 {
+		size = &[2]uint{config.Height, config.Width}
+		options.ConsoleSize = size
+	}
+
+	r, err := c.cli.ContainerExecCreate(ctx, containerID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.cli.ContainerExecAttach(ctx, r.ID, container.ExecAttachOptions{Tty: config.Tty, ConsoleSize: size})
+	if err != nil {
+		return nil, err
+	}
+
+	return &execSession{cli: c, id: r.ID, resp: resp}, nil
+}
+
+// execSession is the ExecSession backed by a connection hijacked from the daemon.
+type execSession struct {
+	cli  *dockerClient
+	id   string
+	resp types.HijackedResponse
+}
+
+func (s *execSession) Read(p []byte) (int, error) {
+	return s.resp.Reader.Read(p)
+}
+
+func (s *execSession) Write(p []byte) (int, error) {
+	return s.resp.Conn.Write(p)
+}
+
+func (s *execSession) Close() error {
+	s.resp.Close()
+	return nil
+}
+
+func (s *execSession) CloseWrite() error {
+	return s.resp.CloseWrite()
+}
+
+func (s *execSession) Resize(ctx context.Context, height, width uint) error {
+	return s.cli.cli.ContainerExecResize(ctx, s.id, container.ResizeOptions{Height: height, Width: width})
+}
+
+func (s *execSession) ExitCode(ctx context.Context) (int, error) {
+	inspect, err := s.cli.cli.ContainerExecInspect(ctx, s.id)
+	if err != nil {
+		return 0, err
+	}
+	if inspect.Running {
+		return 0, fmt.Errorf("exec %s is still running", s.id)
+	}
+	return inspect.ExitCode, nil
+}
+
+// DetectShell returns the first of the Shells which can be run in the
+// container by the given user, or ErrNoShell.
+func DetectShell(ctx context.Context, cli Client, containerID, user string) (string, error) {
+	for _, shell := range Shells {
+		code, err := run(ctx, cli, containerID, user, shell, "-c", "exit 0")
+		if err != nil {
+			return "", err
+		}
+		if code == 0 {
+			return shell, nil
+		}
+	}
+	return "", ErrNoShell
+}
+
+// run runs the command without TTY, discards its output and returns its exit code.
+func run(ctx context.Context, cli Client, containerID, user string, cmd ...string) (int, error) {
+	s, err := cli.Exec(ctx, containerID, ExecConfig{Cmd: cmd, User: user})
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+
+	if err := s.CloseWrite(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return 0, err
+	}
+	if _, err := io.Copy(io.Discard, s); err != nil {
+		return 0, err
+	}
+	return s.ExitCode(ctx)
+}
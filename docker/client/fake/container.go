@@ -0,0 +1,392 @@
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-connections/nat"
+	"github.com/kernaxis/gmd/docker/client"
+)
+
+// statsInterval is the delay between two samples of ContainerStats, like the daemon.
+const statsInterval = time.Second
+
+// ContainerList returns a list of all containers on the engine.
+func (e *Engine) ContainerList() ([]container.Summary, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpContainerList); err != nil {
+		return nil, err
+	}
+
+	out := make([]container.Summary, 0, len(e.containers))
+	for _, c := range e.containers {
+		summary := container.Summary{
+			ID:      c.ID,
+			Names:   []string{c.Name},
+			Image:   c.Config.Image,
+			ImageID: c.Image,
+			State:   c.State.Status,
+			Labels:  c.Config.Labels,
+		}
+		summary.HostConfig.NetworkMode = string(c.HostConfig.NetworkMode)
+		out = append(out, summary)
+	}
+	return out, nil
+}
+
+// ContainerInspect returns the configuration of the container with the given ID or name.
+func (e *Engine) ContainerInspect(id string) (container.InspectResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpContainerInspect); err != nil {
+		return container.InspectResponse{}, err
+	}
+
+	c, err := e.container(id)
+	if err != nil {
+		return container.InspectResponse{}, err
+	}
+	return clone(*c), nil
+}
+
+// ContainerStats streams the stats set with SetStats for the given container,
+// one sample every statsInterval, stamped with the clock of the engine.
+// The stream ends when ctx is cancelled or when the container stops or is removed.
+func (e *Engine) ContainerStats(ctx context.Context, id string, stats func(container.StatsResponse)) error {
+	e.mu.Lock()
+	if err := e.failure(OpContainerStats); err != nil {
+		e.mu.Unlock()
+		return err
+	}
+	c, err := e.container(id)
+	if err != nil {
+		e.mu.Unlock()
+		return err
+	}
+	id = c.ID
+	e.mu.Unlock()
+
+	var prev container.StatsResponse
+	for {
+		e.mu.Lock()
+		c, ok := e.containers[id]
+		if !ok || !c.State.Running {
+			e.mu.Unlock()
+			return nil
+		}
+		v := e.stats[id]
+		v.ID = c.ID
+		v.Name = c.Name
+		v.Read = e.now()
+		v.PreRead = prev.Read
+		e.mu.Unlock()
+
+		stats(v)
+		prev = v
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(statsInterval):
+		}
+	}
+}
+
+// StartContainer starts the container with the given ID.
+func (e *Engine) StartContainer(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpContainerStart); err != nil {
+		return err
+	}
+
+	c, err := e.container(id)
+	if err != nil {
+		return err
+	}
+	if !c.State.Running {
+		e.setRunning(c, true)
+		if code, ok := e.imageExit(c.Image); ok {
+			e.setRunning(c, false)
+			c.State.ExitCode = code
+		}
+	}
+	return nil
+}
+
+// StopContainer stops the container with the given ID.
+func (e *Engine) StopContainer(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpContainerStop); err != nil {
+		return err
+	}
+
+	c, err := e.container(id)
+	if err != nil {
+		return err
+	}
+	if c.State.Running {
+		e.setRunning(c, false)
+	}
+	return nil
+}
+
+// RenameContainer renames the container with the given ID.
+func (e *Engine) RenameContainer(id, name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpContainerRename); err != nil {
+		return err
+	}
+
+	c, err := e.container(id)
+	if err != nil {
+		return err
+	}
+	name = strings.TrimPrefix(name, "/")
+	if other, err := e.container(name); err == nil && other.ID != c.ID {
+		return errdefs.Conflict(fmt.Errorf("Conflict. The container name %q is already in use by container %q", "/"+name, other.ID))
+	}
+	c.Name = "/" + name
+	e.emit(events.ContainerEventType, events.ActionRename, c.ID)
+	return nil
+}
+
+// RestartContainer restarts the container with the given ID.
+func (e *Engine) RestartContainer(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpContainerRestart); err != nil {
+		return err
+	}
+
+	c, err := e.container(id)
+	if err != nil {
+		return err
+	}
+	if c.State.Running {
+		e.setRunning(c, false)
+	}
+	e.setRunning(c, true)
+	e.emit(events.ContainerEventType, events.ActionRestart, c.ID)
+	return nil
+}
+
+// DeleteContainer deletes the container with the given ID.
+// Like the daemon, it refuses to delete a running container.
+func (e *Engine) DeleteContainer(id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpContainerRemove); err != nil {
+		return err
+	}
+
+	c, err := e.container(id)
+	if err != nil {
+		return err
+	}
+	if c.State.Running {
+		return errdefs.Conflict(fmt.Errorf("cannot remove container %s: container is running", c.Name))
+	}
+
+	delete(e.containers, c.ID)
+	delete(e.stats, c.ID)
+	delete(e.logs, c.ID)
+	e.emit(events.ContainerEventType, events.ActionDestroy, c.ID)
+	return nil
+}
+
+// RecreateContainer stops, removes, recreates and starts the container with the given ID.
+func (e *Engine) RecreateContainer(id string) (string, error) {
+	config, err := e.ContainerInspect(id)
+	if err != nil {
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
+	}
+
+	if err := e.StopContainer(id); err != nil {
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
+	}
+
+	if err := e.DeleteContainer(id); err != nil {
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
+	}
+
+	r, err := e.CreateContainerFromConfig(config)
+	if err != nil {
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
+	}
+
+	if err := e.StartContainer(r.ID); err != nil {
+		return "", fmt.Errorf("unable to recreate container %s : %w", id, err)
+	}
+
+	return r.ID, nil
+}
+
+// CreateContainerFromConfig creates a container based on the given container configuration.
+// The image is resolved from config.Config.Image and must exist on the engine.
+// Like the daemon, the defaults of the image configuration apply to the settings
+// the configuration leaves out once planned with client.PlanRecreate.
+func (e *Engine) CreateContainerFromConfig(config container.InspectResponse) (container.CreateResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpContainerCreate); err != nil {
+		return container.CreateResponse{}, err
+	}
+
+	config = clone(config)
+	if config.Config == nil {
+		return container.CreateResponse{}, errdefs.InvalidParameter(fmt.Errorf("config is required"))
+	}
+
+	img := e.imageByRef(config.Config.Image)
+	if img == nil {
+		return container.CreateResponse{}, errdefs.NotFound(fmt.Errorf("No such image: %s", config.Config.Image))
+	}
+
+	name := strings.TrimPrefix(config.Name, "/")
+	if name != "" {
+		if c, err := e.container(name); err == nil {
+			return container.CreateResponse{}, errdefs.Conflict(fmt.Errorf("Conflict. The container name %q is already in use by container %q", "/"+name, c.ID))
+		}
+	}
+
+	var previous *client.ImageConfig
+	if config.ContainerJSONBase != nil {
+		if cfg, ok := e.configs[config.Image]; ok {
+			previous = &cfg
+		}
+	}
+	plan := client.PlanRecreate(config, previous)
+	withImageDefaults(plan.Config, e.configs[img.ID])
+
+	networks := make(map[string]*network.EndpointSettings)
+	for n, settings := range plan.Endpoints() {
+		e.endpoint(networks, n, settings)
+	}
+	cont := e.newContainer(plan.Config, plan.HostConfig, networks, name, img.ID)
+
+	return container.CreateResponse{ID: cont.ID}, nil
+}
+
+// endpoint adds an endpoint with the given user settings on the network with
+// the given name to the container networks. The static address is used when
+// set, the settings are kept as is when the network does not exist.
+// The caller must hold e.mu.
+func (e *Engine) endpoint(networks map[string]*network.EndpointSettings, name string, settings *network.EndpointSettings) {
+	n, err := e.network(name)
+	if err != nil {
+		networks[name] = settings
+		return
+	}
+	e.attach(networks, n)
+	ep := networks[n.Name]
+	ep.Aliases, ep.Links, ep.DriverOpts, ep.IPAMConfig = settings.Aliases, settings.Links, settings.DriverOpts, settings.IPAMConfig
+	if settings.IPAMConfig != nil && settings.IPAMConfig.IPv4Address != "" {
+		ep.IPAddress = settings.IPAMConfig.IPv4Address
+	}
+}
+
+// withImageDefaults sets the settings config leaves out to the ones of the image.
+func withImageDefaults(config *container.Config, image client.ImageConfig) {
+	env := slices.Clone(image.Env)
+	for _, v := range config.Env {
+		k, _, _ := strings.Cut(v, "=")
+		env = slices.DeleteFunc(env, func(d string) bool { return strings.HasPrefix(d, k+"=") })
+		env = append(env, v)
+	}
+	config.Env = env
+
+	if config.Entrypoint == nil {
+		config.Entrypoint = image.Entrypoint
+		if config.Cmd == nil {
+			config.Cmd = image.Cmd
+		}
+	}
+
+	labels := maps.Clone(image.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	maps.Copy(labels, config.Labels)
+	config.Labels = labels
+
+	for _, p := range image.ExposedPorts {
+		if config.ExposedPorts == nil {
+			config.ExposedPorts = make(nat.PortSet)
+		}
+		config.ExposedPorts[nat.Port(p)] = struct{}{}
+	}
+	for _, v := range image.Volumes {
+		if config.Volumes == nil {
+			config.Volumes = make(map[string]struct{})
+		}
+		config.Volumes[v] = struct{}{}
+	}
+
+	if config.WorkingDir == "" {
+		config.WorkingDir = image.WorkingDir
+	}
+	if config.User == "" {
+		config.User = image.User
+	}
+	if config.StopSignal == "" {
+		config.StopSignal = image.StopSignal
+	}
+	if config.Healthcheck == nil {
+		config.Healthcheck = image.Healthcheck
+	}
+}
+
+// container returns the container matching the given ID, ID prefix or name.
+// The caller must hold e.mu.
+func (e *Engine) container(id string) (*container.InspectResponse, error) {
+	if c, ok := e.containers[id]; ok {
+		return c, nil
+	}
+	for _, c := range e.containers {
+		if strings.TrimPrefix(c.Name, "/") == strings.TrimPrefix(id, "/") {
+			return c, nil
+		}
+	}
+	if len(id) >= 12 {
+		for cid, c := range e.containers {
+			if strings.HasPrefix(cid, id) {
+				return c, nil
+			}
+		}
+	}
+	return nil, errdefs.NotFound(fmt.Errorf("No such container: %s", id))
+}
+
+// clone returns a deep copy of v, so callers never share memory with the engine.
+func clone[T any](v T) T {
+	var out T
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		panic(err)
+	}
+	return out
+}
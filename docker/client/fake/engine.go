@@ -0,0 +1,443 @@
+// Package fake provides an in-memory Docker engine implementing client.Client.
+//
+// It keeps containers, images, registry digests and stats in memory and
+// emits the same events a real daemon would, so the cache, the controllers
+// and the TUI models can be exercised without a running Docker daemon.
+package fake
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/kernaxis/gmd/docker/client"
+)
+
+// Operation identifies an engine call on which an error can be injected with FailOn.
+type Operation string
+
+const (
+	OpContainerList     Operation = "container-list"
+	OpContainerInspect  Operation = "container-inspect"
+	OpContainerStats    Operation = "container-stats"
+	OpContainerStart    Operation = "container-start"
+	OpContainerStop     Operation = "container-stop"
+	OpContainerRestart  Operation = "container-restart"
+	OpContainerRemove   Operation = "container-remove"
+	OpContainerRename   Operation = "container-rename"
+	OpContainerCreate   Operation = "container-create"
+	OpContainerLogs     Operation = "container-logs"
+	OpContainerExec     Operation = "container-exec"
+	OpImageList         Operation = "image-list"
+	OpImageHistory      Operation = "image-history"
+	OpImageRemove       Operation = "image-remove"
+	OpImageTag          Operation = "image-tag"
+	OpImagePull         Operation = "image-pull"
+	OpVolumeList        Operation = "volume-list"
+	OpVolumeInspect     Operation = "volume-inspect"
+	OpVolumeRemove      Operation = "volume-remove"
+	OpVolumePrune       Operation = "volume-prune"
+	OpNetworkList       Operation = "network-list"
+	OpNetworkInspect    Operation = "network-inspect"
+	OpNetworkCreate     Operation = "network-create"
+	OpNetworkRemove     Operation = "network-remove"
+	OpNetworkConnect    Operation = "network-connect"
+	OpNetworkDisconnect Operation = "network-disconnect"
+	OpDiskUsage         Operation = "disk-usage"
+	OpCheckUpdate       Operation = "check-update"
+	OpDiffImage         Operation = "diff-image"
+)
+
+// Engine is an in-memory Docker engine.
+// The zero value is not usable, use New to create an Engine.
+type Engine struct {
+	mu         sync.Mutex
+	seq        int                                    // seq is used to generate unique IDs.
+	containers map[string]*container.InspectResponse  // containers is a map of container IDs to their inspect data.
+	images     map[string]*image.Summary              // images is a map of image IDs to their summary.
+	history    map[string][]image.HistoryResponseItem // history is a map of image IDs to their layers.
+	volumes    map[string]*volume.Volume              // volumes is a map of volume names to their data, usage included.
+	networks   map[string]*network.Inspect            // networks is a map of network IDs to their data, without connected containers.
+	remote     map[string]string                      // remote is a map of image references to the digest published by the registry.
+	layers     map[string][]string                    // layers is a map of image references to the layers sent during a pull.
+	configs    map[string]client.ImageConfig          // configs is a map of image IDs to their configuration.
+	remoteCfgs map[string]client.ImageConfig          // remoteCfgs is a map of image references to the configuration published by the registry.
+	stats      map[string]container.StatsResponse     // stats is a map of container IDs to the stats returned by ContainerStats.
+	logs       map[string][]client.LogLine            // logs is a map of container IDs to the lines written with WriteLogs.
+	binaries   map[string][]string                    // binaries is a map of container IDs to the executables Exec can run, /bin/sh when not set.
+	health     map[string]container.HealthStatus      // health is a map of image digests to the health of the containers started from them.
+	exits      map[string]int                         // exits is a map of image digests to the exit code of the containers started from them, which exit at once.
+	failures   map[Operation]error                    // failures is a map of operations to the error they must return.
+	passes     map[Operation]int                      // passes is a map of operations to the calls left to succeed before failing.
+	events     chan events.Message                    // events is the channel of the current events subscription.
+	errors     chan error                             // errors is the error channel of the current events subscription.
+	now        func() time.Time                       // now returns the time used for created dates and events.
+}
+
+var _ client.Client = (*Engine)(nil)
+
+// New returns an in-memory engine with only the predefined bridge, host and none networks.
+func New() *Engine {
+	e := &Engine{
+		containers: make(map[string]*container.InspectResponse),
+		images:     make(map[string]*image.Summary),
+		history:    make(map[string][]image.HistoryResponseItem),
+		volumes:    make(map[string]*volume.Volume),
+		networks:   make(map[string]*network.Inspect),
+		remote:     make(map[string]string),
+		layers:     make(map[string][]string),
+		configs:    make(map[string]client.ImageConfig),
+		remoteCfgs: make(map[string]client.ImageConfig),
+		stats:      make(map[string]container.StatsResponse),
+		logs:       make(map[string][]client.LogLine),
+		binaries:   make(map[string][]string),
+		health:     make(map[string]container.HealthStatus),
+		exits:      make(map[string]int),
+		failures:   make(map[Operation]error),
+		passes:     make(map[Operation]int),
+		now:        time.Now,
+	}
+	for _, n := range predefinedNetworks {
+		if _, err := e.createNetwork(n.name, n.driver, n.subnet); err != nil {
+			panic(err)
+		}
+	}
+	return e
+}
+
+// Endpoint returns a fake endpoint identifying the in-memory engine.
+func (e *Engine) Endpoint() client.Endpoint {
+	return client.Endpoint{Context: "fake", Host: "fake://engine"}
+}
+
+// AddImage adds an image tagged with ref to the engine.
+// If digest is not empty, it is recorded as the repo digest of the image.
+// The tag is moved from any other image already holding it, like a real pull does.
+// It returns the ID of the new image.
+func (e *Engine) AddImage(ref string, digest string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.addImage(ref, digest)
+}
+
+// AddContainer creates a container named name from the image tagged ref.
+// The image is created if it does not exist. When running is true the container is started.
+// The container is connected to the default bridge network.
+// It returns the ID of the new container.
+func (e *Engine) AddContainer(name, ref string, running bool) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	img := e.imageByRef(ref)
+	if img == nil {
+		img = e.images[e.addImage(ref, "")]
+	}
+
+	networks := map[string]*network.EndpointSettings{}
+	if bridge, err := e.network("bridge"); err == nil {
+		e.attach(networks, bridge)
+	}
+
+	cont := e.newContainer(&container.Config{Image: ref}, &container.HostConfig{NetworkMode: "bridge"}, networks, name, img.ID)
+	if running {
+		e.setRunning(cont, true)
+	}
+	return cont.ID
+}
+
+// SetLabels replaces the labels of the given container.
+func (e *Engine) SetLabels(id string, labels map[string]string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	c, err := e.container(id)
+	if err != nil {
+		return err
+	}
+	c.Config.Labels = labels
+	return nil
+}
+
+// SetRemoteDigest sets the digest the registry publishes for ref.
+// The given layers are reported by PullImageWithProgress when the image is pulled.
+// The tags of the references set for a repository are its tags listed by CheckUpdate.
+func (e *Engine) SetRemoteDigest(ref, digest string, layers ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.remote[ref] = digest
+	e.layers[ref] = layers
+}
+
+// SetImageConfig sets the configuration of the local image tagged ref.
+func (e *Engine) SetImageConfig(ref string, config client.ImageConfig) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	img := e.imageByRef(ref)
+	if img == nil {
+		return errdefs.NotFound(fmt.Errorf("No such image: %s", ref))
+	}
+	e.configs[img.ID] = config
+	return nil
+}
+
+// SetRemoteConfig sets the configuration the registry publishes for ref,
+// given to the image when it is pulled.
+func (e *Engine) SetRemoteConfig(ref string, config client.ImageConfig) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.remoteCfgs[ref] = config
+}
+
+// SetStats sets the stats streamed by ContainerStats for the given container.
+// The counters are reported as is, update them to simulate activity.
+func (e *Engine) SetStats(id string, stats container.StatsResponse) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stats[id] = stats
+}
+
+// SetImageHealth makes the containers started from the image with the given
+// repo digest report the given health status, as if the image had a HEALTHCHECK.
+// An empty status removes the healthcheck.
+func (e *Engine) SetImageHealth(digest string, status container.HealthStatus) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if status == "" {
+		delete(e.health, digest)
+		return
+	}
+	e.health[digest] = status
+}
+
+// SetImageExit makes the containers started from the image with the given
+// repo digest exit at once with the given code, like a one-shot job.
+// A negative code makes them keep running.
+func (e *Engine) SetImageExit(digest string, code int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if code < 0 {
+		delete(e.exits, digest)
+		return
+	}
+	e.exits[digest] = code
+}
+
+// FailOn makes every following call to op return err.
+// A nil error removes the failure.
+func (e *Engine) FailOn(op Operation, err error) {
+	e.FailAfter(op, 0, err)
+}
+
+// FailAfter makes the calls to op return err once n more calls succeeded.
+// A nil error removes the failure.
+func (e *Engine) FailAfter(op Operation, n int, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err == nil {
+		delete(e.failures, op)
+		delete(e.passes, op)
+		return
+	}
+	e.failures[op] = err
+	e.passes[op] = n
+}
+
+// SetClock replaces the clock used for created dates and event timestamps.
+func (e *Engine) SetClock(now func() time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.now = now
+}
+
+// failure returns the error injected for op, if any.
+// The caller must hold e.mu.
+func (e *Engine) failure(op Operation) error {
+	if e.passes[op] > 0 {
+		e.passes[op]--
+		return nil
+	}
+	return e.failures[op]
+}
+
+// nextID returns a new unique 64 hexadecimal characters identifier.
+// The caller must hold e.mu.
+func (e *Engine) nextID() string {
+	e.seq++
+	sum := sha256.Sum256([]byte(fmt.Sprintf("gmd-fake-%d", e.seq)))
+	return hex.EncodeToString(sum[:])
+}
+
+// addImage adds an image tagged with ref and returns its ID.
+// The caller must hold e.mu.
+func (e *Engine) addImage(ref string, digest string) string {
+	id := "sha256:" + e.nextID()
+
+	for _, img := range e.images {
+		img.RepoTags = removeString(img.RepoTags, ref)
+	}
+
+	img := &image.Summary{
+		ID:       id,
+		RepoTags: []string{ref},
+		Created:  e.now().Unix(),
+		Size:     int64(len(ref)) * 1024 * 1024,
+	}
+	if digest != "" {
+		img.RepoDigests = []string{repository(ref) + "@" + digest}
+	}
+	e.images[id] = img
+	e.history[id] = []image.HistoryResponseItem{
+		{ID: id, Created: img.Created, Size: img.Size, Tags: img.RepoTags},
+	}
+	return id
+}
+
+// imageByRef returns the image tagged with ref or matching the ID ref.
+// The caller must hold e.mu.
+func (e *Engine) imageByRef(ref string) *image.Summary {
+	if img, ok := e.images[ref]; ok {
+		return img
+	}
+	for _, img := range e.images {
+		for _, tag := range img.RepoTags {
+			if tag == ref || tag == ref+":latest" {
+				return img
+			}
+		}
+	}
+	return nil
+}
+
+// newContainer registers a new created container.
+// The caller must hold e.mu.
+func (e *Engine) newContainer(config *container.Config, hostConfig *container.HostConfig, networks map[string]*network.EndpointSettings, name string, imageID string) *container.InspectResponse {
+	id := e.nextID()
+	if name == "" {
+		name = id[:12]
+	}
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	if networks == nil {
+		networks = map[string]*network.EndpointSettings{}
+	}
+	if hostConfig == nil {
+		hostConfig = &container.HostConfig{}
+	}
+
+	cont := &container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         id,
+			Created:    e.now().Format(time.RFC3339Nano),
+			Name:       name,
+			Image:      imageID,
+			State:      &container.State{Status: container.StateCreated},
+			HostConfig: hostConfig,
+		},
+		Config:          config,
+		NetworkSettings: &container.NetworkSettings{Networks: networks},
+	}
+	e.containers[id] = cont
+	e.emit(events.ContainerEventType, events.ActionCreate, id)
+	return cont
+}
+
+// setRunning updates the state of the given container.
+// The caller must hold e.mu.
+func (e *Engine) setRunning(cont *container.InspectResponse, running bool) {
+	now := e.now().Format(time.RFC3339Nano)
+	if running {
+		cont.State.Status = container.StateRunning
+		cont.State.Running = true
+		cont.State.ExitCode = 0
+		cont.State.StartedAt = now
+		cont.State.Health = e.imageHealth(cont.Image)
+		e.emitEndpoints(events.ActionConnect, cont)
+		e.emit(events.ContainerEventType, events.ActionStart, cont.ID)
+		return
+	}
+	cont.State.Status = container.StateExited
+	cont.State.Running = false
+	cont.State.FinishedAt = now
+	e.emitEndpoints(events.ActionDisconnect, cont)
+	e.emit(events.ContainerEventType, events.ActionDie, cont.ID)
+	e.emit(events.ContainerEventType, events.ActionStop, cont.ID)
+}
+
+// imageHealth returns the health of a container started from the given image,
+// nil when the image has no healthcheck.
+// The caller must hold e.mu.
+func (e *Engine) imageHealth(imageID string) *container.Health {
+	img, ok := e.images[imageID]
+	if !ok {
+		return nil
+	}
+	for _, d := range img.RepoDigests {
+		if _, digest, ok := strings.Cut(d, "@"); ok {
+			if status, ok := e.health[digest]; ok {
+				return &container.Health{Status: status}
+			}
+		}
+	}
+	return nil
+}
+
+// imageExit returns the exit code of a container started from the given
+// image, false when it keeps running.
+// The caller must hold e.mu.
+func (e *Engine) imageExit(imageID string) (int, bool) {
+	img, ok := e.images[imageID]
+	if !ok {
+		return 0, false
+	}
+	for _, d := range img.RepoDigests {
+		if _, digest, ok := strings.Cut(d, "@"); ok {
+			if code, ok := e.exits[digest]; ok {
+				return code, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// emitEndpoints sends a network event for every network the container is connected to,
+// like the daemon does when the endpoints are brought up or down.
+// The caller must hold e.mu.
+func (e *Engine) emitEndpoints(action events.Action, cont *container.InspectResponse) {
+	for name := range cont.NetworkSettings.Networks {
+		if n, err := e.network(name); err == nil {
+			e.emitNetwork(action, n, cont.ID)
+		}
+	}
+}
+
+// repository returns the repository part of an image reference.
+func repository(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i]
+	}
+	return ref
+}
+
+func removeString(s []string, v string) []string {
+	out := make([]string, 0, len(s))
+	for _, e := range s {
+		if e != v {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package fake
+
+import (
+	"log"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// eventsBufferSize is the number of events kept for a subscriber before they are dropped.
+const eventsBufferSize = 1024
+
+// StartEvents subscribes to the events of the engine.
+// Only one subscription is active at a time, a new call replaces the previous one.
+func (e *Engine) StartEvents() (<-chan events.Message, <-chan error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.events != nil {
+		close(e.events)
+	}
+	e.events = make(chan events.Message, eventsBufferSize)
+	e.errors = make(chan error, 1)
+	return e.events, e.errors
+}
+
+// StopEvents cancels the subscription started by StartEvents.
+func (e *Engine) StopEvents() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.events != nil {
+		close(e.events)
+		e.events = nil
+	}
+}
+
+// Emit sends an arbitrary event to the current subscriber.
+func (e *Engine) Emit(msg events.Message) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.send(msg)
+}
+
+// SendError sends an error on the error channel of the current subscription,
+// like the daemon does when the events stream is interrupted.
+func (e *Engine) SendError(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.errors != nil {
+		select {
+		case e.errors <- err:
+		default:
+		}
+	}
+}
+
+// emit builds an event for the given actor and sends it to the current subscriber.
+// The caller must hold e.mu.
+func (e *Engine) emit(typ events.Type, action events.Action, actorID string) {
+	now := e.now()
+	e.send(events.Message{
+		Type:     typ,
+		Action:   action,
+		Actor:    events.Actor{ID: actorID},
+		Scope:    "local",
+		Time:     now.Unix(),
+		TimeNano: now.UnixNano(),
+	})
+}
+
+// send delivers msg without blocking, events are dropped when nobody listens.
+// The caller must hold e.mu.
+func (e *Engine) send(msg events.Message) {
+	if e.events == nil {
+		return
+	}
+	select {
+	case e.events <- msg:
+	default:
+		log.Printf("fake engine - event dropped: %+v", msg)
+	}
+}
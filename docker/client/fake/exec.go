@@ -0,0 +1,171 @@
+package fake
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/kernaxis/gmd/docker/client"
+)
+
+// defaultBinaries are the executables of a container without SetBinaries.
+var defaultBinaries = []string{"/bin/sh"}
+
+// SetBinaries sets the executables Exec can run in the given container.
+func (e *Engine) SetBinaries(id string, paths ...string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	c, err := e.container(id)
+	if err != nil {
+		return err
+	}
+	e.binaries[c.ID] = paths
+	return nil
+}
+
+// Exec runs a command in a running container.
+//
+// A command run with -c exits with code 0 without output. Any other command
+// behaves like a minimal shell: it echoes the lines written on its input
+// after a "$ " prompt until it reads "exit" or its input is closed.
+// A command which is not one of the binaries of the container exits with code 127.
+func (e *Engine) Exec(ctx context.Context, containerID string, config client.ExecConfig) (client.ExecSession, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpContainerExec); err != nil {
+		return nil, err
+	}
+	c, err := e.container(containerID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.State.Running {
+		return nil, errdefs.Conflict(fmt.Errorf("container %s is not running", c.ID))
+	}
+	if len(config.Cmd) == 0 {
+		return nil, errdefs.InvalidParameter(fmt.Errorf("no exec command specified"))
+	}
+
+	binaries, ok := e.binaries[c.ID]
+	if !ok {
+		binaries = defaultBinaries
+	}
+
+	s := newExecSession(config)
+	switch {
+	case !slices.Contains(binaries, config.Cmd[0]):
+		go s.exit(127, fmt.Sprintf("exec: %q: executable file not found in $PATH\r\n", config.Cmd[0]))
+	case slices.Contains(config.Cmd[1:], "-c"):
+		go s.exit(0, "")
+	default:
+		go s.shell()
+	}
+	return s, nil
+}
+
+// execSession is an in-memory ExecSession.
+// Without TTY, its output is multiplexed like the output of the daemon.
+type execSession struct {
+	stdin  *io.PipeReader
+	input  *io.PipeWriter
+	output *io.PipeReader
+	pipe   *io.PipeWriter
+	stdout io.Writer
+	stderr io.Writer
+
+	mu     sync.Mutex
+	code   int
+	done   bool
+	height uint
+	width  uint
+}
+
+func newExecSession(config client.ExecConfig) *execSession {
+	s := &execSession{height: config.Height, width: config.Width}
+	s.stdin, s.input = io.Pipe()
+	s.output, s.pipe = io.Pipe()
+	s.stdout, s.stderr = s.pipe, s.pipe
+	if !config.Tty {
+		s.stdout = stdcopy.NewStdWriter(s.pipe, stdcopy.Stdout)
+		s.stderr = stdcopy.NewStdWriter(s.pipe, stdcopy.Stderr)
+	}
+	return s
+}
+
+// Size returns the current size of the terminal of the session.
+func (s *execSession) Size() (height, width uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.height, s.width
+}
+
+// shell echoes the input lines until "exit" or the end of the input.
+func (s *execSession) shell() {
+	scanner := bufio.NewScanner(s.stdin)
+	fmt.Fprint(s.stdout, "$ ")
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "exit" {
+			break
+		}
+		if line != "" {
+			fmt.Fprintf(s.stdout, "%s\r\n", line)
+		}
+		fmt.Fprint(s.stdout, "$ ")
+	}
+	s.exit(0, "")
+}
+
+// exit writes the given output and ends the session with code.
+func (s *execSession) exit(code int, output string) {
+	if output != "" {
+		fmt.Fprint(s.stderr, output)
+	}
+	s.mu.Lock()
+	s.code = code
+	s.done = true
+	s.mu.Unlock()
+	s.pipe.Close()
+	s.stdin.Close()
+}
+
+func (s *execSession) Read(p []byte) (int, error) {
+	return s.output.Read(p)
+}
+
+func (s *execSession) Write(p []byte) (int, error) {
+	return s.input.Write(p)
+}
+
+func (s *execSession) Close() error {
+	s.input.Close()
+	return s.output.Close()
+}
+
+func (s *execSession) CloseWrite() error {
+	return s.input.Close()
+}
+
+func (s *execSession) Resize(ctx context.Context, height, width uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.height, s.width = height, width
+	return nil
+}
+
+func (s *execSession) ExitCode(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.done {
+		return 0, fmt.Errorf("exec is still running")
+	}
+	return s.code, nil
+}
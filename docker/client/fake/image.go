@@ -0,0 +1,162 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/errdefs"
+)
+
+// ImageList returns a list of images on the engine.
+func (e *Engine) ImageList() ([]image.Summary, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpImageList); err != nil {
+		return nil, err
+	}
+
+	out := make([]image.Summary, 0, len(e.images))
+	for _, img := range e.images {
+		s := clone(*img)
+		s.Containers = int64(e.imageUsage(img.ID))
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// ImageHistory returns the history of the image with the given ID.
+func (e *Engine) ImageHistory(imageID string) ([]image.HistoryResponseItem, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpImageHistory); err != nil {
+		return nil, err
+	}
+
+	img := e.imageByRef(imageID)
+	if img == nil {
+		return nil, errdefs.NotFound(fmt.Errorf("No such image: %s", imageID))
+	}
+	return clone(e.history[img.ID]), nil
+}
+
+// DeleteImage deletes an image from the engine.
+// Like the daemon without force, it refuses to delete an image used by a container.
+func (e *Engine) DeleteImage(ctx context.Context, imageID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpImageRemove); err != nil {
+		return err
+	}
+
+	img := e.imageByRef(imageID)
+	if img == nil {
+		return errdefs.NotFound(fmt.Errorf("No such image: %s", imageID))
+	}
+	if e.imageUsage(img.ID) > 0 {
+		return errdefs.Conflict(fmt.Errorf("conflict: unable to delete %s - image is being used by a container", strings.TrimPrefix(img.ID, "sha256:")[:12]))
+	}
+
+	delete(e.images, img.ID)
+	delete(e.history, img.ID)
+	e.emit(events.ImageEventType, events.ActionDelete, img.ID)
+	return nil
+}
+
+// TagImage tags the image with ref, moving the tag from any other image already holding it.
+func (e *Engine) TagImage(ctx context.Context, imageID, ref string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpImageTag); err != nil {
+		return err
+	}
+
+	img := e.imageByRef(imageID)
+	if img == nil {
+		return errdefs.NotFound(fmt.Errorf("No such image: %s", imageID))
+	}
+	for _, other := range e.images {
+		other.RepoTags = removeString(other.RepoTags, ref)
+	}
+	img.RepoTags = append(img.RepoTags, ref)
+	e.emit(events.ImageEventType, events.ActionTag, img.ID)
+	return nil
+}
+
+// PullImageWithProgress pulls imageRef from the in-memory registry set up with SetRemoteDigest.
+// It reports the same kind of JSON messages the daemon streams during a pull.
+func (e *Engine) PullImageWithProgress(ctx context.Context, imageRef string, progress func(map[string]interface{})) error {
+	e.mu.Lock()
+
+	if err := e.failure(OpImagePull); err != nil {
+		e.mu.Unlock()
+		return err
+	}
+
+	digest, ok := e.remote[imageRef]
+	if !ok {
+		e.mu.Unlock()
+		return errdefs.NotFound(fmt.Errorf("manifest for %s not found: manifest unknown", imageRef))
+	}
+	layers := e.layers[imageRef]
+
+	upToDate := false
+	if img := e.imageByRef(imageRef); img != nil {
+		for _, d := range img.RepoDigests {
+			if strings.HasSuffix(d, "@"+digest) {
+				upToDate = true
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	tag := imageRef[len(repository(imageRef)):]
+	progress(map[string]interface{}{"status": "Pulling from " + repository(imageRef), "id": strings.TrimPrefix(tag, ":")})
+
+	if !upToDate {
+		for _, layer := range layers {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			progress(map[string]interface{}{"status": "Pulling fs layer", "id": layer})
+			progress(map[string]interface{}{"status": "Downloading", "id": layer, "progress": "[==================================================>]"})
+			progress(map[string]interface{}{"status": "Pull complete", "id": layer})
+		}
+	}
+
+	progress(map[string]interface{}{"status": "Digest: " + digest})
+
+	if upToDate {
+		progress(map[string]interface{}{"status": "Status: Image is up to date for " + imageRef})
+		return nil
+	}
+
+	e.mu.Lock()
+	id := e.addImage(imageRef, digest)
+	if config, ok := e.remoteCfgs[imageRef]; ok {
+		e.configs[id] = config
+	}
+	e.emit(events.ImageEventType, events.ActionPull, imageRef)
+	e.mu.Unlock()
+
+	progress(map[string]interface{}{"status": "Status: Downloaded newer image for " + imageRef})
+	return nil
+}
+
+// imageUsage returns the number of containers using the image with the given ID.
+// The caller must hold e.mu.
+func (e *Engine) imageUsage(id string) int {
+	n := 0
+	for _, c := range e.containers {
+		if c.Image == id {
+			n++
+		}
+	}
+	return n
+}
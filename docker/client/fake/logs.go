@@ -0,0 +1,86 @@
+package fake
+
+import (
+	"context"
+	"time"
+
+	"github.com/kernaxis/gmd/docker/client"
+)
+
+// logsPollInterval is the delay between two reads of the logs in follow mode.
+const logsPollInterval = 50 * time.Millisecond
+
+// WriteLogs appends lines written on the given stream to the logs of the container.
+// The lines are timestamped with the clock of the engine.
+func (e *Engine) WriteLogs(id, stream string, lines ...string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	c, err := e.container(id)
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		e.logs[c.ID] = append(e.logs[c.ID], client.LogLine{Stream: stream, Timestamp: e.now(), Text: l})
+	}
+	return nil
+}
+
+// ContainerLogs reports the lines written with WriteLogs matching the options.
+// In follow mode, the lines written later are reported until ctx is cancelled
+// or the container is removed.
+func (e *Engine) ContainerLogs(ctx context.Context, id string, options client.LogsOptions, line func(client.LogLine)) error {
+	e.mu.Lock()
+	if err := e.failure(OpContainerLogs); err != nil {
+		e.mu.Unlock()
+		return err
+	}
+	c, err := e.container(id)
+	if err != nil {
+		e.mu.Unlock()
+		return err
+	}
+	id = c.ID
+	logs := e.logs[id]
+	if options.Tail > 0 && len(logs) > options.Tail {
+		logs = logs[len(logs)-options.Tail:]
+	}
+	sent := len(e.logs[id])
+	e.mu.Unlock()
+
+	report := func(l client.LogLine) {
+		if !options.Since.IsZero() && l.Timestamp.Before(options.Since) {
+			return
+		}
+		if !options.Until.IsZero() && l.Timestamp.After(options.Until) {
+			return
+		}
+		line(l)
+	}
+
+	for _, l := range logs {
+		report(l)
+	}
+
+	for options.Follow {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(logsPollInterval):
+		}
+
+		e.mu.Lock()
+		if _, ok := e.containers[id]; !ok {
+			e.mu.Unlock()
+			return nil
+		}
+		logs := e.logs[id][sent:]
+		sent = len(e.logs[id])
+		e.mu.Unlock()
+
+		for _, l := range logs {
+			report(l)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+// predefinedNetworks are the networks every daemon creates and which cannot be removed.
+var predefinedNetworks = []struct{ name, driver, subnet string }{
+	{"bridge", "bridge", "172.17.0.0/16"},
+	{"host", "host", ""},
+	{"none", "null", ""},
+}
+
+// AddNetwork creates a local network with the given name, driver and subnet.
+// An empty subnet lets the engine pick one. It returns the ID of the new network.
+func (e *Engine) AddNetwork(name, driver, subnet string) (string, error) {
+	return e.CreateNetwork(context.Background(), name, driver, subnet)
+}
+
+// NetworkList returns the list of networks on the engine, without connected containers.
+func (e *Engine) NetworkList() ([]network.Summary, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpNetworkList); err != nil {
+		return nil, err
+	}
+
+	out := make([]network.Summary, 0, len(e.networks))
+	for _, n := range e.networks {
+		s := clone(*n)
+		s.Containers = map[string]network.EndpointResource{}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// NetworkInspect returns the network with the given ID or name.
+// Like the daemon, only running containers are listed as connected.
+func (e *Engine) NetworkInspect(id string) (network.Inspect, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpNetworkInspect); err != nil {
+		return network.Inspect{}, err
+	}
+
+	n, err := e.network(id)
+	if err != nil {
+		return network.Inspect{}, err
+	}
+
+	out := clone(*n)
+	out.Containers = make(map[string]network.EndpointResource)
+	for cid, c := range e.containers {
+		ep, ok := c.NetworkSettings.Networks[n.Name]
+		if !ok || !c.State.Running {
+			continue
+		}
+		res := network.EndpointResource{
+			Name:       strings.TrimPrefix(c.Name, "/"),
+			EndpointID: ep.EndpointID,
+			MacAddress: ep.MacAddress,
+		}
+		if ep.IPAddress != "" {
+			res.IPv4Address = fmt.Sprintf("%s/%d", ep.IPAddress, ep.IPPrefixLen)
+		}
+		out.Containers[cid] = res
+	}
+	return out, nil
+}
+
+// CreateNetwork creates a network and returns its ID.
+// Without subnet, a free 172.x.0.0/16 subnet is picked like the default daemon pools do.
+func (e *Engine) CreateNetwork(ctx context.Context, name, driver, subnet string) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpNetworkCreate); err != nil {
+		return "", err
+	}
+	return e.createNetwork(name, driver, subnet)
+}
+
+// DeleteNetwork deletes the network with the given ID or name.
+// Like the daemon, it refuses to delete a predefined network or a network with active endpoints.
+func (e *Engine) DeleteNetwork(ctx context.Context, id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpNetworkRemove); err != nil {
+		return err
+	}
+
+	n, err := e.network(id)
+	if err != nil {
+		return err
+	}
+	for _, p := range predefinedNetworks {
+		if p.name == n.Name {
+			return errdefs.Forbidden(fmt.Errorf("%s is a pre-defined network and cannot be removed", n.Name))
+		}
+	}
+	for _, c := range e.containers {
+		if _, ok := c.NetworkSettings.Networks[n.Name]; ok && c.State.Running {
+			return errdefs.Forbidden(fmt.Errorf("error while removing network: network %s id %s has active endpoints", n.Name, n.ID))
+		}
+	}
+
+	for _, c := range e.containers {
+		delete(c.NetworkSettings.Networks, n.Name)
+	}
+	delete(e.networks, n.ID)
+	e.emitNetwork(events.ActionDestroy, n, "")
+	return nil
+}
+
+// ConnectNetwork connects the container to the network and allocates it an address.
+func (e *Engine) ConnectNetwork(ctx context.Context, networkID, containerID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpNetworkConnect); err != nil {
+		return err
+	}
+
+	n, err := e.network(networkID)
+	if err != nil {
+		return err
+	}
+	c, err := e.container(containerID)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.NetworkSettings.Networks[n.Name]; ok {
+		return errdefs.Forbidden(fmt.Errorf("endpoint with name %s already exists in network %s", strings.TrimPrefix(c.Name, "/"), n.Name))
+	}
+
+	e.attach(c.NetworkSettings.Networks, n)
+	e.emitNetwork(events.ActionConnect, n, c.ID)
+	return nil
+}
+
+// DisconnectNetwork disconnects the container from the network.
+func (e *Engine) DisconnectNetwork(ctx context.Context, networkID, containerID string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpNetworkDisconnect); err != nil {
+		return err
+	}
+
+	n, err := e.network(networkID)
+	if err != nil {
+		return err
+	}
+	c, err := e.container(containerID)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.NetworkSettings.Networks[n.Name]; !ok {
+		return errdefs.Forbidden(fmt.Errorf("container %s is not connected to network %s", c.ID, n.Name))
+	}
+
+	delete(c.NetworkSettings.Networks, n.Name)
+	e.emitNetwork(events.ActionDisconnect, n, c.ID)
+	return nil
+}
+
+// createNetwork registers a new network and returns its ID.
+// The caller must hold e.mu.
+func (e *Engine) createNetwork(name, driver, subnet string) (string, error) {
+	if _, err := e.network(name); err == nil {
+		return "", errdefs.Conflict(fmt.Errorf("network with name %s already exists", name))
+	}
+	if driver == "" {
+		driver = "bridge"
+	}
+
+	n := &network.Inspect{
+		Name:       name,
+		ID:         e.nextID(),
+		Created:    e.now(),
+		Scope:      "local",
+		Driver:     driver,
+		EnableIPv4: true,
+		IPAM:       network.IPAM{Driver: "default"},
+		Containers: map[string]network.EndpointResource{},
+		Options:    map[string]string{},
+		Labels:     map[string]string{},
+	}
+
+	if driver == "bridge" && subnet == "" {
+		subnet = e.freeSubnet()
+	}
+	if subnet != "" {
+		prefix, err := netip.ParsePrefix(subnet)
+		if err != nil {
+			return "", errdefs.InvalidParameter(fmt.Errorf("invalid subnet %s: %w", subnet, err))
+		}
+		prefix = prefix.Masked()
+		n.IPAM.Config = []network.IPAMConfig{{
+			Subnet:  prefix.String(),
+			Gateway: prefix.Addr().Next().String(),
+		}}
+	}
+
+	e.networks[n.ID] = n
+	e.emitNetwork(events.ActionCreate, n, "")
+	return n.ID, nil
+}
+
+// freeSubnet returns the first 172.x.0.0/16 subnet not used by a network.
+// The caller must hold e.mu.
+func (e *Engine) freeSubnet() string {
+	used := make(map[string]bool)
+	for _, n := range e.networks {
+		for _, cfg := range n.IPAM.Config {
+			used[cfg.Subnet] = true
+		}
+	}
+	for i := 17; i < 32; i++ {
+		subnet := fmt.Sprintf("172.%d.0.0/16", i)
+		if !used[subnet] {
+			return subnet
+		}
+	}
+	return ""
+}
+
+// attach adds an endpoint on network n to the given container networks.
+// The first free address of the subnet, after the gateway, is allocated.
+// The caller must hold e.mu.
+func (e *Engine) attach(networks map[string]*network.EndpointSettings, n *network.Inspect) {
+	ep := &network.EndpointSettings{
+		NetworkID:  n.ID,
+		EndpointID: e.nextID(),
+	}
+
+	if len(n.IPAM.Config) > 0 {
+		prefix, err := netip.ParsePrefix(n.IPAM.Config[0].Subnet)
+		if err == nil {
+			used := map[string]bool{n.IPAM.Config[0].Gateway: true}
+			for _, c := range e.containers {
+				if other, ok := c.NetworkSettings.Networks[n.Name]; ok {
+					used[other.IPAddress] = true
+				}
+			}
+			for addr := prefix.Addr().Next(); prefix.Contains(addr); addr = addr.Next() {
+				if !used[addr.String()] {
+					ep.IPAddress = addr.String()
+					ep.IPPrefixLen = prefix.Bits()
+					ep.Gateway = n.IPAM.Config[0].Gateway
+					b := addr.As4()
+					ep.MacAddress = fmt.Sprintf("02:42:%02x:%02x:%02x:%02x", b[0], b[1], b[2], b[3])
+					break
+				}
+			}
+		}
+	}
+
+	networks[n.Name] = ep
+}
+
+// network returns the network matching the given ID, ID prefix or name.
+// The caller must hold e.mu.
+func (e *Engine) network(id string) (*network.Inspect, error) {
+	if n, ok := e.networks[id]; ok {
+		return n, nil
+	}
+	for _, n := range e.networks {
+		if n.Name == id {
+			return n, nil
+		}
+	}
+	if len(id) >= 12 {
+		for nid, n := range e.networks {
+			if strings.HasPrefix(nid, id) {
+				return n, nil
+			}
+		}
+	}
+	return nil, errdefs.NotFound(fmt.Errorf("network %s not found", id))
+}
+
+// emitNetwork sends a network event with the attributes set by the daemon.
+// The caller must hold e.mu.
+func (e *Engine) emitNetwork(action events.Action, n *network.Inspect, containerID string) {
+	attributes := map[string]string{"name": n.Name, "type": n.Driver}
+	if containerID != "" {
+		attributes["container"] = containerID
+	}
+	now := e.now()
+	e.send(events.Message{
+		Type:     events.NetworkEventType,
+		Action:   action,
+		Actor:    events.Actor{ID: n.ID, Attributes: attributes},
+		Scope:    "local",
+		Time:     now.Unix(),
+		TimeNano: now.UnixNano(),
+	})
+}
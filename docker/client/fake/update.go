@@ -0,0 +1,109 @@
+package fake
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/kernaxis/gmd/docker/client"
+)
+
+// CheckUpdate checks if the image of the given container differs from the
+// digest published for its reference with SetRemoteDigest. The remote images
+// are single-platform, their digest is also the platform digest.
+func (e *Engine) CheckUpdate(containerID string) (client.UpdateCheck, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpCheckUpdate); err != nil {
+		return client.UpdateCheck{}, err
+	}
+
+	c, err := e.container(containerID)
+	if err != nil {
+		return client.UpdateCheck{}, err
+	}
+	check := client.UpdateCheck{Image: c.Config.Image}
+	check.Tags = e.newerTags(c.Config.Image)
+
+	img, ok := e.images[c.Image]
+	if !ok {
+		return check, fmt.Errorf("image %s not found", c.Image)
+	}
+	if len(img.RepoDigests) == 0 {
+		return check, fmt.Errorf("no RepoDigests for %s", c.Config.Image)
+	}
+	_, check.LocalDigest, _ = strings.Cut(img.RepoDigests[0], "@")
+
+	remote, ok := e.remote[c.Config.Image]
+	if !ok {
+		return check, errdefs.NotFound(fmt.Errorf("manifest for %s not found: manifest unknown", c.Config.Image))
+	}
+	check.RemoteDigest = remote
+	check.PlatformDigest = remote
+
+	for _, d := range img.RepoDigests {
+		if strings.HasSuffix(d, "@"+remote) {
+			check.LocalDigest = remote
+			check.Matched = client.MatchIndex
+			return check, nil
+		}
+	}
+	check.Update = true
+	return check, nil
+}
+
+// newerTags returns the newer tags of the image among the references set with SetRemoteDigest.
+// The caller must hold e.mu.
+func (e *Engine) newerTags(image string) client.TagUpdates {
+	repo := client.ImageRepository(image)
+	var tags []string
+	for ref := range e.remote {
+		if client.ImageRepository(ref) == repo {
+			if tag := client.ImageTag(ref); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return client.NewerTags(client.ImageTag(image), tags)
+}
+
+// DiffImage compares the configuration of the local image of the given container
+// with the one set for image with SetRemoteConfig. The layers sent by the pull of
+// image are all counted as missing layers, of 1 MiB each.
+func (e *Engine) DiffImage(containerID, image string) (client.ImageDiff, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	diff := client.ImageDiff{Image: image}
+	if err := e.failure(OpDiffImage); err != nil {
+		return diff, err
+	}
+
+	c, err := e.container(containerID)
+	if err != nil {
+		return diff, err
+	}
+	img, ok := e.images[c.Image]
+	if !ok {
+		return diff, fmt.Errorf("image %s not found", c.Image)
+	}
+	remote, ok := e.remote[image]
+	if !ok {
+		return diff, errdefs.NotFound(fmt.Errorf("manifest for %s not found: manifest unknown", image))
+	}
+	diff.RemoteDigest = remote
+	if len(img.RepoDigests) > 0 {
+		_, diff.LocalDigest, _ = strings.Cut(img.RepoDigests[0], "@")
+	}
+
+	diff.DiffConfigs(e.configs[img.ID], e.remoteCfgs[image])
+	diff.LocalLayers = len(e.history[img.ID])
+	diff.RemoteLayers = len(e.layers[image])
+	diff.RemoteSize = int64(len(e.layers[image])) << 20
+	if diff.LocalDigest != remote {
+		diff.DownloadLayers = diff.RemoteLayers
+		diff.DownloadSize = diff.RemoteSize
+	}
+	return diff, nil
+}
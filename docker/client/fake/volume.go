@@ -0,0 +1,187 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+// AddVolume creates a local volume with the given name and disk usage.
+func (e *Engine) AddVolume(name string, labels map[string]string, size int64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.volumes[name] = &volume.Volume{
+		Name:       name,
+		Driver:     "local",
+		Scope:      "local",
+		Labels:     labels,
+		Mountpoint: path.Join("/var/lib/docker/volumes", name, "_data"),
+		CreatedAt:  e.now().Format(time.RFC3339),
+		UsageData:  &volume.UsageData{Size: size},
+	}
+	e.emit(events.VolumeEventType, events.ActionCreate, name)
+}
+
+// MountVolume mounts the named volume in the given container at destination.
+// The volume is created if it does not exist.
+func (e *Engine) MountVolume(containerID, name, destination string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	c, err := e.container(containerID)
+	if err != nil {
+		return err
+	}
+
+	v, ok := e.volumes[name]
+	if !ok {
+		v = &volume.Volume{
+			Name:       name,
+			Driver:     "local",
+			Scope:      "local",
+			Mountpoint: path.Join("/var/lib/docker/volumes", name, "_data"),
+			CreatedAt:  e.now().Format(time.RFC3339),
+			UsageData:  &volume.UsageData{},
+		}
+		e.volumes[name] = v
+		e.emit(events.VolumeEventType, events.ActionCreate, name)
+	}
+
+	c.Mounts = append(c.Mounts, container.MountPoint{
+		Type:        mount.TypeVolume,
+		Name:        name,
+		Source:      v.Mountpoint,
+		Destination: destination,
+		Driver:      v.Driver,
+		RW:          true,
+	})
+	return nil
+}
+
+// VolumeList returns the list of volumes on the engine, without usage data.
+func (e *Engine) VolumeList() ([]*volume.Volume, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpVolumeList); err != nil {
+		return nil, err
+	}
+
+	out := make([]*volume.Volume, 0, len(e.volumes))
+	for _, v := range e.volumes {
+		c := clone(*v)
+		c.UsageData = nil
+		out = append(out, &c)
+	}
+	return out, nil
+}
+
+// VolumeInspect returns the volume with the given name, without usage data.
+func (e *Engine) VolumeInspect(name string) (volume.Volume, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpVolumeInspect); err != nil {
+		return volume.Volume{}, err
+	}
+
+	v, ok := e.volumes[name]
+	if !ok {
+		return volume.Volume{}, errdefs.NotFound(fmt.Errorf("get %s: no such volume", name))
+	}
+	c := clone(*v)
+	c.UsageData = nil
+	return c, nil
+}
+
+// VolumesDiskUsage returns the usage data of the volumes, indexed by volume name.
+func (e *Engine) VolumesDiskUsage() (map[string]volume.UsageData, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpDiskUsage); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]volume.UsageData, len(e.volumes))
+	for name, v := range e.volumes {
+		out[name] = volume.UsageData{Size: v.UsageData.Size, RefCount: int64(len(e.volumeUsers(name)))}
+	}
+	return out, nil
+}
+
+// DeleteVolume deletes the volume with the given name.
+// Like the daemon without force, it refuses to delete a volume used by a container.
+func (e *Engine) DeleteVolume(ctx context.Context, name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpVolumeRemove); err != nil {
+		return err
+	}
+
+	if _, ok := e.volumes[name]; !ok {
+		return errdefs.NotFound(fmt.Errorf("get %s: no such volume", name))
+	}
+	if users := e.volumeUsers(name); len(users) > 0 {
+		return errdefs.Conflict(fmt.Errorf("remove %s: volume is in use - %v", name, users))
+	}
+
+	delete(e.volumes, name)
+	e.emit(events.VolumeEventType, events.ActionDestroy, name)
+	return nil
+}
+
+// PruneVolumes deletes the anonymous volumes not used by any container, the
+// named ones too when all is set. The anonymous volumes are the ones labelled
+// with types.AnonymousVolumeLabel.
+func (e *Engine) PruneVolumes(ctx context.Context, all bool) (volume.PruneReport, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.failure(OpVolumePrune); err != nil {
+		return volume.PruneReport{}, err
+	}
+
+	var report volume.PruneReport
+	for name, v := range e.volumes {
+		if len(e.volumeUsers(name)) > 0 {
+			continue
+		}
+		if _, anonymous := v.Labels[types.AnonymousVolumeLabel]; !anonymous && !all {
+			continue
+		}
+		delete(e.volumes, name)
+		report.VolumesDeleted = append(report.VolumesDeleted, name)
+		report.SpaceReclaimed += uint64(v.UsageData.Size)
+		e.emit(events.VolumeEventType, events.ActionDestroy, name)
+	}
+	slices.Sort(report.VolumesDeleted)
+	e.emit(events.VolumeEventType, events.ActionPrune, "")
+	return report, nil
+}
+
+// volumeUsers returns the IDs of the containers mounting the named volume.
+// The caller must hold e.mu.
+func (e *Engine) volumeUsers(name string) []string {
+	var out []string
+	for id, c := range e.containers {
+		for _, m := range c.Mounts {
+			if m.Type == mount.TypeVolume && m.Name == name {
+				out = append(out, id)
+				break
+			}
+		}
+	}
+	return out
+}
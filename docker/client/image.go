@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	"github.com/docker/docker/api/types/image"
 )
@@ -10,7 +11,7 @@ import (
 // DeleteImage deletes an image from the Docker daemon.
 // It does not force the deletion of the image, and it does prune children.
 // The function returns an error if the deletion fails.
-func (c *Client) DeleteImage(ctx context.Context, imageID string) error {
+func (c *dockerClient) DeleteImage(ctx context.Context, imageID string) error {
 	_, err := c.cli.ImageRemove(ctx, imageID, image.RemoveOptions{
 		Force:         false,
 		PruneChildren: true,
@@ -18,16 +19,30 @@ func (c *Client) DeleteImage(ctx context.Context, imageID string) error {
 	return err
 }
 
-// PullImageWithProgress pulls an image from the Docker Hub and prints
+// TagImage tags the image with the given ID with ref.
+// The tag is moved from the image holding it, if any.
+func (c *dockerClient) TagImage(ctx context.Context, imageID, ref string) error {
+	return c.cli.ImageTag(ctx, imageID, ref)
+}
+
+// PullImageWithProgress pulls an image from its registry and prints
 // the progress of the pull to the given function.
-// The function returns an error if the pull fails.
-func (c *Client) PullImageWithProgress(ctx context.Context, imageRef string, progress func(map[string]interface{})) (err error) {
-	reader, err := c.cli.ImagePull(ctx, imageRef, image.PullOptions{})
+// The credentials of the registry are read from the Docker configuration.
+// The function returns an error if the pull fails, wrapping ErrAuthRequired
+// when the registry refused the credentials.
+func (c *dockerClient) PullImageWithProgress(ctx context.Context, imageRef string, progress func(map[string]interface{})) (err error) {
+	auth, err := registryAuth(ctx, imageRef)
 	if err != nil {
 		return err
 	}
+	reader, err := c.cli.ImagePull(ctx, imageRef, image.PullOptions{RegistryAuth: auth})
+	if err != nil {
+		return authError(imageRef, err)
+	}
 	defer func() {
-		err = reader.Close()
+		if cerr := reader.Close(); err == nil {
+			err = cerr
+		}
 	}()
 	decoder := json.NewDecoder(reader)
 
@@ -36,9 +51,15 @@ func (c *Client) PullImageWithProgress(ctx context.Context, imageRef string, pro
 		if err := decoder.Decode(&msg); err != nil {
 			return err
 		}
+		// the daemon reports the failures of the pull in the stream
+		if text, ok := msg["error"].(string); ok && text != "" {
+			return authError(imageRef, errors.New(text))
+		}
 		progress(msg)
 	}
 
+	// the next checks compare the pulled image to the registry again
+	forgetRemoteDigest(imageRef)
 	return nil
 }
 
@@ -46,7 +67,7 @@ func (c *Client) PullImageWithProgress(ctx context.Context, imageRef string, pro
 // The function returns an error if the list of images cannot be retrieved.
 // The list of images includes all images on the daemon, including intermediate images.
 // The list of images is sorted by image name.
-func (c *Client) ImageList() ([]image.Summary, error) {
+func (c *dockerClient) ImageList() ([]image.Summary, error) {
 	return c.cli.ImageList(context.Background(), image.ListOptions{All: true})
 }
 
@@ -56,6 +77,6 @@ func (c *Client) ImageList() ([]image.Summary, error) {
 // creation time.
 // The function returns an error if the history of the image cannot be
 // retrieved.
-func (c *Client) ImageHistory(imageID string) ([]image.HistoryResponseItem, error) {
+func (c *dockerClient) ImageHistory(imageID string) ([]image.HistoryResponseItem, error) {
 	return c.cli.ImageHistory(context.Background(), imageID)
 }
@@ -0,0 +1,258 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Labels of the OCI image specification describing the version of an image.
+const (
+	OCIVersionLabel  = "org.opencontainers.image.version"
+	OCIRevisionLabel = "org.opencontainers.image.revision"
+	OCISourceLabel   = "org.opencontainers.image.source"
+)
+
+// ImageConfig is the part of the configuration of an image compared by an
+// ImageDiff, and left out of a recreated container when unchanged.
+type ImageConfig struct {
+	Env          []string
+	ExposedPorts []string
+	Entrypoint   []string
+	Cmd          []string
+	User         string
+	WorkingDir   string
+	Labels       map[string]string
+	Volumes      []string
+	StopSignal   string
+	Healthcheck  *container.HealthConfig
+}
+
+// Change is a changed setting of an image. Old is empty for an added setting,
+// New is empty for a removed one.
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// ImageDiff is the difference between the local image of a container and the
+// image published by the registry for its update.
+type ImageDiff struct {
+	Image          string   // Image is the reference of the new image.
+	Platform       string   // Platform is the platform of the compared images.
+	LocalDigest    string   // LocalDigest is the digest of the local image, empty when it has none.
+	RemoteDigest   string   // RemoteDigest is the digest of the image manifest of the new image.
+	Env            []Change // Env are the changed environment variables, by name.
+	Ports          []Change // Ports are the added and removed exposed ports.
+	Command        []Change // Command are the changed entrypoint, cmd, user and working directory.
+	Labels         []Change // Labels are the changed labels.
+	LocalLayers    int      // LocalLayers is the number of layers of the local image.
+	RemoteLayers   int      // RemoteLayers is the number of layers of the new image.
+	LocalSize      int64    // LocalSize is the compressed size of the local image in the registry, 0 when the registry does not publish it anymore.
+	RemoteSize     int64    // RemoteSize is the compressed size of the new image.
+	DownloadLayers int      // DownloadLayers is the number of layers of the new image missing locally.
+	DownloadSize   int64    // DownloadSize is the compressed size of the missing layers.
+}
+
+// Changed reports whether the configuration of the image changed.
+func (d ImageDiff) Changed() bool {
+	return len(d.Env) > 0 || len(d.Ports) > 0 || len(d.Command) > 0 || len(d.Labels) > 0
+}
+
+// DiffConfigs fills the configuration changes of the diff between the old and the new configuration.
+func (d *ImageDiff) DiffConfigs(old, new ImageConfig) {
+	d.Env = diffMaps(envMap(old.Env), envMap(new.Env))
+	d.Ports = diffMaps(setMap(old.ExposedPorts), setMap(new.ExposedPorts))
+	d.Labels = diffMaps(old.Labels, new.Labels)
+
+	d.Command = nil
+	for _, c := range []Change{
+		{Key: "entrypoint", Old: commandString(old.Entrypoint), New: commandString(new.Entrypoint)},
+		{Key: "cmd", Old: commandString(old.Cmd), New: commandString(new.Cmd)},
+		{Key: "user", Old: old.User, New: new.User},
+		{Key: "workdir", Old: old.WorkingDir, New: new.WorkingDir},
+	} {
+		if c.Old != c.New {
+			d.Command = append(d.Command, c)
+		}
+	}
+}
+
+// diffMaps returns the changed keys of two maps, sorted by key.
+func diffMaps(old, new map[string]string) []Change {
+	var changes []Change
+	for _, k := range slices.Sorted(maps.Keys(old)) {
+		if v, ok := new[k]; !ok || v != old[k] {
+			changes = append(changes, Change{Key: k, Old: old[k], New: v})
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(new)) {
+		if _, ok := old[k]; !ok {
+			changes = append(changes, Change{Key: k, New: new[k]})
+		}
+	}
+	slices.SortStableFunc(changes, func(a, b Change) int { return strings.Compare(a.Key, b.Key) })
+	return changes
+}
+
+// envMap returns the environment variables by name.
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, e := range env {
+		k, v, _ := strings.Cut(e, "=")
+		m[k] = v
+	}
+	return m
+}
+
+// setMap returns a map of the values to themselves, so that diffMaps reports the added and removed values.
+func setMap(values []string) map[string]string {
+	m := make(map[string]string, len(values))
+	for _, v := range values {
+		m[v] = v
+	}
+	return m
+}
+
+// commandString returns the command in the exec form of a Dockerfile, empty when there is none.
+func commandString(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = fmt.Sprintf("%q", a)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// DiffImage compares the local image of the given container with the image
+// published by the registry for image, resolved for the platform of the daemon.
+func (c *dockerClient) DiffImage(containerID, image string) (ImageDiff, error) {
+	diff := ImageDiff{Image: image}
+
+	container, err := c.ContainerInspect(containerID)
+	if err != nil {
+		return diff, err
+	}
+	local, err := c.cli.ImageInspect(context.Background(), container.Image)
+	if err != nil {
+		return diff, err
+	}
+	platform, err := c.daemonPlatform()
+	if err != nil {
+		return diff, err
+	}
+	diff.Platform = platform.String()
+
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return diff, err
+	}
+	registry := ref.Context().RegistryStr()
+	var (
+		remoteConfig *v1.ConfigFile
+		layers       []v1.Layer
+	)
+	// the configuration is fetched on demand, it is read in the request as well
+	err = registryLookups.request(registry, func(opts ...remote.Option) error {
+		img, err := remote.Image(ref, append(opts, remote.WithPlatform(platform), remoteAuth())...)
+		if err != nil {
+			return err
+		}
+		if remoteConfig, err = img.ConfigFile(); err != nil {
+			return err
+		}
+		digest, err := img.Digest()
+		if err != nil {
+			return err
+		}
+		diff.RemoteDigest = digest.String()
+		layers, err = img.Layers()
+		return err
+	})
+	if err != nil {
+		return diff, authError(image, err)
+	}
+
+	diff.DiffConfigs(imageConfigOf(local.Config), ImageConfig{
+		Env:          remoteConfig.Config.Env,
+		ExposedPorts: slices.Collect(maps.Keys(remoteConfig.Config.ExposedPorts)),
+		Entrypoint:   remoteConfig.Config.Entrypoint,
+		Cmd:          remoteConfig.Config.Cmd,
+		User:         remoteConfig.Config.User,
+		WorkingDir:   remoteConfig.Config.WorkingDir,
+		Labels:       remoteConfig.Config.Labels,
+	})
+
+	// the layers are compared by their diff ID, the digest of their uncompressed content
+	localLayers := local.RootFS.Layers
+	diff.LocalLayers = len(localLayers)
+	diff.RemoteLayers = len(layers)
+	for i, l := range layers {
+		size, err := l.Size()
+		if err != nil {
+			return diff, err
+		}
+		diff.RemoteSize += size
+		if i < len(remoteConfig.RootFS.DiffIDs) && !slices.Contains(localLayers, remoteConfig.RootFS.DiffIDs[i].String()) {
+			diff.DownloadLayers++
+			diff.DownloadSize += size
+		}
+	}
+
+	// the compressed size of the local image is only known by the registry
+	if diff.LocalDigest = repoDigest(local.RepoDigests, ref.Context()); diff.LocalDigest != "" {
+		diff.LocalSize = remoteImageSize(ref.Context(), diff.LocalDigest, platform)
+	}
+	return diff, nil
+}
+
+// repoDigest returns the digest of the repo digest of a local image which is
+// in repo, empty when the image was not pulled from it.
+func repoDigest(repoDigests []string, repo name.Repository) string {
+	for _, rd := range repoDigests {
+		d, err := name.NewDigest(rd)
+		if err == nil && d.Context().Name() == repo.Name() {
+			return d.DigestStr()
+		}
+	}
+	return ""
+}
+
+// remoteImageSize returns the compressed size of the image published under
+// the given digest in repo, 0 when the registry does not publish it anymore.
+func remoteImageSize(repo name.Repository, digest string, platform v1.Platform) int64 {
+	var manifest *v1.Manifest
+	err := registryLookups.request(repo.RegistryStr(), func(opts ...remote.Option) error {
+		img, err := remote.Image(repo.Digest(digest), append(opts, remote.WithPlatform(platform), remoteAuth())...)
+		if err != nil {
+			return err
+		}
+		manifest, err = img.Manifest()
+		return err
+	})
+	if err != nil {
+		log.Printf("unable to get the manifest of %s@%s: %s", repo, digest, err)
+		return 0
+	}
+	return manifestSize(manifest)
+}
+
+// manifestSize returns the size of the layers of an image manifest.
+func manifestSize(m *v1.Manifest) int64 {
+	var size int64
+	for _, l := range m.Layers {
+		size += l.Size
+	}
+	return size
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+func TestRepoDigest(t *testing.T) {
+	const (
+		a = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+		b = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	)
+	tests := []struct {
+		repo    string
+		digests []string
+		want    string
+	}{
+		{"nginx", []string{"nginx@" + a}, a},
+		{"nginx", []string{"docker.io/library/nginx@" + a}, a},
+		{"docker.io/library/nginx", []string{"nginx@" + a}, a},
+		{"nginx", []string{"ghcr.io/acme/nginx@" + b, "nginx@" + a}, a},
+		{"ghcr.io/acme/nginx", []string{"nginx@" + a, "ghcr.io/acme/nginx@" + b}, b},
+		{"ghcr.io/acme/nginx", []string{"nginx@" + a}, ""},
+		{"nginx", []string{"nginx@invalid", "nginx@" + a}, a},
+		{"nginx", nil, ""},
+	}
+	for _, tt := range tests {
+		repo, err := name.NewRepository(tt.repo)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := repoDigest(tt.digests, repo); got != tt.want {
+			t.Errorf("repoDigest(%v, %s) = %q, want %q", tt.digests, tt.repo, got, tt.want)
+		}
+	}
+}
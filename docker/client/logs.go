@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Streams of a log line.
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+)
+
+// LogsOptions selects the log lines returned by ContainerLogs.
+type LogsOptions struct {
+	Follow bool      // Follow keeps the stream open and reports new lines until the context is cancelled.
+	Tail   int       // Tail is the number of lines to return from the end of the logs, 0 for all the lines.
+	Since  time.Time // Since only returns the lines written after this time, if not zero.
+	Until  time.Time // Until only returns the lines written before this time, if not zero.
+}
+
+// LogLine is a line written by a container on its standard output or error.
+type LogLine struct {
+	Stream    string    // Stream is Stdout or Stderr.
+	Timestamp time.Time // Timestamp is the time the line was written, as recorded by the daemon.
+	Text      string    // Text is the content of the line, without the trailing newline.
+}
+
+// ContainerLogs reads the logs of the container with the given ID and reports
+// each line to the given function.
+//
+// The stream is demultiplexed into stdout and stderr lines, unless the
+// container runs with a TTY, in which case every line is reported on Stdout.
+// The function returns when the logs have been read, or when ctx is cancelled
+// in follow mode.
+func (c *dockerClient) ContainerLogs(ctx context.Context, id string, options LogsOptions, line func(LogLine)) error {
+	inspect, err := c.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	r, err := c.cli.ContainerLogs(ctx, id, options.sdkOptions())
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	stdout := &logWriter{stream: Stdout, line: line}
+	stderr := &logWriter{stream: Stderr, line: line}
+
+	if inspect.Config != nil && inspect.Config.Tty {
+		_, err = io.Copy(stdout, r)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, r)
+	}
+	stdout.Flush()
+	stderr.Flush()
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// sdkOptions returns the options of the daemon API matching o.
+// Timestamps are always requested, so they can be toggled without reading the logs again.
+func (o LogsOptions) sdkOptions() container.LogsOptions {
+	opts := container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+		Follow:     o.Follow,
+		Tail:       "all",
+	}
+	if o.Tail > 0 {
+		opts.Tail = strconv.Itoa(o.Tail)
+	}
+	if !o.Since.IsZero() {
+		opts.Since = unixNano(o.Since)
+	}
+	if !o.Until.IsZero() {
+		opts.Until = unixNano(o.Until)
+	}
+	return opts
+}
+
+// unixNano formats t the way the daemon expects timestamps: seconds.nanoseconds.
+func unixNano(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// logWriter splits what is written to it into lines and reports them, with
+// the timestamp prefix added by the daemon parsed.
+type logWriter struct {
+	stream string
+	line   func(LogLine)
+	buf    []byte
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.report(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush reports the last line, if it does not end with a newline.
+func (w *logWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.report(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *logWriter) report(b []byte) {
+	b = bytes.TrimSuffix(b, []byte("\r"))
+	l := ParseLogLine(string(b))
+	l.Stream = w.stream
+	w.line(l)
+}
+
+// ParseLogLine parses a log line prefixed by the RFC3339Nano timestamp the daemon adds.
+// A line without a valid timestamp is returned as is, with a zero Timestamp.
+func ParseLogLine(s string) LogLine {
+	if i := strings.IndexByte(s, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, s[:i]); err == nil {
+			return LogLine{Timestamp: t, Text: s[i+1:]}
+		}
+	}
+	return LogLine{Text: s}
+}
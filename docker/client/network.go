@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types/network"
+)
+
+// NetworkList returns the list of networks on the Docker daemon.
+// The returned networks do not list their connected containers, see NetworkInspect.
+// The function returns an error if the list of networks cannot be retrieved.
+func (c *dockerClient) NetworkList() ([]network.Summary, error) {
+	return c.cli.NetworkList(context.Background(), network.ListOptions{})
+}
+
+// NetworkInspect returns the network with the given ID or name, connected containers included.
+// It returns an error if the network could not be inspected.
+func (c *dockerClient) NetworkInspect(id string) (network.Inspect, error) {
+	return c.cli.NetworkInspect(context.Background(), id, network.InspectOptions{})
+}
+
+// CreateNetwork creates a network with the given name and driver.
+// When subnet is not empty, it is used to configure the IPAM of the network,
+// otherwise the daemon picks a subnet from its default pools.
+// The function returns the ID of the created network, or an error if the creation fails.
+func (c *dockerClient) CreateNetwork(ctx context.Context, name, driver, subnet string) (string, error) {
+	options := network.CreateOptions{Driver: driver}
+	if subnet != "" {
+		options.IPAM = &network.IPAM{
+			Config: []network.IPAMConfig{{Subnet: subnet}},
+		}
+	}
+	r, err := c.cli.NetworkCreate(ctx, name, options)
+	if err != nil {
+		return "", err
+	}
+	return r.ID, nil
+}
+
+// DeleteNetwork deletes the network with the given ID.
+// The daemon refuses to delete a network with connected containers.
+// The function returns an error if the deletion fails.
+func (c *dockerClient) DeleteNetwork(ctx context.Context, id string) error {
+	return c.cli.NetworkRemove(ctx, id)
+}
+
+// ConnectNetwork connects the container to the network, both given by ID or name.
+// The function returns an error if the container cannot be connected.
+func (c *dockerClient) ConnectNetwork(ctx context.Context, networkID, containerID string) error {
+	return c.cli.NetworkConnect(ctx, networkID, containerID, nil)
+}
+
+// DisconnectNetwork disconnects the container from the network, both given by ID or name.
+// The function returns an error if the container cannot be disconnected.
+func (c *dockerClient) DisconnectNetwork(ctx context.Context, networkID, containerID string) error {
+	return c.cli.NetworkDisconnect(ctx, networkID, containerID, false)
+}
@@ -0,0 +1,46 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// daemonPlatform returns the platform of the daemon, such as linux/arm/v7, used to
+// pick the image of a multi-arch index. It is resolved once per client.
+func (c *dockerClient) daemonPlatform() (v1.Platform, error) {
+	c.platformMu.Lock()
+	defer c.platformMu.Unlock()
+	if c.platform != nil {
+		return *c.platform, nil
+	}
+
+	ctx := context.Background()
+	version, err := c.cli.ServerVersion(ctx)
+	if err != nil {
+		return v1.Platform{}, fmt.Errorf("unable to get the platform of the daemon: %w", err)
+	}
+	p := v1.Platform{OS: version.Os, Architecture: version.Arch}
+
+	// the variant is only told by the machine name of the daemon host, such as armv7l
+	if p.Architecture == "arm" {
+		info, err := c.cli.Info(ctx)
+		if err != nil {
+			return v1.Platform{}, fmt.Errorf("unable to get the platform of the daemon: %w", err)
+		}
+		p.Variant = armVariant(info.Architecture)
+	}
+	c.platform = &p
+	return p, nil
+}
+
+// armVariant returns the variant of a 32-bit arm machine name: v7 for armv7l,
+// v6 for armv6l, and v7 when the machine does not tell, as Docker does.
+func armVariant(machine string) string {
+	if v, ok := strings.CutPrefix(machine, "armv"); ok && v != "" && v[0] >= '5' && v[0] <= '8' {
+		return "v" + v[:1]
+	}
+	return "v7"
+}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"context"
+	"log"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+)
+
+// RecreatePlan is how a container is created again from the inspect of a
+// previous container, possibly with another image.
+type RecreatePlan struct {
+	Name       string
+	Config     *container.Config
+	HostConfig *container.HostConfig
+	// Networking is the network the container is created on.
+	Networking *network.NetworkingConfig
+	// Connect are the other networks, by name, the container is connected to before it starts.
+	Connect map[string]*network.EndpointSettings
+}
+
+// Endpoints returns all the networks of the plan, by name.
+func (p RecreatePlan) Endpoints() map[string]*network.EndpointSettings {
+	endpoints := maps.Clone(p.Connect)
+	if endpoints == nil {
+		endpoints = make(map[string]*network.EndpointSettings)
+	}
+	if p.Networking != nil {
+		maps.Copy(endpoints, p.Networking.EndpointsConfig)
+	}
+	return endpoints
+}
+
+// PlanRecreate returns the plan creating a container like c.
+//
+// The inspect of a container merges the defaults of its image into its
+// configuration. The settings equal to the defaults of the image of c, given
+// by image, are left out so that the defaults of the new image apply instead
+// of being pinned: the environment variables, command, entrypoint, labels,
+// exposed ports, volumes, working directory, user, stop signal and
+// healthcheck. The hostname is left out when it is the one derived from the
+// ID of c. Nothing is left out when image is nil.
+//
+// The links, and the aliases, static addresses and links of the networks are
+// kept, without the alias the daemon gives to the container from its ID.
+func PlanRecreate(c container.InspectResponse, image *ImageConfig) RecreatePlan {
+	plan := RecreatePlan{Name: c.Name, Connect: make(map[string]*network.EndpointSettings)}
+
+	id := ""
+	if c.ContainerJSONBase != nil {
+		id = c.ID
+		if c.HostConfig != nil {
+			hostConfig := *c.HostConfig
+			hostConfig.Links = createLinks(c.HostConfig.Links)
+			plan.HostConfig = &hostConfig
+		}
+	}
+	if c.Config != nil {
+		config := *c.Config
+		if len(id) >= 12 && config.Hostname == id[:12] {
+			config.Hostname = ""
+		}
+		if image != nil {
+			removeImageDefaults(&config, *image)
+		}
+		plan.Config = &config
+	}
+
+	if c.NetworkSettings != nil {
+		primary := primaryNetwork(c)
+		for name, e := range c.NetworkSettings.Networks {
+			if e == nil {
+				continue
+			}
+			if name == primary {
+				plan.Networking = &network.NetworkingConfig{
+					EndpointsConfig: map[string]*network.EndpointSettings{name: recreateEndpoint(e, id)},
+				}
+				continue
+			}
+			plan.Connect[name] = recreateEndpoint(e, id)
+		}
+	}
+	if plan.Networking == nil {
+		plan.Networking = &network.NetworkingConfig{}
+	}
+	return plan
+}
+
+// removeImageDefaults clears the settings of config equal to the defaults of the image.
+func removeImageDefaults(config *container.Config, image ImageConfig) {
+	config.Env = slices.DeleteFunc(slices.Clone(config.Env), func(e string) bool {
+		return slices.Contains(image.Env, e)
+	})
+	if len(config.Env) == 0 {
+		config.Env = nil
+	}
+
+	// a container overriding the entrypoint does not get the command of its image
+	if slices.Equal(config.Entrypoint, image.Entrypoint) {
+		config.Entrypoint = nil
+		if slices.Equal(config.Cmd, image.Cmd) {
+			config.Cmd = nil
+		}
+	}
+
+	if config.Labels != nil {
+		labels := make(map[string]string, len(config.Labels))
+		for k, v := range config.Labels {
+			if iv, ok := image.Labels[k]; !ok || iv != v {
+				labels[k] = v
+			}
+		}
+		config.Labels = labels
+	}
+	config.ExposedPorts = maps.Clone(config.ExposedPorts)
+	for _, p := range image.ExposedPorts {
+		delete(config.ExposedPorts, nat.Port(p))
+	}
+	if len(config.ExposedPorts) == 0 {
+		config.ExposedPorts = nil
+	}
+	config.Volumes = maps.Clone(config.Volumes)
+	for _, v := range image.Volumes {
+		delete(config.Volumes, v)
+	}
+	if len(config.Volumes) == 0 {
+		config.Volumes = nil
+	}
+
+	if config.WorkingDir == image.WorkingDir {
+		config.WorkingDir = ""
+	}
+	if config.User == image.User {
+		config.User = ""
+	}
+	if config.StopSignal == image.StopSignal {
+		config.StopSignal = ""
+	}
+	if config.Healthcheck != nil && image.Healthcheck != nil && reflect.DeepEqual(*config.Healthcheck, *image.Healthcheck) {
+		config.Healthcheck = nil
+	}
+}
+
+// primaryNetwork returns the network of the network mode of c, the one it is
+// created on, or the first of its networks by name.
+func primaryNetwork(c container.InspectResponse) string {
+	names := slices.Sorted(maps.Keys(c.NetworkSettings.Networks))
+	if len(names) == 0 {
+		return ""
+	}
+	if c.ContainerJSONBase != nil && c.HostConfig != nil {
+		mode := c.HostConfig.NetworkMode
+		if mode.IsDefault() {
+			mode = network.NetworkBridge
+		}
+		if slices.Contains(names, string(mode)) {
+			return string(mode)
+		}
+	}
+	return names[0]
+}
+
+// recreateEndpoint returns the user settings of a network endpoint of the container with the given ID.
+func recreateEndpoint(e *network.EndpointSettings, id string) *network.EndpointSettings {
+	out := &network.EndpointSettings{
+		Links:      slices.Clone(e.Links),
+		MacAddress: e.MacAddress,
+		DriverOpts: maps.Clone(e.DriverOpts),
+		GwPriority: e.GwPriority,
+	}
+	if e.IPAMConfig != nil {
+		out.IPAMConfig = e.IPAMConfig.Copy()
+	}
+	for _, a := range e.Aliases {
+		if id == "" || !strings.HasPrefix(id, a) || len(a) < 12 {
+			out.Aliases = append(out.Aliases, a)
+		}
+	}
+	return out
+}
+
+// createLinks converts the links of an inspect, /db:/web/alias, to the form
+// expected when creating a container, db:alias.
+func createLinks(links []string) []string {
+	if links == nil {
+		return nil
+	}
+	out := make([]string, 0, len(links))
+	for _, l := range links {
+		name, alias, ok := strings.Cut(l, ":")
+		if !ok {
+			out = append(out, l)
+			continue
+		}
+		name = strings.TrimPrefix(name, "/")
+		alias = alias[strings.LastIndex(alias, "/")+1:]
+		out = append(out, name+":"+alias)
+	}
+	return out
+}
+
+// imageConfigOf returns the configuration of a local image.
+func imageConfigOf(config *dockerspec.DockerOCIImageConfig) ImageConfig {
+	if config == nil {
+		return ImageConfig{}
+	}
+	return ImageConfig{
+		Env:          config.Env,
+		ExposedPorts: slices.Sorted(maps.Keys(config.ExposedPorts)),
+		Entrypoint:   config.Entrypoint,
+		Cmd:          config.Cmd,
+		User:         config.User,
+		WorkingDir:   config.WorkingDir,
+		Labels:       config.Labels,
+		Volumes:      slices.Sorted(maps.Keys(config.Volumes)),
+		StopSignal:   config.StopSignal,
+		Healthcheck:  config.Healthcheck,
+	}
+}
+
+// recreateImageConfig returns the configuration of the image of the previous
+// container, nil when it cannot be inspected anymore.
+func (c *dockerClient) recreateImageConfig(imageID string) *ImageConfig {
+	if imageID == "" {
+		return nil
+	}
+	img, err := c.cli.ImageInspect(context.Background(), imageID)
+	if err != nil {
+		log.Printf("unable to inspect the image %s, its defaults are kept: %v", imageID, err)
+		return nil
+	}
+	config := imageConfigOf(img.Config)
+	return &config
+}
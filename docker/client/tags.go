@@ -0,0 +1,163 @@
+package client
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+// TagUpdates are the newest tags of the repository of an image whose tag is a version,
+// such as "1.25.3" or "v2.1-alpine". Only the tags with the same shape are considered:
+// the same prefix, the same number of components and the same suffix.
+type TagUpdates struct {
+	Patch string `json:"patch,omitempty"` // Patch is the newest tag with the same major and minor versions.
+	Minor string `json:"minor,omitempty"` // Minor is the newest tag with the same major version and a newer minor one.
+	Major string `json:"major,omitempty"` // Major is the newest tag with a newer major version.
+}
+
+// Level returns the highest level having a newer tag: major, minor, patch, or empty.
+func (t TagUpdates) Level() string {
+	switch {
+	case t.Major != "":
+		return "major"
+	case t.Minor != "":
+		return "minor"
+	case t.Patch != "":
+		return "patch"
+	}
+	return ""
+}
+
+// Target returns the newest tag followed by the track, or empty when there is none.
+func (t TagUpdates) Target(track types.UpdateTrack) string {
+	candidates := []string{}
+	switch track {
+	case types.TrackMajor:
+		candidates = []string{t.Major, t.Minor, t.Patch}
+	case types.TrackMinor:
+		candidates = []string{t.Minor, t.Patch}
+	case types.TrackPatch:
+		candidates = []string{t.Patch}
+	}
+	for _, tag := range candidates {
+		if tag != "" {
+			return tag
+		}
+	}
+	return ""
+}
+
+// versionPattern matches the tags made of a version: an optional "v", 1 to 3
+// numbers and an optional suffix such as "-alpine".
+var versionPattern = regexp.MustCompile(`^(v?)(\d+(?:\.\d+){0,2})(-[0-9A-Za-z.-]+)?$`)
+
+// tagVersion is a parsed version tag.
+type tagVersion struct {
+	prefix  string
+	numbers []int
+	suffix  string
+}
+
+// parseTag parses a version tag, it returns false when the tag is not a version.
+func parseTag(tag string) (tagVersion, bool) {
+	m := versionPattern.FindStringSubmatch(tag)
+	if m == nil {
+		return tagVersion{}, false
+	}
+	v := tagVersion{prefix: m[1], suffix: m[3]}
+	for _, part := range strings.Split(m[2], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return tagVersion{}, false
+		}
+		v.numbers = append(v.numbers, n)
+	}
+	return v, true
+}
+
+// sameShape reports whether both versions have the same prefix, number of components and suffix.
+func (v tagVersion) sameShape(o tagVersion) bool {
+	return v.prefix == o.prefix && v.suffix == o.suffix && len(v.numbers) == len(o.numbers)
+}
+
+// compare compares the numbers of two versions of the same shape.
+func (v tagVersion) compare(o tagVersion) int {
+	for i := range v.numbers {
+		if v.numbers[i] != o.numbers[i] {
+			if v.numbers[i] < o.numbers[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// level returns the index of the first component differing between the versions:
+// 0 for major, 1 for minor and 2 for patch.
+func (v tagVersion) level(o tagVersion) int {
+	for i := range v.numbers {
+		if v.numbers[i] != o.numbers[i] {
+			return i
+		}
+	}
+	return len(v.numbers)
+}
+
+// NewerTags returns the newest tags of each level newer than tag, among tags.
+func NewerTags(tag string, tags []string) TagUpdates {
+	current, ok := parseTag(tag)
+	if !ok {
+		return TagUpdates{}
+	}
+
+	// newest holds the newest tag and version of each level, major first
+	var newest [3]string
+	var versions [3]tagVersion
+	for _, t := range tags {
+		v, ok := parseTag(t)
+		if !ok || !v.sameShape(current) || v.compare(current) <= 0 {
+			continue
+		}
+		l := v.level(current)
+		if newest[l] == "" || v.compare(versions[l]) > 0 {
+			newest[l], versions[l] = t, v
+		}
+	}
+	return TagUpdates{Major: newest[0], Minor: newest[1], Patch: newest[2]}
+}
+
+// splitTag splits an image reference into its repository and its tag.
+// It returns false when the reference has a digest or no explicit tag.
+func splitTag(image string) (string, string, bool) {
+	if strings.Contains(image, "@") {
+		return "", "", false
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || i < strings.LastIndex(image, "/") {
+		return "", "", false
+	}
+	return image[:i], image[i+1:], true
+}
+
+// WithTag returns the image reference with its tag replaced by tag.
+func WithTag(image, tag string) string {
+	return ImageRepository(image) + ":" + tag
+}
+
+// ImageTag returns the tag of an image reference, empty when it has a digest or no explicit tag.
+func ImageTag(image string) string {
+	_, tag, _ := splitTag(image)
+	return tag
+}
+
+// ImageRepository returns the repository of an image reference, without its tag and digest.
+func ImageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if repo, _, ok := splitTag(image); ok {
+		return repo
+	}
+	return image
+}
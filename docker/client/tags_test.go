@@ -0,0 +1,111 @@
+package client
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		ok      bool
+		prefix  string
+		numbers []int
+		suffix  string
+	}{
+		{"1.25.3", true, "", []int{1, 25, 3}, ""},
+		{"v2.1", true, "v", []int{2, 1}, ""},
+		{"7", true, "", []int{7}, ""},
+		{"7.2-alpine", true, "", []int{7, 2}, "-alpine"},
+		{"1.26.0-rc1", true, "", []int{1, 26, 0}, "-rc1"},
+		{"3.19.1-alpine3.19", true, "", []int{3, 19, 1}, "-alpine3.19"},
+		{"latest", false, "", nil, ""},
+		{"alpine", false, "", nil, ""},
+		{"1.2.3.4", false, "", nil, ""},
+		{"V1.2", false, "", nil, ""},
+		{"1.2_beta", false, "", nil, ""},
+	}
+	for _, tt := range tests {
+		v, ok := parseTag(tt.tag)
+		if ok != tt.ok {
+			t.Errorf("parseTag(%q) ok = %t, want %t", tt.tag, ok, tt.ok)
+			continue
+		}
+		if ok && (v.prefix != tt.prefix || !slices.Equal(v.numbers, tt.numbers) || v.suffix != tt.suffix) {
+			t.Errorf("parseTag(%q) = %+v, want %q %v %q", tt.tag, v, tt.prefix, tt.numbers, tt.suffix)
+		}
+	}
+}
+
+func TestNewerTags(t *testing.T) {
+	tags := []string{
+		"latest", "alpine",
+		"1.25.2", "1.25.3", "1.25.4", "1.25.10",
+		"1.26.0", "1.26.1", "1.27.0-rc1",
+		"2.0.0", "2.1.0",
+		"1.25.11-alpine", "1.26.0-alpine",
+		"1.25", "1.26", "1", "2",
+		"v1.25.5", "v3.0.0",
+	}
+	tests := []struct {
+		tag  string
+		want TagUpdates
+	}{
+		{"1.25.3", TagUpdates{Patch: "1.25.10", Minor: "1.26.1", Major: "2.1.0"}},
+		{"1.25.10", TagUpdates{Minor: "1.26.1", Major: "2.1.0"}},
+		{"2.1.0", TagUpdates{}},
+		{"1.25.3-alpine", TagUpdates{Patch: "1.25.11-alpine", Minor: "1.26.0-alpine"}},
+		{"1.25", TagUpdates{Minor: "1.26"}},
+		{"1", TagUpdates{Major: "2"}},
+		{"v1.25.3", TagUpdates{Patch: "v1.25.5", Major: "v3.0.0"}},
+		{"1.27.0-rc1", TagUpdates{}},
+		{"latest", TagUpdates{}},
+	}
+	for _, tt := range tests {
+		if got := NewerTags(tt.tag, tags); got != tt.want {
+			t.Errorf("NewerTags(%q) = %+v, want %+v", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestTagUpdatesTarget(t *testing.T) {
+	u := TagUpdates{Patch: "1.25.10", Minor: "1.26.1", Major: "2.1.0"}
+	tests := []struct {
+		updates TagUpdates
+		track   types.UpdateTrack
+		want    string
+	}{
+		{u, types.TrackPatch, "1.25.10"},
+		{u, types.TrackMinor, "1.26.1"},
+		{u, types.TrackMajor, "2.1.0"},
+		{TagUpdates{Patch: "1.25.10"}, types.TrackMajor, "1.25.10"},
+		{TagUpdates{Major: "2.1.0"}, types.TrackMinor, ""},
+		{u, types.TrackDigest, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.updates.Target(tt.track); got != tt.want {
+			t.Errorf("%+v.Target(%q) = %q, want %q", tt.updates, tt.track, got, tt.want)
+		}
+	}
+}
+
+func TestImageRepository(t *testing.T) {
+	tests := []struct{ image, repo, tag string }{
+		{"nginx:1.25", "nginx", "1.25"},
+		{"nginx", "nginx", ""},
+		{"localhost:5000/app:v1", "localhost:5000/app", "v1"},
+		{"localhost:5000/app", "localhost:5000/app", ""},
+		{"nginx@sha256:abc", "nginx", ""},
+		{"nginx:1.25@sha256:abc", "nginx", ""},
+	}
+	for _, tt := range tests {
+		if got := ImageRepository(tt.image); got != tt.repo {
+			t.Errorf("ImageRepository(%q) = %q, want %q", tt.image, got, tt.repo)
+		}
+		if got := ImageTag(tt.image); got != tt.tag {
+			t.Errorf("ImageTag(%q) = %q, want %q", tt.image, got, tt.tag)
+		}
+	}
+}
@@ -4,92 +4,161 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"runtime"
 	"slices"
 	"strings"
 
-	"github.com/docker/docker/api/types/image"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 )
 
+// UpdateCheck is the result of the update check of a container.
+type UpdateCheck struct {
+	Image          string      // Image is the image reference the container was created from.
+	LocalDigest    string      // LocalDigest is the digest of the local image in the repository of Image, empty when it has none.
+	RemoteDigest   string      // RemoteDigest is the digest published by the registry for Image, the one of the index for a multi-arch image.
+	PlatformDigest string      // PlatformDigest is the digest of the image manifest of Platform, RemoteDigest for a single-platform image.
+	Platform       string      // Platform is the platform of the daemon the image is resolved for, such as linux/arm64.
+	Matched        DigestMatch // Matched tells which remote digest matched the local image, empty when it is outdated.
+	Update         bool        // Update is set when the registry publishes another image than the local one.
+	Tags           TagUpdates  // Tags are the newer tags of the repository, when Image has a version tag.
+}
+
+// DigestMatch tells which remote digest matched the local image of an update check.
+type DigestMatch string
+
+const (
+	MatchIndex    DigestMatch = "index"    // MatchIndex is set when the local image was pulled from the digest published for the reference.
+	MatchManifest DigestMatch = "manifest" // MatchManifest is set when the local image is the manifest of the daemon platform, only other platforms of the index changed.
+	MatchConfig   DigestMatch = "config"   // MatchConfig is set when the local image has the configuration of the image of the daemon platform.
+)
+
 // CheckUpdate checks if the given container needs to be updated.
-// It returns true if an update is needed, false otherwise.
-// It also returns an error if an error occurs during the check.
-func (c *Client) CheckUpdate(containerID string) (bool, error) {
+// The digest published for its image reference is compared to the digests of
+// the local image first. When it differs, the image of the daemon platform is
+// resolved and compared, so that an index updated for other platforms only is
+// not an update. The returned check holds the digests compared, the ones known when an error occurs.
+func (c *dockerClient) CheckUpdate(containerID string) (UpdateCheck, error) {
 
 	container, err := c.ContainerInspect(containerID)
 	if err != nil {
-		return false, err
+		return UpdateCheck{}, err
 	}
+	check := UpdateCheck{Image: container.Config.Image}
+	check.Tags = newerRemoteTags(container.Config.Image)
 
-	var image image.Summary
-	images, err := c.ImageList()
-
+	platform, err := c.daemonPlatform()
 	if err != nil {
-		return false, err
+		return check, err
 	}
+	check.Platform = platform.String()
 
-	for _, img := range images {
-		if img.ID == container.Image {
-			image = img
-			break
-		}
+	imageID, repoDigests, err := c.getLocalDigests(container.Image)
+	if err != nil {
+		return check, err
 	}
-
-	if image.ID == "" {
-		return false, fmt.Errorf("image %s not found", container.Image)
+	localDigests := make([]string, 0, len(repoDigests))
+	for _, d := range repoDigests {
+		localDigests = append(localDigests, digestOf(d))
 	}
-	// log.Printf("check update for container %s:  image %s - %s - %+v", c.Name, c.Image, c.Config.Image)
-
-	// if strings.Contains(imageRef, "@") {
-	// 	return false, nil
-	// }
-
-	// if strings.HasPrefix(imageRef, "sha256") {
-	// 	return true, nil
-	// }
-
-	localDigests, err := c.getLocalDigests(container.Config.Image)
-	if err != nil {
-		return false, err
+	// an image tagged in several repositories has a repo digest in each of them
+	if ref, err := name.ParseReference(container.Config.Image); err == nil {
+		check.LocalDigest = repoDigest(repoDigests, ref.Context())
 	}
 
 	remoteDigest, err := getRemoteDigest(container.Config.Image)
 	if err != nil {
 		log.Printf("image : %s, localDigests: %v, err: %s", container.Image, localDigests, err)
-		return false, err
+		return check, err
 	}
+	check.RemoteDigest = remoteDigest
 
 	log.Printf("image : %s, localDigests: %v, remoteDigest: %s", container.Image, localDigests, remoteDigest)
 
-	f := func(s string) bool {
-		return strings.HasPrefix(s, remoteDigest) || strings.HasSuffix(s, remoteDigest)
+	local := func(digest string) bool {
+		return digest == imageID || slices.Contains(localDigests, digest)
+	}
+	if local(remoteDigest) {
+		check.LocalDigest = remoteDigest
+		check.Matched = MatchIndex
+		return check, nil
 	}
 
-	if slices.ContainsFunc(localDigests, f) {
-		return false, nil
+	manifest, config, err := getPlatformDigests(container.Config.Image, remoteDigest, platform)
+	if err != nil {
+		log.Printf("image : %s, platform %s, err: %s", container.Image, platform, err)
+		return check, err
+	}
+	check.PlatformDigest = manifest
+
+	switch {
+	case local(manifest):
+		check.LocalDigest = manifest
+		check.Matched = MatchManifest
+		return check, nil
+	case config == imageID:
+		check.Matched = MatchConfig
+		return check, nil
 	}
 
-	log.Printf("image to update : %s, container: %s, localDigests: %v, remoteDigest: %s", image.ID, container.ID, localDigests, remoteDigest)
+	log.Printf("image to update : %s, container: %s, localDigests: %v, remoteDigest: %s, platformDigest: %s", container.Image, container.ID, localDigests, remoteDigest, manifest)
 
-	return true, nil
+	check.Update = true
+	return check, nil
 }
 
-func (c *Client) getLocalDigests(imageID string) ([]string, error) {
-	//imgInspect, _, err := cli.ImageInspectWithRaw(ctx, imageID)
+// getLocalDigests returns the ID of the local image and its repo digests.
+func (c *dockerClient) getLocalDigests(imageID string) (string, []string, error) {
 	imgInspect, err := c.cli.ImageInspect(context.Background(), imageID)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	if len(imgInspect.RepoDigests) == 0 {
-		return nil, fmt.Errorf("pas de RepoDigests pour %s", imageID)
+		return "", nil, fmt.Errorf("pas de RepoDigests pour %s", imageID)
+	}
+
+	return imgInspect.ID, imgInspect.RepoDigests, nil
+}
+
+// digestOf returns the digest of a repo digest such as "nginx@sha256:...".
+func digestOf(repoDigest string) string {
+	if _, digest, ok := strings.Cut(repoDigest, "@"); ok {
+		return digest
+	}
+	return repoDigest
+}
+
+// newerRemoteTags lists the tags of the repository of the image and returns
+// the newer ones, when the image has a version tag.
+func newerRemoteTags(image string) TagUpdates {
+	tag := ImageTag(image)
+	if _, ok := parseTag(tag); !ok {
+		return TagUpdates{}
 	}
 
-	return imgInspect.RepoDigests, nil
+	repo, err := name.NewRepository(ImageRepository(image))
+	if err != nil {
+		log.Printf("invalid repository for image %s: %s", image, err)
+		return TagUpdates{}
+	}
+	entry, err := registryLookups.lookup("tags "+repo.Name(), repo.RegistryStr(), func(opts ...remote.Option) (cacheEntry, error) {
+		tags, err := remote.List(repo, append(opts, remoteAuth())...)
+		return cacheEntry{Tags: tags}, err
+	})
+	if err != nil {
+		log.Printf("unable to list the tags of %s: %s", repo, authError(image, err))
+		return TagUpdates{}
+	}
+	return NewerTags(tag, entry.Tags)
+}
+
+// digestKey returns the cache key of the remote digest of an image reference.
+func digestKey(ref name.Reference) string {
+	return "digest " + ref.Name()
 }
 
+// getRemoteDigest returns the digest the registry publishes for the image,
+// from the cache when it was looked up recently.
 func getRemoteDigest(image string) (string, error) {
 
 	log.Printf("getRemoteDigest for %s", image)
@@ -99,13 +168,60 @@ func getRemoteDigest(image string) (string, error) {
 		return "", err
 	}
 
-	// HEAD request for manifest digest
-	desc, err := remote.Head(ref,
-		remote.WithPlatform(v1.Platform{Architecture: runtime.GOARCH, OS: runtime.GOOS}),
-	)
+	entry, err := registryLookups.lookup(digestKey(ref), ref.Context().RegistryStr(), func(opts ...remote.Option) (cacheEntry, error) {
+		// HEAD request for manifest digest
+		desc, err := remote.Head(ref, append(opts, remoteAuth())...)
+		if err != nil {
+			return cacheEntry{}, err
+		}
+		return cacheEntry{Digest: desc.Digest.String()}, nil
+	})
 	if err != nil {
-		return "", err
+		return "", authError(image, err)
 	}
 
-	return desc.Digest.String(), nil
+	return entry.Digest, nil
+}
+
+// getPlatformDigests returns the digest of the image manifest of the platform
+// published under the given digest of the image, and the digest of its configuration.
+// The digest is the one of an index, or of the image manifest itself for a single-platform image.
+func getPlatformDigests(image, digest string, platform v1.Platform) (string, string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", "", err
+	}
+	pinned := ref.Context().Digest(digest)
+
+	key := "platform " + pinned.Name() + " " + platform.String()
+	entry, err := registryLookups.lookup(key, ref.Context().RegistryStr(), func(opts ...remote.Option) (cacheEntry, error) {
+		desc, err := remote.Get(pinned, append(opts, remote.WithPlatform(platform), remoteAuth())...)
+		if err != nil {
+			return cacheEntry{}, err
+		}
+		img, err := desc.Image()
+		if err != nil {
+			return cacheEntry{}, err
+		}
+		manifest, err := img.Digest()
+		if err != nil {
+			return cacheEntry{}, err
+		}
+		config, err := img.ConfigName()
+		if err != nil {
+			return cacheEntry{}, err
+		}
+		return cacheEntry{Digest: manifest.String(), Config: config.String()}, nil
+	})
+	if err != nil {
+		return "", "", authError(image, err)
+	}
+	return entry.Digest, entry.Config, nil
+}
+
+// forgetRemoteDigest drops the cached remote digest of the image, once pulled.
+func forgetRemoteDigest(image string) {
+	if ref, err := name.ParseReference(image); err == nil {
+		registryLookups.forget(digestKey(ref))
+	}
 }
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// inspectClient is a daemon knowing a single container and its image.
+type inspectClient struct {
+	client.APIClient
+	container container.InspectResponse
+	image     image.InspectResponse
+}
+
+func (c inspectClient) ContainerInspect(ctx context.Context, id string) (container.InspectResponse, error) {
+	return c.container, nil
+}
+
+func (c inspectClient) ImageInspect(ctx context.Context, id string, opts ...client.ImageInspectOption) (image.InspectResponse, error) {
+	return c.image, nil
+}
+
+func TestCheckUpdateLocalDigest(t *testing.T) {
+	const (
+		hub    = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+		ghcr   = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+		remote = "sha256:3333333333333333333333333333333333333333333333333333333333333333"
+		config = "sha256:4444444444444444444444444444444444444444444444444444444444444444"
+	)
+	platform := v1.Platform{OS: "linux", Architecture: "amd64"}
+	tests := []struct {
+		image       string
+		repoDigests []string
+		remote      string
+		want        string
+		update      bool
+	}{
+		{"ghcr.io/acme/web:latest", []string{"web@" + hub, "ghcr.io/acme/web@" + ghcr}, remote, ghcr, true},
+		{"web:latest", []string{"ghcr.io/acme/web@" + ghcr, "web@" + hub}, remote, hub, true},
+		{"docker.io/library/web:latest", []string{"ghcr.io/acme/web@" + ghcr, "web@" + hub}, remote, hub, true},
+		{"ghcr.io/acme/web:latest", []string{"web@" + hub, "ghcr.io/acme/web@" + ghcr}, ghcr, ghcr, false},
+		{"ghcr.io/acme/web:latest", []string{"web@" + hub}, remote, "", true},
+	}
+
+	saved := registryLookups
+	t.Cleanup(func() { registryLookups = saved })
+	for _, tt := range tests {
+		ref, err := name.ParseReference(tt.image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		registryLookups = newRegistryCache(time.Hour, "")
+		registryLookups.entries[digestKey(ref)] = cacheEntry{Digest: tt.remote, Fetched: time.Now()}
+		platformKey := "platform " + ref.Context().Digest(tt.remote).Name() + " " + platform.String()
+		registryLookups.entries[platformKey] = cacheEntry{Digest: tt.remote, Config: config, Fetched: time.Now()}
+
+		c := &dockerClient{
+			cli: inspectClient{
+				container: container.InspectResponse{
+					ContainerJSONBase: &container.ContainerJSONBase{ID: "web", Image: "sha256:web"},
+					Config:            &container.Config{Image: tt.image},
+				},
+				image: image.InspectResponse{ID: "sha256:web", RepoDigests: tt.repoDigests},
+			},
+			platform: &platform,
+		}
+		check, err := c.CheckUpdate("web")
+		if err != nil {
+			t.Errorf("CheckUpdate(%s, %v) error %v", tt.image, tt.repoDigests, err)
+			continue
+		}
+		if check.LocalDigest != tt.want || check.Update != tt.update {
+			t.Errorf("CheckUpdate(%s, %v) = %s, update %t, want %s, update %t", tt.image, tt.repoDigests, check.LocalDigest, check.Update, tt.want, tt.update)
+		}
+	}
+}
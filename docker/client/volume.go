@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// VolumeList returns the list of volumes on the Docker daemon.
+// The returned volumes do not contain usage data, see VolumesDiskUsage.
+// The function returns an error if the list of volumes cannot be retrieved.
+func (c *dockerClient) VolumeList() ([]*volume.Volume, error) {
+	r, err := c.cli.VolumeList(context.Background(), volume.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return r.Volumes, nil
+}
+
+// VolumeInspect returns the volume with the given name.
+// It returns an error if the volume could not be inspected.
+func (c *dockerClient) VolumeInspect(name string) (volume.Volume, error) {
+	return c.cli.VolumeInspect(context.Background(), name)
+}
+
+// VolumesDiskUsage returns the usage data of the volumes, as reported by `docker system df`.
+// The returned map is indexed by volume name.
+// Computing the usage can be slow on daemons with many volumes.
+func (c *dockerClient) VolumesDiskUsage() (map[string]volume.UsageData, error) {
+	du, err := c.cli.DiskUsage(context.Background(), types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.VolumeObject},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]volume.UsageData, len(du.Volumes))
+	for _, v := range du.Volumes {
+		if v.UsageData != nil {
+			out[v.Name] = *v.UsageData
+		}
+	}
+	return out, nil
+}
+
+// DeleteVolume deletes the volume with the given name.
+// It does not force the deletion, so a volume in use by a container is not deleted.
+// The function returns an error if the deletion fails.
+func (c *dockerClient) DeleteVolume(ctx context.Context, name string) error {
+	return c.cli.VolumeRemove(ctx, name, false)
+}
+
+// PruneVolumes deletes the anonymous volumes not used by any container, the
+// named ones too when all is set. The daemons older than API 1.42 do not tell
+// the anonymous volumes apart, they can only prune all the unused volumes.
+// The function returns the report of the deleted volumes, or an error if the prune fails.
+func (c *dockerClient) PruneVolumes(ctx context.Context, all bool) (volume.PruneReport, error) {
+	args := filters.NewArgs()
+	if versions.GreaterThanOrEqualTo(c.cli.ClientVersion(), "1.42") {
+		if all {
+			args.Add("all", "true")
+		}
+	} else if !all {
+		return volume.PruneReport{}, fmt.Errorf("the daemon API %s cannot prune only the anonymous volumes, 1.42 is required", c.cli.ClientVersion())
+	}
+	return c.cli.VolumesPrune(ctx, args)
+}
@@ -0,0 +1,157 @@
+// Package compose reads the labels set by Docker Compose on containers and
+// runs project-wide operations in the order of the service dependencies.
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+// Labels set by Docker Compose on the containers of a project.
+const (
+	ProjectLabel   = "com.docker.compose.project"
+	ServiceLabel   = "com.docker.compose.service"
+	DependsOnLabel = "com.docker.compose.depends_on"
+	NumberLabel    = "com.docker.compose.container-number"
+)
+
+// Conditions a service can wait for on its dependencies.
+const (
+	ConditionStarted   = "service_started"
+	ConditionHealthy   = "service_healthy"
+	ConditionCompleted = "service_completed_successfully"
+)
+
+// ErrDependencyCycle is returned when the services of a project depend on each other.
+var ErrDependencyCycle = errors.New("dependency cycle between compose services")
+
+// Dependency is a service another service depends on.
+type Dependency struct {
+	Service   string // Service is the name of the service depended on.
+	Condition string // Condition is the state the service must reach, ConditionStarted by default.
+	Restart   bool   // Restart reports whether the dependent service is restarted with the dependency.
+}
+
+// Project returns the compose project of the container, or an empty string.
+func Project(c types.Container) string {
+	return label(c, ProjectLabel)
+}
+
+// Service returns the compose service of the container, or an empty string.
+func Service(c types.Container) string {
+	return label(c, ServiceLabel)
+}
+
+// DependsOn returns the dependencies of the service of the container.
+//
+// The label is written by compose as a comma separated list of
+// service:condition:restart entries, the condition and restart parts being
+// optional on older versions.
+func DependsOn(c types.Container) []Dependency {
+	value := label(c, DependsOnLabel)
+	if value == "" {
+		return nil
+	}
+
+	var out []Dependency
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if parts[0] == "" {
+			continue
+		}
+		dep := Dependency{Service: parts[0], Condition: ConditionStarted}
+		if len(parts) > 1 && parts[1] != "" {
+			dep.Condition = parts[1]
+		}
+		if len(parts) > 2 {
+			dep.Restart = parts[2] == "true"
+		}
+		out = append(out, dep)
+	}
+	return out
+}
+
+// Order returns the containers sorted so that the dependencies of a service
+// come before it. Services without dependencies between them are sorted by
+// name, and the containers of a service by container name.
+// Dependencies on services missing from the given containers are ignored.
+// ErrDependencyCycle is returned if the dependencies cannot be satisfied.
+func Order(containers []types.Container) ([]types.Container, error) {
+	byService := make(map[string][]types.Container)
+	for _, c := range containers {
+		service := Service(c)
+		if service == "" {
+			service = strings.TrimPrefix(c.Name, "/")
+		}
+		byService[service] = append(byService[service], c)
+	}
+
+	// pending counts the unsatisfied dependencies of each service,
+	// dependents lists the services waiting on each service.
+	pending := make(map[string]int, len(byService))
+	dependents := make(map[string][]string)
+	for service, conts := range byService {
+		pending[service] += 0
+		seen := make(map[string]bool)
+		for _, c := range conts {
+			for _, dep := range DependsOn(c) {
+				if _, ok := byService[dep.Service]; !ok || seen[dep.Service] || dep.Service == service {
+					continue
+				}
+				seen[dep.Service] = true
+				pending[service]++
+				dependents[dep.Service] = append(dependents[dep.Service], service)
+			}
+		}
+	}
+
+	var ready []string
+	for service, n := range pending {
+		if n == 0 {
+			ready = append(ready, service)
+		}
+	}
+
+	out := make([]types.Container, 0, len(containers))
+	for len(ready) > 0 {
+		slices.Sort(ready)
+		service := ready[0]
+		ready = ready[1:]
+
+		conts := byService[service]
+		slices.SortFunc(conts, func(a, b types.Container) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		out = append(out, conts...)
+
+		for _, dependent := range dependents[service] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(out) != len(containers) {
+		var blocked []string
+		for service, n := range pending {
+			if n > 0 {
+				blocked = append(blocked, service)
+			}
+		}
+		slices.Sort(blocked)
+		return nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(blocked, ", "))
+	}
+	return out, nil
+}
+
+func label(c types.Container, name string) string {
+	if c.Config == nil {
+		return ""
+	}
+	return c.Config.Labels[name]
+}
@@ -0,0 +1,144 @@
+package compose
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+// WaitTimeout is the maximum time Start waits for a dependency to become
+// healthy or to complete before starting the services depending on it.
+var WaitTimeout = 2 * time.Minute
+
+// waitInterval is the delay between two inspections of a dependency being waited for.
+const waitInterval = 500 * time.Millisecond
+
+// Start starts the containers in dependency order. Before starting a service,
+// its dependencies declared with the service_healthy or the
+// service_completed_successfully condition are waited for.
+// Containers already running are left untouched.
+func Start(ctx context.Context, cli client.Client, containers []types.Container) error {
+	ordered, err := Order(containers)
+	if err != nil {
+		return err
+	}
+
+	byService := make(map[string][]types.Container)
+	for _, c := range ordered {
+		byService[Service(c)] = append(byService[Service(c)], c)
+	}
+
+	for _, c := range ordered {
+		for _, dep := range DependsOn(c) {
+			if dep.Condition == ConditionStarted {
+				continue
+			}
+			for _, d := range byService[dep.Service] {
+				if err := wait(ctx, cli, d, dep.Condition); err != nil {
+					return fmt.Errorf("unable to start %s: %w", name(c), err)
+				}
+			}
+		}
+
+		if c.State != nil && c.State.Running {
+			continue
+		}
+		if err := cli.StartContainer(c.ID); err != nil {
+			return fmt.Errorf("unable to start %s: %w", name(c), err)
+		}
+	}
+	return nil
+}
+
+// Stop stops the containers in reverse dependency order, so a service is
+// stopped before the services it depends on.
+func Stop(ctx context.Context, cli client.Client, containers []types.Container) error {
+	ordered, err := Order(containers)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range slices.Backward(ordered) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if c.State != nil && !c.State.Running && !c.State.Restarting {
+			continue
+		}
+		if err := cli.StopContainer(c.ID); err != nil {
+			return fmt.Errorf("unable to stop %s: %w", name(c), err)
+		}
+	}
+	return nil
+}
+
+// Restart stops the containers in reverse dependency order, then starts them
+// again in dependency order.
+func Restart(ctx context.Context, cli client.Client, containers []types.Container) error {
+	if err := Stop(ctx, cli, containers); err != nil {
+		return err
+	}
+
+	stopped := make([]types.Container, len(containers))
+	for i, c := range containers {
+		stopped[i] = c
+		stopped[i].State = &container.State{Status: container.StateExited}
+	}
+	return Start(ctx, cli, stopped)
+}
+
+// wait waits until the container reaches the given dependency condition.
+func wait(ctx context.Context, cli client.Client, c types.Container, condition string) error {
+	ctx, cancel := context.WithTimeout(ctx, WaitTimeout)
+	defer cancel()
+
+	for {
+		inspect, err := cli.ContainerInspect(c.ID)
+		if err != nil {
+			return err
+		}
+
+		state := inspect.State
+		switch condition {
+		case ConditionHealthy:
+			if state.Health == nil {
+				// no healthcheck, compose considers the service started
+				if state.Running {
+					return nil
+				}
+			} else if state.Health.Status == container.Healthy {
+				return nil
+			} else if state.Health.Status == container.Unhealthy {
+				return fmt.Errorf("dependency %s is unhealthy", name(c))
+			}
+		case ConditionCompleted:
+			if !state.Running && state.Status == container.StateExited {
+				if state.ExitCode != 0 {
+					return fmt.Errorf("dependency %s exited with code %d", name(c), state.ExitCode)
+				}
+				return nil
+			}
+		default:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout waiting for %s to be %s: %w", name(c), strings.TrimPrefix(condition, "service_"), ctx.Err())
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+func name(c types.Container) string {
+	if c.ContainerJSONBase == nil {
+		return ""
+	}
+	return strings.TrimPrefix(c.Name, "/")
+}
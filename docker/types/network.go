@@ -0,0 +1,50 @@
+package types
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/network"
+)
+
+type Network struct {
+	network.Inspect
+}
+
+// NetworkEndpoint is a container connected to a network.
+type NetworkEndpoint struct {
+	ContainerID string
+	network.EndpointResource
+}
+
+// Subnet returns the first subnet configured on the network, or an empty string.
+func (n Network) Subnet() string {
+	for _, cfg := range n.IPAM.Config {
+		if cfg.Subnet != "" {
+			return cfg.Subnet
+		}
+	}
+	return ""
+}
+
+// Gateway returns the first gateway configured on the network, or an empty string.
+func (n Network) Gateway() string {
+	for _, cfg := range n.IPAM.Config {
+		if cfg.Gateway != "" {
+			return cfg.Gateway
+		}
+	}
+	return ""
+}
+
+// Endpoints returns the containers connected to the network, sorted by name.
+func (n Network) Endpoints() []NetworkEndpoint {
+	out := make([]NetworkEndpoint, 0, len(n.Containers))
+	for id, ep := range n.Containers {
+		out = append(out, NetworkEndpoint{ContainerID: id, EndpointResource: ep})
+	}
+	slices.SortFunc(out, func(a, b NetworkEndpoint) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return out
+}
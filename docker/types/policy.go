@@ -0,0 +1,165 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kernaxis/gmd/schedule"
+)
+
+// Labels configuring the update policy of a container.
+const (
+	UpdateEnableLabel   = "gmd.update.enable"   // UpdateEnableLabel disables the updates with "false", opts in the unattended updates with "true".
+	UpdatePolicyLabel   = "gmd.update.policy"   // UpdatePolicyLabel is the UpdateMode of the container.
+	UpdateScheduleLabel = "gmd.update.schedule" // UpdateScheduleLabel is the schedule of the unattended updates of the container.
+	UpdateTrackLabel    = "gmd.update.track"    // UpdateTrackLabel is the UpdateTrack of the container.
+	UpdateStrategyLabel = "gmd.update.strategy" // UpdateStrategyLabel is the UpdateStrategy of the container.
+)
+
+// UpdateMode tells what is done when an update is available for a container.
+type UpdateMode string
+
+const (
+	UpdateDefault UpdateMode = ""       // UpdateDefault updates on request, and in the unattended updates selecting the container.
+	UpdateNotify  UpdateMode = "notify" // UpdateNotify only reports the updates, the unattended updates skip the container.
+	UpdateAuto    UpdateMode = "auto"   // UpdateAuto opts the container in the unattended updates.
+	UpdatePin     UpdateMode = "pin"    // UpdatePin never checks nor updates the container.
+)
+
+// UpdateTrack tells which images are updates for a container.
+type UpdateTrack string
+
+const (
+	TrackDigest UpdateTrack = ""             // TrackDigest follows the digest of the tag of the container.
+	TrackPatch  UpdateTrack = "semver-patch" // TrackPatch follows the newer patch versions of the tag.
+	TrackMinor  UpdateTrack = "semver-minor" // TrackMinor follows the newer minor and patch versions of the tag.
+	TrackMajor  UpdateTrack = "semver-major" // TrackMajor follows all the newer versions of the tag.
+)
+
+// UpdateStrategy tells how the updated container replaces the previous one.
+type UpdateStrategy string
+
+const (
+	StrategyDefault    UpdateStrategy = ""            // StrategyDefault follows the strategy chosen for the update run.
+	StrategyStopFirst  UpdateStrategy = "stop-first"  // StrategyStopFirst stops the previous container before creating the new one.
+	StrategyStartFirst UpdateStrategy = "start-first" // StrategyStartFirst starts the new container under a temporary name before stopping the previous one.
+)
+
+// ParseUpdateStrategy returns the strategy with the given name, stop-first or start-first.
+func ParseUpdateStrategy(name string) (UpdateStrategy, error) {
+	switch s := UpdateStrategy(strings.ToLower(name)); s {
+	case StrategyStopFirst, StrategyStartFirst:
+		return s, nil
+	}
+	return StrategyDefault, fmt.Errorf("unknown update strategy %q, expected stop-first or start-first", name)
+}
+
+// UpdatePolicy is the update policy of a container, configured by its labels.
+type UpdatePolicy struct {
+	Disabled bool           // Disabled is set by gmd.update.enable=false.
+	Mode     UpdateMode     // Mode is set by gmd.update.policy, or to auto by gmd.update.enable=true.
+	Schedule string         // Schedule is the schedule of the unattended updates, empty to follow the one of gmd watch.
+	Track    UpdateTrack    // Track is set by gmd.update.track.
+	Strategy UpdateStrategy // Strategy is set by gmd.update.strategy.
+	Invalid  string         // Invalid describes an invalid label, the updates are then disabled.
+}
+
+// UpdatePolicyOf returns the update policy configured by the labels of a container.
+func UpdatePolicyOf(labels map[string]string) UpdatePolicy {
+	var p UpdatePolicy
+
+	if v, ok := labels[UpdateEnableLabel]; ok {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return UpdatePolicy{Invalid: fmt.Sprintf("invalid %s label %q", UpdateEnableLabel, v)}
+		}
+		p.Disabled = !enabled
+		if enabled {
+			p.Mode = UpdateAuto
+		}
+	}
+
+	if v, ok := labels[UpdatePolicyLabel]; ok {
+		switch mode := UpdateMode(strings.ToLower(v)); mode {
+		case UpdateNotify, UpdateAuto, UpdatePin:
+			p.Mode = mode
+		default:
+			return UpdatePolicy{Invalid: fmt.Sprintf("invalid %s label %q, expected notify, auto or pin", UpdatePolicyLabel, v)}
+		}
+	}
+
+	if v, ok := labels[UpdateScheduleLabel]; ok {
+		if _, err := schedule.Parse(v); err != nil {
+			return UpdatePolicy{Invalid: fmt.Sprintf("invalid %s label: %v", UpdateScheduleLabel, err)}
+		}
+		p.Schedule = v
+	}
+
+	if v, ok := labels[UpdateTrackLabel]; ok {
+		switch track := UpdateTrack(strings.ToLower(v)); track {
+		case TrackPatch, TrackMinor, TrackMajor:
+			p.Track = track
+		case "digest":
+			p.Track = TrackDigest
+		default:
+			return UpdatePolicy{Invalid: fmt.Sprintf("invalid %s label %q, expected digest, semver-patch, semver-minor or semver-major", UpdateTrackLabel, v)}
+		}
+	}
+
+	if v, ok := labels[UpdateStrategyLabel]; ok {
+		strategy, err := ParseUpdateStrategy(v)
+		if err != nil {
+			return UpdatePolicy{Invalid: fmt.Sprintf("invalid %s label %q, expected stop-first or start-first", UpdateStrategyLabel, v)}
+		}
+		p.Strategy = strategy
+	}
+
+	return p
+}
+
+// Checked reports whether the container is checked for updates.
+func (p UpdatePolicy) Checked() bool {
+	return p.Invalid == "" && !p.Disabled && p.Mode != UpdatePin
+}
+
+// Unattended reports whether the unattended updates may update the container.
+func (p UpdatePolicy) Unattended() bool {
+	return p.Checked() && p.Mode != UpdateNotify
+}
+
+// OptedIn reports whether the container is opted in the unattended updates.
+func (p UpdatePolicy) OptedIn() bool {
+	return p.Checked() && p.Mode == UpdateAuto
+}
+
+// Name returns the name of the effective policy: invalid, disabled, pin, notify, auto or default.
+func (p UpdatePolicy) Name() string {
+	switch {
+	case p.Invalid != "":
+		return "invalid"
+	case p.Disabled:
+		return "disabled"
+	case p.Mode == UpdateDefault:
+		return "default"
+	default:
+		return string(p.Mode)
+	}
+}
+
+// String returns the name of the policy followed by its track, schedule and strategy, if any.
+func (p UpdatePolicy) String() string {
+	parts := []string{p.Name()}
+	if p.Checked() {
+		if p.Track != TrackDigest {
+			parts = append(parts, string(p.Track))
+		}
+		if p.Schedule != "" {
+			parts = append(parts, p.Schedule)
+		}
+		if p.Strategy != StrategyDefault {
+			parts = append(parts, string(p.Strategy))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
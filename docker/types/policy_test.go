@@ -0,0 +1,100 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestUpdatePolicyOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   UpdatePolicy
+		// checked, unattended and optedIn are the results of Checked, Unattended and OptedIn.
+		checked, unattended, optedIn bool
+	}{
+		{"no label", nil, UpdatePolicy{}, true, true, false},
+		{"other labels", map[string]string{"com.docker.compose.project": "app"}, UpdatePolicy{}, true, true, false},
+
+		{"enabled", map[string]string{UpdateEnableLabel: "true"}, UpdatePolicy{Mode: UpdateAuto}, true, true, true},
+		{"enabled as 1", map[string]string{UpdateEnableLabel: "1"}, UpdatePolicy{Mode: UpdateAuto}, true, true, true},
+		{"disabled", map[string]string{UpdateEnableLabel: "false"}, UpdatePolicy{Disabled: true}, false, false, false},
+		{"invalid enable", map[string]string{UpdateEnableLabel: "yes"}, UpdatePolicy{Invalid: `invalid gmd.update.enable label "yes"`}, false, false, false},
+
+		{"notify", map[string]string{UpdatePolicyLabel: "notify"}, UpdatePolicy{Mode: UpdateNotify}, true, false, false},
+		{"auto", map[string]string{UpdatePolicyLabel: "AUTO"}, UpdatePolicy{Mode: UpdateAuto}, true, true, true},
+		{"pin", map[string]string{UpdatePolicyLabel: "pin"}, UpdatePolicy{Mode: UpdatePin}, false, false, false},
+		{"invalid policy", map[string]string{UpdatePolicyLabel: "manual"}, UpdatePolicy{Invalid: `invalid gmd.update.policy label "manual", expected notify, auto or pin`}, false, false, false},
+
+		{"schedule", map[string]string{UpdateScheduleLabel: "@daily"}, UpdatePolicy{Schedule: "@daily"}, true, true, false},
+		{"cron schedule", map[string]string{UpdateScheduleLabel: "0 4 * * sun"}, UpdatePolicy{Schedule: "0 4 * * sun"}, true, true, false},
+		{"invalid schedule", map[string]string{UpdateScheduleLabel: "often"}, UpdatePolicy{Invalid: `invalid gmd.update.schedule label: invalid schedule "often": expected a duration or a cron expression with 5 fields`}, false, false, false},
+
+		{"digest track", map[string]string{UpdateTrackLabel: "digest"}, UpdatePolicy{Track: TrackDigest}, true, true, false},
+		{"minor track", map[string]string{UpdateTrackLabel: "Semver-Minor"}, UpdatePolicy{Track: TrackMinor}, true, true, false},
+		{"invalid track", map[string]string{UpdateTrackLabel: "latest"}, UpdatePolicy{Invalid: `invalid gmd.update.track label "latest", expected digest, semver-patch, semver-minor or semver-major`}, false, false, false},
+
+		{"start-first", map[string]string{UpdateStrategyLabel: "start-first"}, UpdatePolicy{Strategy: StrategyStartFirst}, true, true, false},
+		{"invalid strategy", map[string]string{UpdateStrategyLabel: "blue-green"}, UpdatePolicy{Invalid: `invalid gmd.update.strategy label "blue-green", expected stop-first or start-first`}, false, false, false},
+
+		// the policy label wins over the mode set by the enable label
+		{"enabled notify", map[string]string{UpdateEnableLabel: "true", UpdatePolicyLabel: "notify"}, UpdatePolicy{Mode: UpdateNotify}, true, false, false},
+		{"enabled pin", map[string]string{UpdateEnableLabel: "true", UpdatePolicyLabel: "pin"}, UpdatePolicy{Mode: UpdatePin}, false, false, false},
+		// a disabled container stays disabled whatever its policy
+		{"disabled auto", map[string]string{UpdateEnableLabel: "false", UpdatePolicyLabel: "auto"}, UpdatePolicy{Disabled: true, Mode: UpdateAuto}, false, false, false},
+		// an invalid label disables the updates whatever the other labels
+		{"auto invalid track", map[string]string{UpdatePolicyLabel: "auto", UpdateTrackLabel: "next"}, UpdatePolicy{Invalid: `invalid gmd.update.track label "next", expected digest, semver-patch, semver-minor or semver-major`}, false, false, false},
+		{"all labels", map[string]string{
+			UpdateEnableLabel:   "true",
+			UpdatePolicyLabel:   "auto",
+			UpdateScheduleLabel: "6h",
+			UpdateTrackLabel:    "semver-patch",
+			UpdateStrategyLabel: "stop-first",
+		}, UpdatePolicy{Mode: UpdateAuto, Schedule: "6h", Track: TrackPatch, Strategy: StrategyStopFirst}, true, true, true},
+	}
+	for _, tt := range tests {
+		p := UpdatePolicyOf(tt.labels)
+		if p != tt.want {
+			t.Errorf("%s: UpdatePolicyOf(%v) = %+v, want %+v", tt.name, tt.labels, p, tt.want)
+		}
+		if p.Checked() != tt.checked || p.Unattended() != tt.unattended || p.OptedIn() != tt.optedIn {
+			t.Errorf("%s: Checked, Unattended, OptedIn = %t, %t, %t, want %t, %t, %t", tt.name, p.Checked(), p.Unattended(), p.OptedIn(), tt.checked, tt.unattended, tt.optedIn)
+		}
+	}
+}
+
+func TestUpdatePolicyString(t *testing.T) {
+	tests := []struct {
+		policy UpdatePolicy
+		want   string
+	}{
+		{UpdatePolicy{}, "default"},
+		{UpdatePolicy{Mode: UpdateAuto, Track: TrackMinor, Schedule: "@daily", Strategy: StrategyStartFirst}, "auto · semver-minor · @daily · start-first"},
+		{UpdatePolicy{Disabled: true, Track: TrackMinor}, "disabled"},
+		{UpdatePolicy{Mode: UpdatePin, Schedule: "@daily"}, "pin"},
+		{UpdatePolicy{Invalid: "invalid", Mode: UpdateAuto}, "invalid"},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestParseUpdateStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		want UpdateStrategy
+		ok   bool
+	}{
+		{"stop-first", StrategyStopFirst, true},
+		{"Start-First", StrategyStartFirst, true},
+		{"", StrategyDefault, false},
+		{"rolling", StrategyDefault, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseUpdateStrategy(tt.name)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseUpdateStrategy(%q) = %q, %v, want %q, ok %t", tt.name, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Stats are the resource usage of a container computed from two samples of the stats stream.
+type Stats struct {
+	CPUPercent  float64 // CPUPercent is the CPU usage, 100% per fully used CPU.
+	CPUs        uint32  // CPUs is the number of CPUs available to the container.
+	MemoryUsage uint64  // MemoryUsage is the memory used, page cache excluded.
+	MemoryLimit uint64  // MemoryLimit is the memory limit of the container, or the memory of the host.
+	NetRx       float64 // NetRx is the network receive rate in bytes per second.
+	NetTx       float64 // NetTx is the network transmit rate in bytes per second.
+	BlockRead   float64 // BlockRead is the block device read rate in bytes per second.
+	BlockWrite  float64 // BlockWrite is the block device write rate in bytes per second.
+}
+
+// NewStats computes the stats of a container from the current sample and the previous one.
+// The rates are zero when prev is empty, i.e. for the first sample of a stream.
+func NewStats(cur, prev container.StatsResponse) Stats {
+	s := Stats{
+		CPUPercent:  cpuPercent(cur),
+		CPUs:        onlineCPUs(cur),
+		MemoryUsage: memoryUsage(cur.MemoryStats),
+		MemoryLimit: cur.MemoryStats.Limit,
+	}
+
+	elapsed := cur.Read.Sub(prev.Read)
+	if prev.Read.IsZero() || elapsed <= 0 {
+		return s
+	}
+
+	rx, tx := networkBytes(cur)
+	prevRx, prevTx := networkBytes(prev)
+	s.NetRx = rate(rx, prevRx, elapsed)
+	s.NetTx = rate(tx, prevTx, elapsed)
+
+	read, write := blockBytes(cur)
+	prevRead, prevWrite := blockBytes(prev)
+	s.BlockRead = rate(read, prevRead, elapsed)
+	s.BlockWrite = rate(write, prevWrite, elapsed)
+	return s
+}
+
+// MemoryPercent returns the memory usage relative to the limit.
+func (s Stats) MemoryPercent() float64 {
+	if s.MemoryLimit == 0 {
+		return 0
+	}
+	return float64(s.MemoryUsage) / float64(s.MemoryLimit) * 100
+}
+
+// cpuPercent computes the CPU usage between the sample and the previous one
+// sent by the daemon, the same way the docker CLI does.
+func cpuPercent(v container.StatsResponse) float64 {
+	cpuDelta := float64(v.CPUStats.CPUUsage.TotalUsage) - float64(v.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(v.CPUStats.SystemUsage) - float64(v.PreCPUStats.SystemUsage)
+	if cpuDelta <= 0 || systemDelta <= 0 {
+		return 0
+	}
+	return cpuDelta / systemDelta * float64(onlineCPUs(v)) * 100
+}
+
+// onlineCPUs returns the number of CPUs of the sample, from the per-CPU usage for older daemons.
+func onlineCPUs(v container.StatsResponse) uint32 {
+	if v.CPUStats.OnlineCPUs > 0 {
+		return v.CPUStats.OnlineCPUs
+	}
+	return uint32(len(v.CPUStats.CPUUsage.PercpuUsage))
+}
+
+// memoryUsage returns the memory used without the inactive page cache,
+// which the kernel can reclaim, like the docker CLI does.
+func memoryUsage(m container.MemoryStats) uint64 {
+	// cgroup v1
+	if v, ok := m.Stats["total_inactive_file"]; ok && v < m.Usage {
+		return m.Usage - v
+	}
+	// cgroup v2
+	if v, ok := m.Stats["inactive_file"]; ok && v < m.Usage {
+		return m.Usage - v
+	}
+	return m.Usage
+}
+
+// networkBytes returns the bytes received and transmitted on all the interfaces.
+func networkBytes(v container.StatsResponse) (rx, tx uint64) {
+	for _, n := range v.Networks {
+		rx += n.RxBytes
+		tx += n.TxBytes
+	}
+	return rx, tx
+}
+
+// blockBytes returns the bytes read from and written to all the block devices.
+func blockBytes(v container.StatsResponse) (read, write uint64) {
+	for _, e := range v.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			read += e.Value
+		case "write":
+			write += e.Value
+		}
+	}
+	return read, write
+}
+
+// rate returns the rate per second of a counter, zero when the counter has been reset.
+func rate(cur, prev uint64, elapsed time.Duration) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / elapsed.Seconds()
+}
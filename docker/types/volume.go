@@ -0,0 +1,24 @@
+package types
+
+import "github.com/docker/docker/api/types/volume"
+
+// AnonymousVolumeLabel is set by the daemon, from API 1.42, on the volumes created without a name.
+const AnonymousVolumeLabel = "com.docker.volume.anonymous"
+
+type Volume struct {
+	volume.Volume
+}
+
+// Anonymous reports whether the volume was created without a name, for a container.
+func (v Volume) Anonymous() bool {
+	_, ok := v.Labels[AnonymousVolumeLabel]
+	return ok
+}
+
+// Size returns the disk usage of the volume, or -1 when it is unknown.
+func (v Volume) Size() int64 {
+	if v.UsageData == nil {
+		return -1
+	}
+	return v.UsageData.Size
+}
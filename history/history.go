@@ -0,0 +1,166 @@
+// Package history records the container updates in an append-only log.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of an update.
+type Result string
+
+const (
+	Updated    Result = "updated"     // Updated is set when the new container replaced the previous one.
+	RolledBack Result = "rolled back" // RolledBack is set when the update failed and the previous container was restored.
+	Failed     Result = "failed"      // Failed is set when the update failed before replacing the container, or when its rollback failed.
+)
+
+// Record is the audit record of the update of a container.
+type Record struct {
+	Started        time.Time     `json:"started"`
+	Finished       time.Time     `json:"finished"`
+	Host           string        `json:"host"`
+	Container      string        `json:"container"`                  // Container is the name of the container.
+	OldContainerID string        `json:"old_container_id"`           // OldContainerID is the ID of the updated container.
+	NewContainerID string        `json:"new_container_id,omitempty"` // NewContainerID is the ID of the created container, empty when it was not created.
+	Old            ImageVersion  `json:"old"`                        // Old is the image the container ran before the update.
+	New            ImageVersion  `json:"new"`                        // New is the image pulled by the update.
+	Result         Result        `json:"result"`
+	Error          string        `json:"error,omitempty"`
+	Steps          []Step        `json:"steps,omitempty"`
+	Strategy       string        `json:"strategy,omitempty"` // Strategy is the update strategy used: stop-first or start-first.
+	Downtime       time.Duration `json:"downtime,omitempty"` // Downtime is the time, in nanoseconds, the container name had no running container.
+	Operator       string        `json:"operator"`           // Operator is the user who ran the update, as user@machine.
+	Source         string        `json:"source"`             // Source is the part of gmd which ran the update: tui, update or watch.
+}
+
+// ImageVersion identifies an image.
+type ImageVersion struct {
+	Ref    string `json:"ref"`              // Ref is the image reference, such as nginx:1.25.
+	ID     string `json:"id,omitempty"`     // ID is the local image ID.
+	Digest string `json:"digest,omitempty"` // Digest is the registry digest of the image.
+}
+
+// Step is a step of an update.
+type Step struct {
+	Label    string    `json:"label"`
+	Target   string    `json:"target"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Duration returns the duration of the update.
+func (r Record) Duration() time.Duration {
+	return r.Finished.Sub(r.Started)
+}
+
+// Store is an append-only log of update records, stored as one JSON record per line.
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+// New returns the store saving the records in the file at path.
+func New(path string) *Store {
+	return &Store{path: path}
+}
+
+// DefaultPath returns the history file in the user state directory,
+// $XDG_STATE_HOME/gmd/history.jsonl or ~/.local/state/gmd/history.jsonl.
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "gmd", "history.jsonl"), nil
+}
+
+// Path returns the file of the store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Append appends the record to the store.
+func (s *Store) Append(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	// a single write keeps the lines of concurrent gmd processes whole
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load returns the records of the store, oldest first.
+// The lines which are not valid records are skipped.
+func (s *Store) Load() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			log.Printf("invalid history record at %s:%d: %s", s.path, line, err)
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return records, fmt.Errorf("unable to read the history %s: %w", s.path, err)
+	}
+	return records, nil
+}
+
+// Operator returns the current user as user@machine.
+func Operator() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name += "@" + host
+	}
+	return name
+}
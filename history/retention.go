@@ -0,0 +1,89 @@
+package history
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// PreviousImage is an image replaced by the update of a container, which the
+// container can be rolled back to while the image is kept.
+type PreviousImage struct {
+	Host      string
+	Container string       // Container is the name of the container the image was replaced in.
+	Image     ImageVersion // Image is the replaced image, with the reference the container used.
+	Replaced  time.Time    // Replaced is when the update replaced the image.
+}
+
+// Repository returns the repository of the image, such as nginx for nginx:1.25.
+func (p PreviousImage) Repository() string {
+	return Repository(p.Image.Ref)
+}
+
+// Repository returns the repository of an image reference, without its tag and digest.
+func Repository(ref string) string {
+	ref, _, _ = strings.Cut(ref, "@")
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref
+}
+
+// PreviousImages returns the images replaced by the successful updates of the
+// records, most recently replaced first. An image is returned once, with its
+// last replacement, and not at all when a later update made it the image of a
+// container again.
+func PreviousImages(records []Record) []PreviousImage {
+	records = slices.Clone(records)
+	slices.SortStableFunc(records, func(a, b Record) int { return b.Finished.Compare(a.Finished) })
+
+	var out []PreviousImage
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if r.Result != Updated {
+			continue
+		}
+		// the records are read from the most recent, the later updates are seen first
+		if r.New.ID != "" {
+			seen[r.Host+"/"+r.New.ID] = true
+		}
+		if r.Old.ID == "" || r.Old.ID == r.New.ID || seen[r.Host+"/"+r.Old.ID] {
+			continue
+		}
+		seen[r.Host+"/"+r.Old.ID] = true
+		out = append(out, PreviousImage{Host: r.Host, Container: r.Container, Image: r.Old, Replaced: r.Finished})
+	}
+	return out
+}
+
+// Retention tells which previous images are kept after the updates: the
+// last Keep images of each repository, and the ones replaced for less than
+// MaxAge. An image kept by either limit is kept.
+type Retention struct {
+	Keep   int           // Keep is the number of previous images kept per repository, negative to keep them all.
+	MaxAge time.Duration // MaxAge is how long the previous images are kept beyond Keep, 0 to not keep them longer.
+}
+
+// Expired returns the previous images, given most recent first, the retention does not keep anymore.
+func (r Retention) Expired(previous []PreviousImage, now time.Time) []PreviousImage {
+	if r.Keep < 0 {
+		return nil
+	}
+
+	var out []PreviousImage
+	ranks := make(map[string]int)
+	for _, p := range previous {
+		repo := p.Host + "/" + p.Repository()
+		rank := ranks[repo]
+		ranks[repo]++
+
+		if rank < r.Keep {
+			continue
+		}
+		if r.MaxAge > 0 && now.Sub(p.Replaced) <= r.MaxAge {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
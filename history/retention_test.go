@@ -0,0 +1,144 @@
+package history
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPreviousImages(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	v1 := ImageVersion{Ref: "nginx:1", ID: "sha256:1"}
+	v2 := ImageVersion{Ref: "nginx:2", ID: "sha256:2"}
+	v3 := ImageVersion{Ref: "nginx:3", ID: "sha256:3"}
+
+	tests := []struct {
+		name    string
+		records []Record
+		want    []string // want are the IDs of the previous images
+	}{
+		{
+			name:    "no update",
+			records: nil,
+			want:    nil,
+		},
+		{
+			name: "most recent first",
+			records: []Record{
+				{Host: "local", Container: "web", Old: v1, New: v2, Result: Updated, Finished: t0},
+				{Host: "local", Container: "web", Old: v2, New: v3, Result: Updated, Finished: t0.Add(time.Hour)},
+			},
+			want: []string{"sha256:2", "sha256:1"},
+		},
+		{
+			name: "records out of order",
+			records: []Record{
+				{Host: "local", Container: "web", Old: v2, New: v3, Result: Updated, Finished: t0.Add(time.Hour)},
+				{Host: "local", Container: "web", Old: v1, New: v2, Result: Updated, Finished: t0},
+			},
+			want: []string{"sha256:2", "sha256:1"},
+		},
+		{
+			name: "failed and rolled back updates",
+			records: []Record{
+				{Host: "local", Container: "web", Old: v1, New: v2, Result: Failed, Finished: t0},
+				{Host: "local", Container: "web", Old: v1, New: v2, Result: RolledBack, Finished: t0.Add(time.Hour)},
+			},
+			want: nil,
+		},
+		{
+			name: "same image",
+			records: []Record{
+				{Host: "local", Container: "web", Old: v1, New: v1, Result: Updated, Finished: t0},
+			},
+			want: nil,
+		},
+		{
+			name: "image used again by a rollback",
+			records: []Record{
+				{Host: "local", Container: "web", Old: v1, New: v2, Result: Updated, Finished: t0},
+				{Host: "local", Container: "web", Old: v2, New: v1, Result: Updated, Finished: t0.Add(time.Hour)},
+			},
+			want: []string{"sha256:2"},
+		},
+		{
+			name: "image replaced twice",
+			records: []Record{
+				{Host: "local", Container: "web", Old: v1, New: v2, Result: Updated, Finished: t0},
+				{Host: "local", Container: "api", Old: v1, New: v2, Result: Updated, Finished: t0.Add(time.Hour)},
+			},
+			want: []string{"sha256:1"},
+		},
+		{
+			name: "hosts apart",
+			records: []Record{
+				{Host: "local", Container: "web", Old: v1, New: v2, Result: Updated, Finished: t0},
+				{Host: "remote", Container: "web", Old: v2, New: v1, Result: Updated, Finished: t0.Add(time.Hour)},
+			},
+			want: []string{"sha256:2", "sha256:1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range PreviousImages(tt.records) {
+				got = append(got, p.Image.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("PreviousImages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionExpired(t *testing.T) {
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	previous := []PreviousImage{
+		{Host: "local", Image: ImageVersion{Ref: "nginx:3", ID: "n3"}, Replaced: now.Add(-time.Hour)},
+		{Host: "local", Image: ImageVersion{Ref: "redis:7", ID: "r7"}, Replaced: now.Add(-2 * time.Hour)},
+		{Host: "local", Image: ImageVersion{Ref: "nginx:2", ID: "n2"}, Replaced: now.Add(-48 * time.Hour)},
+		{Host: "remote", Image: ImageVersion{Ref: "nginx:2", ID: "m2"}, Replaced: now.Add(-72 * time.Hour)},
+		{Host: "local", Image: ImageVersion{Ref: "nginx:1", ID: "n1"}, Replaced: now.Add(-96 * time.Hour)},
+	}
+
+	tests := []struct {
+		name      string
+		retention Retention
+		want      []string
+	}{
+		{"keep all", Retention{Keep: -1}, nil},
+		{"keep all ignores the age", Retention{Keep: -1, MaxAge: time.Hour}, nil},
+		{"keep none", Retention{Keep: 0}, []string{"n3", "r7", "n2", "m2", "n1"}},
+		{"keep one per repository and host", Retention{Keep: 1}, []string{"n2", "n1"}},
+		{"keep two", Retention{Keep: 2}, []string{"n1"}},
+		{"keep by age only", Retention{Keep: 0, MaxAge: 24 * time.Hour}, []string{"n2", "m2", "n1"}},
+		{"kept by either", Retention{Keep: 1, MaxAge: 72 * time.Hour}, []string{"n1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range tt.retention.Expired(previous, now) {
+				got = append(got, p.Image.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository(t *testing.T) {
+	tests := []struct{ ref, want string }{
+		{"nginx", "nginx"},
+		{"nginx:1.25", "nginx"},
+		{"nginx@sha256:abc", "nginx"},
+		{"nginx:1.25@sha256:abc", "nginx"},
+		{"registry:5000/team/app", "registry:5000/team/app"},
+		{"registry:5000/team/app:v2", "registry:5000/team/app"},
+	}
+	for _, tt := range tests {
+		if got := Repository(tt.ref); got != tt.want {
+			t.Errorf("Repository(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// Package schedule provides the schedules and the maintenance windows of the unattended updates.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule returns the next time a task must run.
+type Schedule interface {
+	// Next returns the first activation time strictly after t.
+	Next(t time.Time) time.Time
+}
+
+// every is a schedule running at a fixed interval.
+type every time.Duration
+
+func (d every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// Every returns a schedule running every d.
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+// Parse parses a schedule: a duration such as "6h" or "@every 6h", one of the
+// @hourly, @daily, @weekly, @monthly and @yearly shortcuts, or a cron expression
+// with 5 fields (minute, hour, day of month, month, day of week).
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := strings.CutPrefix(expr, "@every "); ok {
+		expr = strings.TrimSpace(d)
+	}
+	if d, err := time.ParseDuration(expr); err == nil {
+		if d < time.Minute {
+			return nil, fmt.Errorf("invalid schedule %q: the interval must be at least 1m", expr)
+		}
+		return Every(d), nil
+	}
+	return ParseCron(expr)
+}
+
+// shortcuts are the cron expressions of the @ shortcuts.
+var shortcuts = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// Cron is a schedule defined by a cron expression, in the local time zone.
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // the fields are bitsets of the allowed values.
+	domStar, dowStar              bool   // domStar and dowStar are set when the day fields are "*".
+}
+
+// field describes the range of a field of a cron expression.
+type field struct {
+	name     string
+	min, max int
+	names    []string // names are the names of the values, starting at min.
+}
+
+var fields = []field{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, monthNames},
+	{"day of week", 0, 7, dayNames},
+}
+
+// ParseCron parses a cron expression with 5 fields. The fields accept "*",
+// values, ranges ("1-5"), lists ("1,15"), steps ("*/15", "0-30/10") and the
+// names of the months and days ("jan", "mon-fri").
+func ParseCron(expr string) (*Cron, error) {
+	if s, ok := shortcuts[expr]; ok {
+		expr = s
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected a duration or a cron expression with 5 fields", expr)
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, f := range fields {
+		b, err := f.parse(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", expr, err)
+		}
+		bits[i] = b
+	}
+	// 7 is another name for sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &Cron{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: parts[2] == "*",
+		dowStar: parts[4] == "*",
+	}, nil
+}
+
+// parse returns the bitset of the values allowed by the field.
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(expr, ",") {
+		rng, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepText)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in the %s field", stepText, f.name)
+			}
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			first, last, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(first); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(last); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q in the %s field", rng, f.name)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses a value of the field, as a number or a name.
+func (f field) value(text string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(text, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q in the %s field, expected %d-%d", text, f.name, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first time matching the expression strictly after t,
+// or the zero time when none matches within 5 years.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches the day fields. As in cron,
+// when both fields are restricted, a day matching either of them is enough.
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
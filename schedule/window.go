@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a weekly maintenance window, such as "mon-fri 02:00-04:00", in the local time zone.
+// A window whose end is before its start ends the next day.
+type Window struct {
+	days       uint8 // days is the bitset of the weekdays the window starts on.
+	start, end int   // start and end are minutes of the day.
+}
+
+// ParseWindow parses a maintenance window: optional days, as a list or a range
+// of day names ("sat,sun", "mon-fri"), followed by a time range ("22:00-02:00").
+// Without days, the window is open every day.
+func ParseWindow(text string) (Window, error) {
+	parts := strings.Fields(text)
+	if len(parts) == 0 || len(parts) > 2 {
+		return Window{}, fmt.Errorf("invalid maintenance window %q: expected [days] HH:MM-HH:MM", text)
+	}
+
+	w := Window{days: 0x7f}
+	if len(parts) == 2 {
+		days, err := fields[4].parse(parts[0])
+		if err != nil {
+			return Window{}, fmt.Errorf("invalid maintenance window %q: %w", text, err)
+		}
+		if days&(1<<7) != 0 {
+			days |= 1
+		}
+		w.days = uint8(days & 0x7f)
+	}
+
+	first, last, ok := strings.Cut(parts[len(parts)-1], "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid maintenance window %q: expected [days] HH:MM-HH:MM", text)
+	}
+	var err error
+	if w.start, err = minutes(first); err != nil {
+		return Window{}, fmt.Errorf("invalid maintenance window %q: %w", text, err)
+	}
+	if w.end, err = minutes(last); err != nil {
+		return Window{}, fmt.Errorf("invalid maintenance window %q: %w", text, err)
+	}
+	if w.start == w.end {
+		return Window{}, fmt.Errorf("invalid maintenance window %q: empty time range", text)
+	}
+	return w, nil
+}
+
+// minutes parses a HH:MM time into minutes of the day, 24:00 being the end of the day.
+func minutes(text string) (int, error) {
+	t, err := time.Parse("15:04", text)
+	if err != nil {
+		if text == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", text)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether the window is open at t.
+func (w Window) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	today := w.days&(1<<uint(t.Weekday())) != 0
+	if w.start < w.end {
+		return today && m >= w.start && m < w.end
+	}
+	yesterday := w.days&(1<<uint((t.Weekday()+6)%7)) != 0
+	return (today && m >= w.start) || (yesterday && m < w.end)
+}
+
+// Next returns the first time the window opens strictly after t.
+func (w Window) Next(t time.Time) time.Time {
+	for day := 0; day <= 7; day++ {
+		d := t.AddDate(0, 0, day)
+		if w.days&(1<<uint(d.Weekday())) == 0 {
+			continue
+		}
+		if open := time.Date(d.Year(), d.Month(), d.Day(), 0, w.start, 0, 0, t.Location()); open.After(t) {
+			return open
+		}
+	}
+	return time.Time{}
+}
+
+// NextOpen returns the first time one of the windows opens strictly after t,
+// or the zero time without windows.
+func NextOpen(windows []Window, t time.Time) time.Time {
+	var next time.Time
+	for _, w := range windows {
+		if open := w.Next(t); !open.IsZero() && (next.IsZero() || open.Before(next)) {
+			next = open
+		}
+	}
+	return next
+}
+
+// Open reports whether one of the windows is open at t. Without windows, it is always open.
+func Open(windows []Window, t time.Time) bool {
+	if len(windows) == 0 {
+		return true
+	}
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
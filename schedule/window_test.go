@@ -0,0 +1,125 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// at returns the given time of the week of Monday 2026-10-19, in UTC.
+func at(day time.Weekday, clock string) time.Time {
+	c, err := time.Parse("15:04", clock)
+	if err != nil {
+		panic(err)
+	}
+	// 2026-10-18 is a sunday
+	return time.Date(2026, 10, 18+int(day), c.Hour(), c.Minute(), 0, 0, time.UTC)
+}
+
+func TestWindowContains(t *testing.T) {
+	tests := []struct {
+		window string
+		t      time.Time
+		want   bool
+	}{
+		{"02:00-04:00", at(time.Wednesday, "02:00"), true},
+		{"02:00-04:00", at(time.Wednesday, "03:59"), true},
+		{"02:00-04:00", at(time.Wednesday, "04:00"), false},
+		{"02:00-04:00", at(time.Wednesday, "01:59"), false},
+		{"mon-fri 02:00-04:00", at(time.Friday, "03:00"), true},
+		{"mon-fri 02:00-04:00", at(time.Saturday, "03:00"), false},
+		{"mon-fri 02:00-04:00", at(time.Sunday, "03:00"), false},
+		{"00:00-24:00", at(time.Sunday, "23:59"), true},
+		// the window crossing midnight ends on the next day
+		{"sat,sun 22:00-06:00", at(time.Saturday, "22:00"), true},
+		{"sat,sun 22:00-06:00", at(time.Saturday, "21:59"), false},
+		{"sat,sun 22:00-06:00", at(time.Sunday, "05:59"), true},
+		{"sat,sun 22:00-06:00", at(time.Monday, "05:59"), true},
+		{"sat,sun 22:00-06:00", at(time.Monday, "06:00"), false},
+		{"sat,sun 22:00-06:00", at(time.Monday, "22:00"), false},
+		{"sat,sun 22:00-06:00", at(time.Saturday, "05:00"), false},
+		{"sun 23:00-01:00", at(time.Monday, "00:30"), true},
+		{"sat 23:00-01:00", at(time.Sunday, "00:30"), true},
+		{"7 23:00-01:00", at(time.Monday, "00:30"), true},
+	}
+	for _, tt := range tests {
+		w, err := ParseWindow(tt.window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := w.Contains(tt.t); got != tt.want {
+			t.Errorf("%q.Contains(%s) = %t, want %t", tt.window, tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	windows := parseWindows(t, "mon-fri 02:00-04:00", "sat,sun 22:00-06:00")
+	tests := []struct {
+		windows []Window
+		t       time.Time
+		want    bool
+	}{
+		{nil, at(time.Monday, "12:00"), true},
+		{windows, at(time.Monday, "12:00"), false},
+		{windows, at(time.Tuesday, "03:00"), true},
+		{windows, at(time.Monday, "03:00"), true},
+		{windows, at(time.Sunday, "23:00"), true},
+		{windows, at(time.Saturday, "03:00"), false},
+	}
+	for _, tt := range tests {
+		if got := Open(tt.windows, tt.t); got != tt.want {
+			t.Errorf("Open(%d windows, %s) = %t, want %t", len(tt.windows), tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestNextOpen(t *testing.T) {
+	tests := []struct {
+		windows []string
+		t       time.Time
+		want    time.Time
+	}{
+		{nil, at(time.Monday, "12:00"), time.Time{}},
+		{[]string{"02:00-04:00"}, at(time.Monday, "01:00"), at(time.Monday, "02:00")},
+		{[]string{"02:00-04:00"}, at(time.Monday, "02:00"), at(time.Tuesday, "02:00")},
+		{[]string{"02:00-04:00"}, at(time.Monday, "12:00"), at(time.Tuesday, "02:00")},
+		{[]string{"mon-fri 02:00-04:00"}, at(time.Friday, "12:00"), at(time.Monday, "02:00").AddDate(0, 0, 7)},
+		{[]string{"sat,sun 22:00-06:00"}, at(time.Monday, "12:00"), at(time.Saturday, "22:00")},
+		{[]string{"sat,sun 22:00-06:00"}, at(time.Sunday, "03:00"), at(time.Sunday, "22:00")},
+		{[]string{"mon 22:00-02:00"}, at(time.Monday, "22:30"), at(time.Monday, "22:00").AddDate(0, 0, 7)},
+		{[]string{"mon-fri 02:00-04:00", "sat,sun 22:00-06:00"}, at(time.Friday, "12:00"), at(time.Saturday, "22:00")},
+		{[]string{"sat 22:00-06:00", "00:00-01:00"}, at(time.Saturday, "12:00"), at(time.Saturday, "22:00")},
+	}
+	for _, tt := range tests {
+		windows := parseWindows(t, tt.windows...)
+		got := NextOpen(windows, tt.t)
+		if !got.Equal(tt.want) {
+			t.Errorf("NextOpen(%q, %s) = %s, want %s", tt.windows, tt.t.Format("Mon 15:04"), got, tt.want)
+			continue
+		}
+		if !got.IsZero() && !Open(windows, got) {
+			t.Errorf("NextOpen(%q, %s) = %s, which is not open", tt.windows, tt.t.Format("Mon 15:04"), got)
+		}
+	}
+}
+
+func TestParseWindowErrors(t *testing.T) {
+	for _, text := range []string{"", "02:00", "mon 02:00-02:00", "everyday 02:00-04:00", "mon-fri 2am-4am", "mon fri 02:00-04:00", "25:00-26:00"} {
+		if _, err := ParseWindow(text); err == nil {
+			t.Errorf("ParseWindow(%q) succeeded, want an error", text)
+		}
+	}
+}
+
+func parseWindows(t *testing.T, texts ...string) []Window {
+	t.Helper()
+	var windows []Window
+	for _, text := range texts {
+		w, err := ParseWindow(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		windows = append(windows, w)
+	}
+	return windows
+}
@@ -3,16 +3,13 @@ package tui
 import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kernaxis/gmd/docker/cache"
+	"github.com/kernaxis/gmd/tui/commands"
 )
 
-type CacheStartMsg struct {
-	Err error
-}
-
 func StartMonitorCache(m *cache.Cache) tea.Cmd {
 	return func() tea.Msg {
 		err := m.LoadAndStart()
-		return CacheStartMsg{Err: err}
+		return commands.CacheStartMsg{Host: m.Host(), Err: err}
 	}
 }
 
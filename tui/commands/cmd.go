@@ -1,8 +1,12 @@
 package commands
 
 import (
+	"context"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/compose"
+	"github.com/kernaxis/gmd/docker/types"
 )
 
 func SwitchPageCmd(modelCreate func() tea.Model) tea.Cmd {
@@ -15,9 +19,9 @@ func SwitchPageCmd(modelCreate func() tea.Model) tea.Cmd {
 	}
 }
 
-func ContainerCmd(cli *client.Client, action Action, id string) tea.Cmd {
+func ContainerCmd(cli client.Client, action Action, id string) tea.Cmd {
 	return func() tea.Msg {
-		msg := ContainerActionMsg{ContainerID: id, Action: action}
+		msg := ContainerActionMsg{Host: cli.Endpoint().Name(), ContainerID: id, Action: action}
 
 		switch action {
 		case StartContainerAction:
@@ -32,3 +36,22 @@ func ContainerCmd(cli *client.Client, action Action, id string) tea.Cmd {
 		return msg
 	}
 }
+
+// ProjectCmd runs the action on the containers of a compose project,
+// following the dependency order of its services.
+func ProjectCmd(cli client.Client, action Action, project string, containers []types.Container) tea.Cmd {
+	return func() tea.Msg {
+		msg := ProjectActionMsg{Host: cli.Endpoint().Name(), Project: project, Action: action}
+
+		ctx := context.Background()
+		switch action {
+		case StartContainerAction:
+			msg.Err = compose.Start(ctx, cli, containers)
+		case StopContainerAction:
+			msg.Err = compose.Stop(ctx, cli, containers)
+		case RestartContainerAction:
+			msg.Err = compose.Restart(ctx, cli, containers)
+		}
+		return msg
+	}
+}
@@ -6,6 +6,18 @@ type SwitchPageMsg struct {
 	Model tea.Model
 }
 
+// CacheStartMsg is sent once the cache of a host is loaded, or failed to load.
+type CacheStartMsg struct {
+	Host string
+	Err  error
+}
+
+// SelectHostMsg asks the models to show the objects of the given host.
+// An empty Host selects all the hosts.
+type SelectHostMsg struct {
+	Host string
+}
+
 type Action string
 
 const (
@@ -16,12 +28,21 @@ const (
 )
 
 type ContainerActionMsg struct {
+	Host        string
 	ContainerID string
 	Action      Action
 	Update      bool
 	Err         error
 }
 
+// ProjectActionMsg is sent once an action on all the containers of a compose project is done.
+type ProjectActionMsg struct {
+	Host    string
+	Project string
+	Action  Action
+	Err     error
+}
+
 // type PullStartedMsg struct {
 // 	Channel chan PullProgressMsg
 // }
@@ -1,88 +1,138 @@
 package containerstats
 
 import (
+	"context"
+	"log"
 	"sync"
 	"time"
 
-	"github.com/alitto/pond/v2"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
 	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/types"
 )
 
+const (
+	restartDelay    = time.Second      // restartDelay is the first delay before a stream which ended is opened again.
+	maxRestartDelay = 30 * time.Second // maxRestartDelay bounds the delay doubled after each stream ending without a sample.
+)
+
+// StatsMsg delivers the stats computed from the last sample of a container.
 type StatsMsg struct {
+	Host  string
 	ID    string
-	Stats container.StatsResponse
+	Stats types.Stats
 }
 
+// Controller streams the stats of the containers of a host.
+// Each container has its own stream, started by AddContainer and stopped by RemoveContainer.
 type Controller struct {
-	mu         sync.RWMutex
-	cli        *client.Client
-	pool       pond.Pool
-	delay      time.Duration
-	containers map[string]struct{}
+	mu         sync.Mutex
+	cli        client.Client
+	host       string
+	containers map[string]context.CancelFunc // containers is a map of container IDs to the cancel function of their stream.
 	events     chan StatsMsg
 }
 
-func New(cli *client.Client) *Controller {
-	pool := pond.NewPool(5, pond.WithQueueSize(5))
+func New(cli client.Client) *Controller {
 	c := &Controller{
 		cli:        cli,
-		pool:       pool,
-		delay:      500 * time.Millisecond,
-		containers: make(map[string]struct{}),
-		events:     make(chan StatsMsg),
+		host:       cli.Endpoint().Name(),
+		containers: make(map[string]context.CancelFunc),
+		events:     make(chan StatsMsg, 64),
 	}
 	return c
 }
 
-func (c *Controller) Start() {
-	go c.loop()
-}
-
 func (c *Controller) Events() <-chan StatsMsg {
 	return c.events
 }
 
+// AddContainer starts streaming the stats of the container, if not already done.
 func (c *Controller) AddContainer(id string) {
 	c.mu.Lock()
-	c.containers[id] = struct{}{}
-	c.mu.Unlock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.containers[id]; ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.containers[id] = cancel
+	go c.stream(ctx, id)
 }
 
+// RemoveContainer stops streaming the stats of the container.
 func (c *Controller) RemoveContainer(id string) {
 	c.mu.Lock()
-	delete(c.containers, id)
-	c.mu.Unlock()
+	defer c.mu.Unlock()
+
+	if cancel, ok := c.containers[id]; ok {
+		cancel()
+		delete(c.containers, id)
+	}
 }
 
-func (c *Controller) loop() {
-	ticker := time.NewTicker(c.delay)
-	defer ticker.Stop()
+// Sync streams the stats of the given containers only.
+func (c *Controller) Sync(ids []string) {
+	keep := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		keep[id] = struct{}{}
+		c.AddContainer(id)
+	}
 
-	for {
-		select {
-		case <-ticker.C:
-			c.poll()
+	c.mu.Lock()
+	for id, cancel := range c.containers {
+		if _, ok := keep[id]; !ok {
+			cancel()
+			delete(c.containers, id)
 		}
 	}
+	c.mu.Unlock()
 }
 
-func (c *Controller) poll() {
-	c.mu.RLock()
-	ids := make([]string, 0, len(c.containers))
-	for id := range c.containers {
-		ids = append(ids, id)
-	}
-	c.mu.RUnlock()
-
-	for _, id := range ids {
-		cid := id
-		c.pool.Submit(func() {
-			stats, err := c.cli.ContainerStats(cid)
-			if err != nil {
-				return
+// stream reads the stats of the container until ctx is cancelled. A stream
+// ending by itself, on an error or when the container stops, is opened again
+// after a delay doubled while the streams end without a sample. It is only
+// given up when the container does not exist anymore.
+func (c *Controller) stream(ctx context.Context, id string) {
+	var prev container.StatsResponse
+	delay := restartDelay
+	for {
+		sampled := false
+		err := c.cli.ContainerStats(ctx, id, func(v container.StatsResponse) {
+			msg := StatsMsg{Host: c.host, ID: id, Stats: types.NewStats(v, prev)}
+			prev = v
+			sampled = true
+			select {
+			case c.events <- msg:
+			case <-ctx.Done():
 			}
-			c.events <- StatsMsg{ID: cid, Stats: stats}
 		})
+		if ctx.Err() != nil {
+			return
+		}
+		if errdefs.IsNotFound(err) {
+			// the stream is still the one of the container when ctx is not cancelled
+			c.mu.Lock()
+			if cancel, ok := c.containers[id]; ok && ctx.Err() == nil {
+				cancel()
+				delete(c.containers, id)
+			}
+			c.mu.Unlock()
+			return
+		}
+		if err != nil {
+			log.Printf("error streaming stats of container %s: %s", id, err)
+		}
+
+		if sampled {
+			delay = restartDelay
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxRestartDelay)
 	}
 }
@@ -0,0 +1,133 @@
+package containerstats
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kernaxis/gmd/docker/client/fake"
+)
+
+// receive returns the stats messages sent until each of the given containers got n of them.
+func receive(t *testing.T, c *Controller, ids []string, n int, timeout time.Duration) map[string]int {
+	t.Helper()
+	got := make(map[string]int)
+	deadline := time.After(timeout)
+	for {
+		done := true
+		for _, id := range ids {
+			done = done && got[id] >= n
+		}
+		if done {
+			return got
+		}
+		select {
+		case msg := <-c.Events():
+			if msg.Host != "fake" {
+				t.Errorf("StatsMsg.Host = %q, want fake", msg.Host)
+			}
+			got[msg.ID]++
+		case <-deadline:
+			t.Fatalf("received %v, want %d stats for each of %v", got, n, ids)
+		}
+	}
+}
+
+// streamed reports whether the controller streams the stats of the container.
+func streamed(c *Controller, id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.containers[id]
+	return ok
+}
+
+func TestSync(t *testing.T) {
+	e := fake.New()
+	e.AddImage("web:1", "")
+	var ids []string
+	for i := range 3 {
+		ids = append(ids, e.AddContainer(fmt.Sprintf("web%d", i), "web:1", true))
+	}
+
+	c := New(e)
+	c.Sync(ids)
+	receive(t, c, ids, 2, 5*time.Second)
+
+	c.Sync(ids[:1])
+	got := receive(t, c, ids[:1], 3, 5*time.Second)
+	for _, id := range ids[1:] {
+		// a sample read before the sync may still be reported
+		if got[id] > 1 {
+			t.Errorf("container %s got %d stats after being removed", id, got[id])
+		}
+		if streamed(c, id) {
+			t.Errorf("container %s still streamed after being removed", id)
+		}
+	}
+	c.Sync(nil)
+}
+
+func TestStreamRestart(t *testing.T) {
+	e := fake.New()
+	e.AddImage("web:1", "")
+	id := e.AddContainer("web", "web:1", true)
+
+	c := New(e)
+	c.AddContainer(id)
+	t.Cleanup(func() { c.RemoveContainer(id) })
+	receive(t, c, []string{id}, 1, 3*time.Second)
+
+	// the stream ends when the container stops, it is opened again until it restarts
+	if err := e.StopContainer(id); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * restartDelay)
+	for len(c.Events()) > 0 {
+		<-c.Events()
+	}
+	if !streamed(c, id) {
+		t.Fatal("container dropped once stopped")
+	}
+	if err := e.StartContainer(id); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, c, []string{id}, 1, 2*maxRestartDelay)
+}
+
+func TestStreamError(t *testing.T) {
+	e := fake.New()
+	e.AddImage("web:1", "")
+	id := e.AddContainer("web", "web:1", true)
+	e.FailOn(fake.OpContainerStats, errors.New("connection reset"))
+
+	c := New(e)
+	c.AddContainer(id)
+	t.Cleanup(func() { c.RemoveContainer(id) })
+	time.Sleep(restartDelay + restartDelay/2)
+	if !streamed(c, id) {
+		t.Fatal("container dropped after a stream error")
+	}
+
+	e.FailOn(fake.OpContainerStats, nil)
+	receive(t, c, []string{id}, 1, 4*restartDelay)
+}
+
+func TestStreamRemovedContainer(t *testing.T) {
+	e := fake.New()
+	e.AddImage("web:1", "")
+	id := e.AddContainer("web", "web:1", false)
+	if err := e.DeleteContainer(id); err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(e)
+	c.AddContainer(id)
+	deadline := time.Now().Add(time.Second)
+	for streamed(c, id) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if streamed(c, id) {
+		t.Error("streaming the stats of a removed container")
+	}
+}
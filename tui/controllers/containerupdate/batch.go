@@ -0,0 +1,402 @@
+package containerupdate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/compose"
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+// maxPulls is the number of images a batch pulls at the same time.
+const maxPulls = 4
+
+// Parallelism is the number of containers a batch recreates at the same time by default.
+var Parallelism = 1
+
+// ErrBatchCycle is returned when the containers of a batch depend on each other.
+var ErrBatchCycle = errors.New("dependency cycle between containers")
+
+// JobState is the state of the update of a container in a batch.
+type JobState string
+
+const (
+	JobPending    JobState = "pending"
+	JobPulling    JobState = "pulling"
+	JobWaiting    JobState = "waiting"
+	JobUpdating   JobState = "updating"
+	JobUpdated    JobState = "updated"
+	JobRolledBack JobState = "rolled back"
+	JobFailed     JobState = "failed"
+	JobSkipped    JobState = "skipped"
+)
+
+// Done reports whether the update of the container is over.
+func (s JobState) Done() bool {
+	switch s {
+	case JobUpdated, JobRolledBack, JobFailed, JobSkipped:
+		return true
+	}
+	return false
+}
+
+// Target is a container to update in a batch, with the client of its host.
+type Target struct {
+	Client    client.Client
+	Container types.Container
+}
+
+// Job is the update of a container in a batch.
+type Job struct {
+	Host      string
+	Container types.Container
+	DependsOn []string // DependsOn are the names of the containers of the batch updated before this one.
+	State     JobState
+	Step      string // Step is the step in progress, or the progress of the pull.
+	Err       error
+	Downtime  time.Duration // Downtime is the time the container name had no running container.
+	Started   time.Time
+	Finished  time.Time
+
+	cli    client.Client
+	deps   []int       // deps are the indexes of the jobs updated before this one.
+	pulled *pullResult // pulled is the pull of the image of the container.
+}
+
+// Name returns the name of the updated container.
+func (j Job) Name() string {
+	return strings.TrimPrefix(j.Container.Name, "/")
+}
+
+// Image returns the image the container is updated to.
+func (j Job) Image() string {
+	return j.Container.Config.Image
+}
+
+// Duration returns the time spent recreating the container, up to now while in progress.
+func (j Job) Duration() time.Duration {
+	switch {
+	case j.Started.IsZero():
+		return 0
+	case j.Finished.IsZero():
+		return time.Since(j.Started)
+	}
+	return j.Finished.Sub(j.Started)
+}
+
+// Batch updates several containers: their images are pulled concurrently,
+// then the containers are recreated, a container only once the containers it
+// depends on are updated. A container is skipped when one of them could not be.
+type Batch struct {
+	m          sync.RWMutex
+	jobs       []*Job
+	updateChan chan ControllerUpdateMsg
+}
+
+// NewBatch returns a batch updating the targets, ordered so that a container
+// comes after the containers it depends on: the containers of the compose
+// services its service depends on, and the container whose network stack it
+// joins with network_mode container:. Independent containers are sorted by
+// host and name. ErrBatchCycle is returned if the dependencies cannot be satisfied.
+func NewBatch(targets []Target) (*Batch, error) {
+	jobs := make([]*Job, len(targets))
+	for i, t := range targets {
+		jobs[i] = &Job{Host: t.Client.Endpoint().Name(), Container: t.Container, State: JobPending, cli: t.Client}
+	}
+	jobs, err := orderJobs(jobs)
+	if err != nil {
+		return nil, err
+	}
+	return &Batch{jobs: jobs, updateChan: make(chan ControllerUpdateMsg, 10)}, nil
+}
+
+// dependsOn reports whether the container of a is updated after the container of b.
+func dependsOn(a, b *Job) bool {
+	if a.Host != b.Host || a == b {
+		return false
+	}
+	if project := compose.Project(a.Container); project != "" && project == compose.Project(b.Container) {
+		for _, dep := range compose.DependsOn(a.Container) {
+			if dep.Service == compose.Service(b.Container) {
+				return true
+			}
+		}
+	}
+	if a.Container.HostConfig != nil && a.Container.HostConfig.NetworkMode.IsContainer() {
+		peer := a.Container.HostConfig.NetworkMode.ConnectedContainer()
+		return peer == b.Name() || (peer != "" && strings.HasPrefix(b.Container.ID, peer))
+	}
+	return false
+}
+
+// orderJobs sorts the jobs so that their dependencies come first and sets them.
+func orderJobs(jobs []*Job) ([]*Job, error) {
+	compare := func(a, b *Job) int {
+		if r := strings.Compare(a.Host, b.Host); r != 0 {
+			return r
+		}
+		return strings.Compare(a.Name(), b.Name())
+	}
+	slices.SortFunc(jobs, compare)
+
+	out := make([]*Job, 0, len(jobs))
+	done := make(map[*Job]bool, len(jobs))
+	for len(out) < len(jobs) {
+		// the first job whose dependencies are all placed comes next
+		next := -1
+		for i, j := range jobs {
+			if done[j] {
+				continue
+			}
+			if !slices.ContainsFunc(jobs, func(d *Job) bool { return !done[d] && dependsOn(j, d) }) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var blocked []string
+			for _, j := range jobs {
+				if !done[j] {
+					blocked = append(blocked, j.Name())
+				}
+			}
+			return nil, fmt.Errorf("%w: %s", ErrBatchCycle, strings.Join(blocked, ", "))
+		}
+		done[jobs[next]] = true
+		out = append(out, jobs[next])
+	}
+
+	for i, j := range out {
+		for k, d := range out[:i] {
+			if dependsOn(j, d) {
+				j.deps = append(j.deps, k)
+				j.DependsOn = append(j.DependsOn, d.Name())
+			}
+		}
+	}
+	return out, nil
+}
+
+func (b *Batch) Events() <-chan ControllerUpdateMsg {
+	return b.updateChan
+}
+
+// Jobs returns a copy of the jobs of the batch, in update order.
+func (b *Batch) Jobs() []Job {
+	b.m.RLock()
+	defer b.m.RUnlock()
+	out := make([]Job, len(b.jobs))
+	for i, j := range b.jobs {
+		out[i] = *j
+	}
+	return out
+}
+
+// Start pulls the images, then recreates up to parallelism containers at the same time.
+// The events channel is closed once all the containers are done.
+func (b *Batch) Start(parallelism int) {
+	go func() {
+		defer close(b.updateChan)
+		b.pull()
+		b.recreate(max(parallelism, 1))
+	}()
+}
+
+// pull pulls the images of the jobs, maxPulls at the same time. The containers
+// whose image could not be pulled are recorded as failed updates.
+func (b *Batch) pull() {
+	groups := make(map[string][]int)
+	var keys []string
+	for i, j := range b.jobs {
+		k := j.Host + "/" + j.Image()
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], i)
+	}
+
+	sem := make(chan struct{}, maxPulls)
+	var wg sync.WaitGroup
+	for _, k := range keys {
+		jobs := groups[k]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			b.setState(jobs, JobPulling, "pulling")
+			first := b.jobs[jobs[0]]
+			progress := &pullProgress{layers: make(map[string]bool)}
+			pulled := pullImage(first.cli, first.Image(), func(layer, status, p string) {
+				b.setState(jobs, JobPulling, progress.update(layer, status))
+			})
+			b.m.Lock()
+			for _, i := range jobs {
+				b.jobs[i].pulled = pulled
+			}
+			b.m.Unlock()
+			if pulled.err == nil {
+				b.setState(jobs, JobWaiting, "")
+				return
+			}
+			for _, i := range jobs {
+				j := b.jobs[i]
+				b.finish(i, run(j.cli, j.Container, &jobReporter{b: b, index: i}, pulled))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// recreate recreates the containers in order, up to parallelism at the same time.
+func (b *Batch) recreate(parallelism int) {
+	finished := make(chan struct{}, len(b.jobs))
+	running := 0
+	for {
+		for running < parallelism {
+			i := b.next()
+			if i < 0 {
+				break
+			}
+			running++
+			go func() {
+				j := b.jobs[i]
+				b.finish(i, run(j.cli, j.Container, &jobReporter{b: b, index: i}, j.pulled))
+				finished <- struct{}{}
+			}()
+		}
+		if running == 0 {
+			return
+		}
+		<-finished
+		running--
+	}
+}
+
+// next starts the first waiting job whose dependencies are updated and returns
+// its index, or -1 when none can start yet. The jobs whose dependency was not
+// updated are skipped.
+func (b *Batch) next() int {
+	b.m.Lock()
+	skipped := false
+	defer func() {
+		b.m.Unlock()
+		if skipped {
+			b.updateChan <- ControllerUpdateMsg{}
+		}
+	}()
+
+	// the dependencies come first, so a skipped job is seen by its dependents
+	for i, j := range b.jobs {
+		if j.State != JobWaiting {
+			continue
+		}
+		ready := true
+		for _, d := range j.deps {
+			dep := b.jobs[d]
+			if dep.State.Done() && dep.State != JobUpdated {
+				j.State, j.Step = JobSkipped, ""
+				j.Err = fmt.Errorf("%s was not updated", dep.Name())
+				skipped = true
+				break
+			}
+			ready = ready && dep.State == JobUpdated
+		}
+		if j.State == JobWaiting && ready {
+			j.State, j.Started = JobUpdating, time.Now()
+			return i
+		}
+	}
+	return -1
+}
+
+// setState sets the state and the step of the given jobs.
+func (b *Batch) setState(jobs []int, state JobState, step string) {
+	b.m.Lock()
+	for _, i := range jobs {
+		b.jobs[i].State, b.jobs[i].Step = state, step
+	}
+	b.m.Unlock()
+	b.updateChan <- ControllerUpdateMsg{}
+}
+
+// finish sets the result of the update of a job.
+func (b *Batch) finish(i int, err error) {
+	b.m.Lock()
+	j := b.jobs[i]
+	j.Finished, j.Step, j.Err = time.Now(), "", err
+	if j.Started.IsZero() {
+		j.Started = j.Finished
+	}
+	switch {
+	case err == nil:
+		j.State = JobUpdated
+	case errors.Is(err, ErrRolledBack):
+		j.State = JobRolledBack
+	default:
+		j.State = JobFailed
+	}
+	b.m.Unlock()
+	b.updateChan <- ControllerUpdateMsg{}
+}
+
+// pullProgress summarizes the progress of a pull as the number of layers pulled.
+type pullProgress struct {
+	order  []string
+	layers map[string]bool // layers tells, for each layer, whether it is pulled.
+}
+
+func (p *pullProgress) update(layer, status string) string {
+	// the messages about the whole image are reported with its tag as layer
+	if !strings.HasPrefix(status, "Pulling from") {
+		if _, ok := p.layers[layer]; !ok {
+			p.order = append(p.order, layer)
+		}
+		p.layers[layer] = status == "Pull complete" || status == "Already exists"
+	}
+	pulled := 0
+	for _, done := range p.layers {
+		if done {
+			pulled++
+		}
+	}
+	return fmt.Sprintf("pulling, %d/%d layers", pulled, len(p.order))
+}
+
+// jobReporter reports the steps of the update of a job as its current step.
+type jobReporter struct {
+	b     *Batch
+	index int
+}
+
+func (r *jobReporter) Pull(layer, status, progress string) {}
+
+func (r *jobReporter) Step(label, name string) func(err error) {
+	r.setStep(label)
+	return func(err error) {}
+}
+
+func (r *jobReporter) Error(text string) {}
+
+func (r *jobReporter) Warning(text string) {
+	r.setStep(text)
+}
+
+func (r *jobReporter) Downtime(d time.Duration) {
+	r.b.m.Lock()
+	r.b.jobs[r.index].Downtime = d
+	r.b.m.Unlock()
+}
+
+func (r *jobReporter) setStep(step string) {
+	r.b.m.Lock()
+	r.b.jobs[r.index].Step = step
+	r.b.m.Unlock()
+	r.b.updateChan <- ControllerUpdateMsg{}
+}
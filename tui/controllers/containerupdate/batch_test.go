@@ -0,0 +1,218 @@
+package containerupdate
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/kernaxis/gmd/docker/client/fake"
+	"github.com/kernaxis/gmd/docker/compose"
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+// testJob returns the pending job of the container with the given host, ID, name and network mode.
+// The labels are the compose labels service=depends_on of the project app, when service is not empty.
+func testJob(host, id, name, mode, service, dependsOn string) *Job {
+	c := container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			ID:         id,
+			Name:       "/" + name,
+			HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode(mode)},
+		},
+		Config: &container.Config{Image: name + ":1"},
+	}
+	if service != "" {
+		c.Config.Labels = map[string]string{
+			compose.ProjectLabel:   "app",
+			compose.ServiceLabel:   service,
+			compose.DependsOnLabel: dependsOn,
+		}
+	}
+	return &Job{Host: host, Container: types.Container{InspectResponse: c}, State: JobPending}
+}
+
+func TestOrderJobs(t *testing.T) {
+	tests := []struct {
+		name      string
+		jobs      []*Job
+		want      []string // want are the jobs in order, as name<-dependencies
+		wantCycle bool
+	}{
+		{
+			name: "independent",
+			jobs: []*Job{
+				testJob("remote", "1", "a", "bridge", "", ""),
+				testJob("local", "2", "b", "bridge", "", ""),
+				testJob("local", "3", "a", "bridge", "", ""),
+			},
+			want: []string{"a<-", "b<-", "a<-"},
+		},
+		{
+			name: "depends_on chain",
+			jobs: []*Job{
+				testJob("local", "1", "app-web-1", "bridge", "web", "api:service_started:false"),
+				testJob("local", "2", "app-api-1", "bridge", "api", "db:service_healthy:false"),
+				testJob("local", "3", "app-db-1", "bridge", "db", ""),
+			},
+			want: []string{"app-db-1<-", "app-api-1<-app-db-1", "app-web-1<-app-api-1"},
+		},
+		{
+			name: "depends_on in another project",
+			jobs: []*Job{
+				testJob("local", "1", "a", "bridge", "web", "db"),
+				func() *Job {
+					j := testJob("local", "2", "b", "bridge", "db", "")
+					j.Container.Config.Labels[compose.ProjectLabel] = "other"
+					return j
+				}(),
+			},
+			want: []string{"a<-", "b<-"},
+		},
+		{
+			name: "network_mode container by name",
+			jobs: []*Job{
+				testJob("local", "1", "a-web", "container:vpn", "", ""),
+				testJob("local", "2", "vpn", "bridge", "", ""),
+			},
+			want: []string{"vpn<-", "a-web<-vpn"},
+		},
+		{
+			name: "network_mode container by ID",
+			jobs: []*Job{
+				testJob("local", "1", "a-web", "container:2f0c", "", ""),
+				testJob("local", "2f0c9a7e", "vpn", "bridge", "", ""),
+			},
+			want: []string{"vpn<-", "a-web<-vpn"},
+		},
+		{
+			name: "network_mode container on another host",
+			jobs: []*Job{
+				testJob("local", "1", "a-web", "container:vpn", "", ""),
+				testJob("remote", "2", "vpn", "bridge", "", ""),
+			},
+			want: []string{"a-web<-", "vpn<-"},
+		},
+		{
+			name: "cycle",
+			jobs: []*Job{
+				testJob("local", "1", "app-a-1", "bridge", "a", "b"),
+				testJob("local", "2", "app-b-1", "container:app-a-1", "b", ""),
+				testJob("local", "3", "app-c-1", "bridge", "c", ""),
+			},
+			wantCycle: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := orderJobs(tt.jobs)
+			if tt.wantCycle {
+				if !errors.Is(err, ErrBatchCycle) {
+					t.Fatalf("orderJobs() = %v, want %v", err, ErrBatchCycle)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, j := range jobs {
+				got = append(got, j.Name()+"<-"+strings.Join(j.DependsOn, ","))
+				for k, d := range j.deps {
+					if jobs[d].Name() != j.DependsOn[k] {
+						t.Errorf("%s depends on the job %d, %s, want %s", j.Name(), d, jobs[d].Name(), j.DependsOn[k])
+					}
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("orderJobs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchNext(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []JobState // states are the states of the jobs vpn, web and proxy, each joining the network of the previous one
+		want   int
+		after  []JobState
+	}{
+		{"first ready", []JobState{JobWaiting, JobWaiting, JobWaiting}, 0, []JobState{JobUpdating, JobWaiting, JobWaiting}},
+		{"parent updating", []JobState{JobUpdating, JobWaiting, JobWaiting}, -1, []JobState{JobUpdating, JobWaiting, JobWaiting}},
+		{"parent pulling", []JobState{JobPulling, JobWaiting, JobWaiting}, -1, []JobState{JobPulling, JobWaiting, JobWaiting}},
+		{"parent updated", []JobState{JobUpdated, JobWaiting, JobWaiting}, 1, []JobState{JobUpdated, JobUpdating, JobWaiting}},
+		{"parent failed", []JobState{JobFailed, JobWaiting, JobWaiting}, -1, []JobState{JobFailed, JobSkipped, JobSkipped}},
+		{"parent rolled back", []JobState{JobRolledBack, JobWaiting, JobWaiting}, -1, []JobState{JobRolledBack, JobSkipped, JobSkipped}},
+		{"child failed", []JobState{JobUpdated, JobFailed, JobWaiting}, -1, []JobState{JobUpdated, JobFailed, JobSkipped}},
+		{"child pull failed", []JobState{JobWaiting, JobFailed, JobWaiting}, 0, []JobState{JobUpdating, JobFailed, JobWaiting}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := orderJobs([]*Job{
+				testJob("local", "1", "vpn", "bridge", "", ""),
+				testJob("local", "2", "web", "container:vpn", "", ""),
+				testJob("local", "3", "proxy", "container:web", "", ""),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, s := range tt.states {
+				jobs[i].State = s
+			}
+			b := &Batch{jobs: jobs, updateChan: make(chan ControllerUpdateMsg, 10)}
+
+			if got := b.next(); got != tt.want {
+				t.Errorf("next() = %d, want %d", got, tt.want)
+			}
+			for i, j := range b.Jobs() {
+				if j.State != tt.after[i] {
+					t.Errorf("%s is %s, want %s", j.Name(), j.State, tt.after[i])
+				}
+				if j.State == JobSkipped && j.Err == nil {
+					t.Errorf("%s is skipped without error", j.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestBatchSkipsDependentsOfFailedUpdate(t *testing.T) {
+	setup(t)
+	e := fake.New()
+	e.AddImage("vpn:1", "sha256:v1")
+	e.AddImage("web:1", "sha256:w1")
+	e.AddImage("db:1", "sha256:d1")
+	e.SetRemoteDigest("vpn:1", "sha256:v2")
+	e.SetRemoteDigest("web:1", "sha256:w2")
+	e.SetRemoteDigest("db:1", "sha256:d2")
+	e.SetImageHealth("sha256:v2", container.Unhealthy)
+	e.AddContainer("vpn", "vpn:1", true)
+	e.AddContainer("db", "db:1", true)
+	addDependent(t, e, "web", "web:1", "vpn")
+
+	var targets []Target
+	for _, name := range []string{"web", "db", "vpn"} {
+		targets = append(targets, Target{Client: e, Container: inspect(t, e, name)})
+	}
+	b, err := NewBatch(targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Start(2)
+	for range b.Events() {
+	}
+
+	want := map[string]JobState{"db": JobUpdated, "vpn": JobRolledBack, "web": JobSkipped}
+	for _, j := range b.Jobs() {
+		if j.State != want[j.Name()] {
+			t.Errorf("%s is %s (%v), want %s", j.Name(), j.State, j.Err, want[j.Name()])
+		}
+	}
+	for _, name := range []string{"vpn", "web", "db"} {
+		if !running(e, name) {
+			t.Errorf("%s is not running", name)
+		}
+	}
+}
@@ -1,38 +1,29 @@
 package containerupdate
 
 import (
-	"context"
 	"fmt"
-	"log"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/docker/docker/api/types/container"
 	"github.com/kernaxis/gmd/docker/client"
 	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
 	style "github.com/kernaxis/gmd/tui/styles"
 )
 
 type ControllerUpdateMsg struct {
 }
 
-type createResponse struct {
-	Resp container.CreateResponse
-	Err  error
-}
-
 type Controller struct {
 	m          sync.RWMutex
-	cli        *client.Client
+	cli        client.Client
 	updateChan chan ControllerUpdateMsg
 
-	order  []string
-	layers map[string]string
-	lines  []string
+	lines []string
 }
 
-func New(client *client.Client) *Controller {
+func New(client client.Client) *Controller {
 	c := Controller{
 		cli:        client,
 		updateChan: make(chan ControllerUpdateMsg, 10),
@@ -51,222 +42,171 @@ func (c *Controller) GetLines() []string {
 }
 
 func (c *Controller) StartUpdate(container types.Container) {
-	c.order = []string{}
-	c.layers = make(map[string]string)
-	go c.updateContainer(container)
+	c.StartUpdates([]types.Container{container})
 }
 
-func (c *Controller) updateContainer(container types.Container) {
-
-	containerName := strings.TrimPrefix(container.Name, "/")
-
-	done := make(chan error)
-	defer close(done)
-
-	err := c.cli.PullImageWithProgress(context.Background(), container.Config.Image, func(msg map[string]interface{}) {
-		var ok bool
-		var status, layerId string
-
-		if status, ok = msg["status"].(string); !ok {
-			return
+// StartUpdates updates the given containers one after the other, in the given order.
+// The update stops at the first failure, so a container is never updated
+// while one it depends on could not be.
+func (c *Controller) StartUpdates(containers []types.Container) {
+	go func() {
+		defer close(c.updateChan)
+
+		for i, container := range containers {
+			if len(containers) > 1 {
+				c.m.Lock()
+				if i > 0 {
+					c.lines = append(c.lines, "")
+				}
+				c.lines = append(c.lines, style.Title().Render(fmt.Sprintf("[%d/%d] %s", i+1, len(containers), strings.TrimPrefix(container.Name, "/"))))
+				c.m.Unlock()
+			}
+
+			if err := Update(c.cli, container, c.newLineReporter()); err != nil {
+				c.m.Lock()
+				c.lines = append(c.lines, "update failed, press enter to close...")
+				c.m.Unlock()
+				return
+			}
 		}
 
-		if layerId, ok = msg["id"].(string); !ok {
-			return
-		}
+		c.m.Lock()
+		c.lines = append(c.lines, "update complete, press enter to close...")
+		c.m.Unlock()
+	}()
+}
 
-		if layerId == "" {
-			layerId = fmt.Sprintf("general-%d", len(c.layers)) // évite collision
-		}
+// StartRollback recreates the container on its previous image.
+func (c *Controller) StartRollback(container types.Container, previous history.PreviousImage) {
+	go func() {
+		defer close(c.updateChan)
 
-		line := status
-		if progress, ok := msg["progress"].(string); ok {
-			line += " " + progress
+		line := "rollback complete, press enter to close..."
+		if err := Rollback(c.cli, container, previous, c.newLineReporter()); err != nil {
+			line = "rollback failed, press enter to close..."
 		}
-
 		c.m.Lock()
-		if _, exists := c.layers[layerId]; !exists {
-			c.order = append(c.order, layerId) // première fois qu’on voit ce layer
-		}
-		c.layers[layerId] = line
-
-		c.lines = c.lines[:0]
-		for _, id := range c.order {
-			c.lines = append(c.lines, c.layers[id])
-		}
+		c.lines = append(c.lines, line)
 		c.m.Unlock()
+	}()
+}
 
-		log.Printf("line: %s", line)
-
-		c.updateChan <- ControllerUpdateMsg{}
-	})
+// lineReporter renders the progress of the update of a container as the lines of the controller.
+type lineReporter struct {
+	c      *Controller
+	start  int               // start is the index of the first line of the pull progress.
+	order  []string          // order holds the layer IDs in the order they were first seen.
+	layers map[string]string // layers is a map of layer IDs to their last status.
+}
 
-	if err != nil {
-		log.Printf("Error pull for image %s : %v", container.Config.Image, err)
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error pull image: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
+func (c *Controller) newLineReporter() *lineReporter {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return &lineReporter{
+		c:      c,
+		start:  len(c.lines),
+		layers: make(map[string]string),
 	}
+}
 
-	containerConfig, err := c.cli.ContainerInspect(container.ID)
-	if err != nil {
-		log.Printf("Error get config for container %s : %v", container.ID, err)
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error get config: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
+func (r *lineReporter) Pull(layer, status, progress string) {
+	if layer == "" {
+		layer = fmt.Sprintf("general-%d", len(r.layers)) // évite collision
 	}
 
-	add := true
-	err = spinUntilDone(func() error {
-		return c.cli.StopContainer(container.ID)
-	}, func(frame string) {
-		c.m.Lock()
-		if add {
-			c.lines = append(c.lines, fmt.Sprintf("%s Stoping container: %s", frame, containerName))
-			add = false
-		} else {
-			c.lines[len(c.lines)-1] = fmt.Sprintf("%s Stoping container: %s", frame, containerName)
-		}
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-	})
-
-	if err != nil {
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error stop: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
+	line := status
+	if progress != "" {
+		line += " " + progress
 	}
 
+	c := r.c
 	c.m.Lock()
-	c.lines[len(c.lines)-1] = fmt.Sprintf("%s Stoping container: %s", style.Success().Render("✓"), containerName)
-	c.m.Unlock()
-	c.updateChan <- ControllerUpdateMsg{}
-
-	add = true
-	err = spinUntilDone(func() error {
-		return c.cli.DeleteContainer(container.ID)
-	}, func(frame string) {
-		c.m.Lock()
-		if add {
-			c.lines = append(c.lines, fmt.Sprintf("%s Removing container: %s", frame, containerName))
-			add = false
-		} else {
-			c.lines[len(c.lines)-1] = fmt.Sprintf("%s Removing container: %s", frame, containerName)
-		}
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-	})
-
-	if err != nil {
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error remove: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
+	if _, exists := r.layers[layer]; !exists {
+		r.order = append(r.order, layer) // première fois qu’on voit ce layer
 	}
+	r.layers[layer] = line
 
-	c.m.Lock()
-	c.lines[len(c.lines)-1] = fmt.Sprintf("%s Removing container: %s", style.Success().Render("✓"), containerName)
+	c.lines = c.lines[:r.start]
+	for _, id := range r.order {
+		c.lines = append(c.lines, r.layers[id])
+	}
 	c.m.Unlock()
+
 	c.updateChan <- ControllerUpdateMsg{}
+}
 
-	add = true
-	cr := spinUntilDone(func() createResponse {
-		r, e := c.cli.CreateContainerFromConfig(containerConfig)
-		return createResponse{Resp: r, Err: e}
-	}, func(frame string) {
-		c.m.Lock()
-		if add {
-			c.lines = append(c.lines, fmt.Sprintf("%s Creating container: %s", frame, containerName))
-			add = false
-		} else {
-			c.lines[len(c.lines)-1] = fmt.Sprintf("%s Creating container: %s", frame, containerName)
-		}
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-	})
+// Step adds a line with a spinner, marked as done or failed when the step ends.
+func (r *lineReporter) Step(label, name string) func(err error) {
+	c := r.c
+	index := c.addLine(fmt.Sprintf("%s %s: %s", style.Spinner().Render(spinnerFrames[0]), label, name))
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		spinUntilDone(stop, func(frame string) {
+			c.setLine(index, fmt.Sprintf("%s %s: %s", frame, label, name))
+		})
+	}()
 
-	err = cr.Err
+	return func(err error) {
+		close(stop)
+		<-stopped
 
-	if err != nil {
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error create: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
+		mark := style.Success().Render("✓")
+		if err != nil {
+			mark = style.Danger().Render("✗")
+		}
+		c.setLine(index, fmt.Sprintf("%s %s: %s", mark, label, name))
 	}
+}
 
-	c.m.Lock()
-	c.lines[len(c.lines)-1] = fmt.Sprintf("%s Creating container: %s", style.Success().Render("✓"), containerName)
-	c.m.Unlock()
-	c.updateChan <- ControllerUpdateMsg{}
+func (r *lineReporter) Error(text string) {
+	r.c.addLine(text)
+}
 
-	add = true
-	err = spinUntilDone(func() error {
-		return c.cli.StartContainer(cr.Resp.ID)
-	}, func(frame string) {
-		c.m.Lock()
-		if add {
-			c.lines = append(c.lines, fmt.Sprintf("%s Starting container: %s", frame, containerName))
-			add = false
-		} else {
-			c.lines[len(c.lines)-1] = fmt.Sprintf("%s Starting container: %s", frame, containerName)
-		}
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-	})
+func (r *lineReporter) Warning(text string) {
+	r.c.addLine(style.Warning().Render(text))
+}
 
-	if err != nil {
-		c.m.Lock()
-		c.lines = append(c.lines, fmt.Sprintf("Error start: %v", err))
-		c.m.Unlock()
-		c.updateChan <- ControllerUpdateMsg{}
-		return
-	}
+func (r *lineReporter) Downtime(d time.Duration) {
+	r.c.addLine(style.Success().Render(fmt.Sprintf("Downtime: %s", d.Round(time.Millisecond))))
+}
 
+// addLine appends a line to the update log and returns its index.
+func (c *Controller) addLine(line string) int {
 	c.m.Lock()
-	c.lines[len(c.lines)-1] = fmt.Sprintf("%s Starting container: %s", style.Success().Render("✓"), containerName)
+	c.lines = append(c.lines, line)
+	index := len(c.lines) - 1
 	c.m.Unlock()
 	c.updateChan <- ControllerUpdateMsg{}
+	return index
+}
 
+// setLine replaces the line with the given index.
+func (c *Controller) setLine(index int, line string) {
 	c.m.Lock()
-	c.lines = append(c.lines, "update complete, press enter to close...")
+	c.lines[index] = line
 	c.m.Unlock()
-
-	close(c.updateChan)
+	c.updateChan <- ControllerUpdateMsg{}
 }
 
-func spinUntilDone[T any](
-	action func() T,
-	updateLine func(frame string),
-) T {
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 
-	done := make(chan T)
-
-	// Lance l’action en arrière-plan
-	go func() {
-		done <- action()
-	}()
-
-	spinner := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
-	index := 0
+// spinUntilDone renders the frames of a spinner until stop is closed.
+func spinUntilDone(stop <-chan struct{}, updateLine func(frame string)) {
+	index := 1
 
 	for {
 		select {
-		case r := <-done:
+		case <-stop:
 			// Terminé
-			return r
+			return
 
 		case <-time.After(100 * time.Millisecond):
 			// Frame suivante
-			frame := spinner[index]
-			index = (index + 1) % len(spinner)
+			frame := spinnerFrames[index]
+			index = (index + 1) % len(spinnerFrames)
 
 			updateLine(style.Spinner().Render(frame))
 		}
@@ -0,0 +1,156 @@
+package containerupdate
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// dependents returns the containers joining the network stack of the
+// container with the given ID and name (network_mode: container:), sorted by name.
+func (u updater) dependents(id, name string) ([]container.InspectResponse, error) {
+	list, err := u.cli.ContainerList()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []container.InspectResponse
+	for _, s := range list {
+		mode := container.NetworkMode(s.HostConfig.NetworkMode)
+		if !mode.IsContainer() || !joins(mode, id, name) {
+			continue
+		}
+		c, err := u.cli.ContainerInspect(s.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	slices.SortFunc(out, func(a, b container.InspectResponse) int { return strings.Compare(a.Name, b.Name) })
+	return out, nil
+}
+
+// joins reports whether the network mode joins the network stack of the container with the given ID and name.
+func joins(mode container.NetworkMode, id, name string) bool {
+	peer := mode.ConnectedContainer()
+	return peer != "" && (peer == name || strings.HasPrefix(id, peer))
+}
+
+// recreateDependents recreates the containers joining the network stack of
+// the previous container, with the given ID and name, so that they join the
+// one of the new container: their network stack went away with the previous
+// container. The containers joining the recreated ones are recreated in turn.
+// A failure is reported without rolling back the update, and the names of the
+// dependents left joining the previous container are returned: it must be kept.
+func (u updater) recreateDependents(oldID, newID, name string) []string {
+	dependents, err := u.dependents(oldID, name)
+	if err != nil {
+		log.Printf("unable to list the containers joining the network of %s: %v", name, err)
+		u.reporter.Error(fmt.Sprintf("Error list dependents: %v", err))
+		return nil
+	}
+
+	var kept []string
+	for _, d := range dependents {
+		// a dependent joining by name keeps doing so, the name is the one of the new container now
+		peer := name
+		if d.HostConfig.NetworkMode.ConnectedContainer() != name {
+			peer = newID
+		}
+		if err := u.recreateDependent(d, peer); err != nil {
+			u.reporter.Error(fmt.Sprintf("Error recreate %s: %v", strings.TrimPrefix(d.Name, "/"), err))
+			kept = append(kept, strings.TrimPrefix(d.Name, "/"))
+		}
+	}
+	return kept
+}
+
+// recreateDependent recreates the container joining the network stack of
+// peer, then the containers joining its own. The previous container is
+// restored, and restarted if it was running, when the new one cannot be
+// created or started. It is kept while a container still joins it.
+func (u updater) recreateDependent(c container.InspectResponse, peer string) error {
+	name := strings.TrimPrefix(c.Name, "/")
+	backupName := name + backupSuffix
+	wasRunning := c.State != nil && c.State.Running
+
+	hostConfig := *c.HostConfig
+	hostConfig.NetworkMode = container.NetworkMode("container:" + peer)
+	base := *c.ContainerJSONBase
+	base.HostConfig = &hostConfig
+	recreated := c
+	recreated.ContainerJSONBase = &base
+
+	if wasRunning {
+		if err := u.step("Stoping dependent container", name, func() error {
+			return u.cli.StopContainer(c.ID)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := u.step("Renaming container", fmt.Sprintf("%s → %s", name, backupName), func() error {
+		return u.cli.RenameContainer(c.ID, backupName)
+	}); err != nil {
+		if wasRunning {
+			u.step("Restarting container", name, func() error {
+				return u.cli.StartContainer(c.ID)
+			})
+		}
+		return err
+	}
+
+	var newID string
+	err := u.step("Recreating dependent container", name, func() error {
+		r, err := u.cli.CreateContainerFromConfig(recreated)
+		newID = r.ID
+		return err
+	})
+	if err == nil && wasRunning {
+		err = u.step("Starting container", name, func() error {
+			return u.cli.StartContainer(newID)
+		})
+	}
+	if err != nil {
+		if err := u.step("Restoring container", name, func() error {
+			if newID != "" {
+				if err := u.cli.StopContainer(newID); err != nil {
+					return err
+				}
+				if err := u.cli.DeleteContainer(newID); err != nil {
+					return err
+				}
+			}
+			if err := u.cli.RenameContainer(c.ID, name); err != nil {
+				return err
+			}
+			if wasRunning {
+				return u.cli.StartContainer(c.ID)
+			}
+			return nil
+		}); err != nil {
+			u.reporter.Error(fmt.Sprintf("Error rollback: %v", err))
+		}
+		return err
+	}
+
+	if kept := u.recreateDependents(c.ID, newID, name); len(kept) > 0 {
+		u.keepPrevious(backupName, kept)
+		return nil
+	}
+	if err := u.step("Removing previous container", backupName, func() error {
+		return u.cli.DeleteContainer(c.ID)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error remove: %v", err))
+	}
+	return nil
+}
+
+// keepPrevious reports the previous container, named backupName, is kept for
+// the dependents which still join its network stack.
+func (u updater) keepPrevious(backupName string, dependents []string) {
+	u.reporter.Warning(fmt.Sprintf("Keeping %s, %s still joining its network", backupName, strings.Join(dependents, ", ")))
+}
@@ -0,0 +1,104 @@
+package containerupdate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/kernaxis/gmd/docker/client/fake"
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+// addDependent adds a running container named name joining the network stack of peer.
+func addDependent(t *testing.T, e *fake.Engine, name, ref, peer string) {
+	t.Helper()
+	r, err := e.CreateContainerFromConfig(container.InspectResponse{
+		ContainerJSONBase: &container.ContainerJSONBase{
+			Name:       "/" + name,
+			HostConfig: &container.HostConfig{NetworkMode: container.NetworkMode("container:" + peer)},
+		},
+		Config: &container.Config{Image: ref},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.StartContainer(r.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateRecreatesDependents(t *testing.T) {
+	for _, strategy := range []types.UpdateStrategy{types.StrategyStopFirst, types.StrategyStartFirst} {
+		t.Run(string(strategy), func(t *testing.T) {
+			setup(t)
+			Strategy = strategy
+			e := fake.New()
+			e.AddImage("vpn:1", "sha256:v1")
+			e.SetRemoteDigest("vpn:1", "sha256:v2")
+			e.AddImage("web:1", "")
+			e.AddContainer("vpn", "vpn:1", true)
+			addDependent(t, e, "web", "web:1", "vpn")
+			addDependent(t, e, "proxy", "web:1", "web")
+
+			r := &testReporter{t: t}
+			if err := Update(e, inspect(t, e, "vpn"), r); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, name := range []string{"vpn", "web", "proxy"} {
+				if !running(e, name) {
+					t.Errorf("%s is not running", name)
+				}
+			}
+			if mode := inspect(t, e, "web").HostConfig.NetworkMode; mode != "container:vpn" {
+				t.Errorf("web joins %s, want container:vpn", mode)
+			}
+			if mode := inspect(t, e, "proxy").HostConfig.NetworkMode; mode != "container:web" {
+				t.Errorf("proxy joins %s, want container:web", mode)
+			}
+			list, _ := e.ContainerList()
+			if len(list) != 3 {
+				t.Errorf("%d containers left, want 3", len(list))
+			}
+		})
+	}
+}
+
+func TestUpdateKeepsPeerOfRestoredDependent(t *testing.T) {
+	for _, strategy := range []types.UpdateStrategy{types.StrategyStopFirst, types.StrategyStartFirst} {
+		t.Run(string(strategy), func(t *testing.T) {
+			setup(t)
+			Strategy = strategy
+			e := fake.New()
+			e.AddImage("vpn:1", "sha256:v1")
+			e.SetRemoteDigest("vpn:1", "sha256:v2")
+			e.AddImage("web:1", "")
+			oldID := e.AddContainer("vpn", "vpn:1", true)
+			addDependent(t, e, "web", "web:1", oldID)
+			// the vpn container is created, not its dependent
+			e.FailAfter(fake.OpContainerCreate, 1, errors.New("no space left"))
+
+			r := &testReporter{t: t}
+			if err := Update(e, inspect(t, e, "vpn"), r); err != nil {
+				t.Fatal(err)
+			}
+
+			if !running(e, "vpn") {
+				t.Error("vpn is not running")
+			}
+			web := inspect(t, e, "web")
+			if !web.State.Running {
+				t.Error("the restored web is not running")
+			}
+			if mode := web.HostConfig.NetworkMode; mode != container.NetworkMode("container:"+oldID) {
+				t.Errorf("web joins %s, want the previous vpn", mode)
+			}
+			if _, err := e.ContainerInspect(oldID); err != nil {
+				t.Errorf("the previous vpn joined by web was removed: %v", err)
+			}
+			if len(r.warnings) == 0 {
+				t.Error("keeping the previous vpn is not reported")
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package containerupdate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
+)
+
+// backupSuffix is appended to the name of the container kept during an update.
+const backupSuffix = "-gmd-backup"
+
+// healthPollInterval is the delay between two inspections of the updated container.
+const healthPollInterval = 500 * time.Millisecond
+
+var (
+	// StartPeriod is how long the updated container must keep running when it has no healthcheck.
+	StartPeriod = 3 * time.Second
+	// HealthTimeout is how long the updated container has to become healthy when it has a healthcheck.
+	HealthTimeout = 2 * time.Minute
+	// History records the updates, nil to not record them.
+	History *history.Store
+	// Source tells which part of gmd runs the updates in their history records.
+	Source = "tui"
+)
+
+// Reporter receives the progress of a container update.
+// The TUI renders it as lines, the headless commands print it as plain text.
+type Reporter interface {
+	// Pull reports the status of a layer of the pulled image.
+	// The layer is empty for the messages about the whole image.
+	Pull(layer, status, progress string)
+	// Step reports the start of a step and returns the function reporting its end.
+	Step(label, name string) func(err error)
+	// Error reports the error stopping the update.
+	Error(text string)
+	// Warning reports a rollback, or a problem which does not fail the update.
+	Warning(text string)
+	// Downtime reports the time the container name had no running container, once the update succeeded.
+	Downtime(d time.Duration)
+}
+
+// updater runs the update pipeline of a container.
+type updater struct {
+	cli      client.Client
+	reporter Reporter
+	record   *history.Record // record is the history record of the update.
+}
+
+// WithImage returns a copy of the container whose image reference is image,
+// to update the container to another tag of its image.
+func WithImage(c types.Container, image string) types.Container {
+	if c.Config != nil {
+		config := *c.Config
+		config.Image = image
+		c.Config = &config
+	}
+	return c
+}
+
+// ErrRolledBack is returned when a failed update restored the previous container.
+var ErrRolledBack = errors.New("update rolled back")
+
+// Update pulls the image of the container and recreates the container from it.
+// The previous container is kept until the new one is running and healthy,
+// and restored when the update fails.
+// The update is recorded in History.
+func Update(cli client.Client, c types.Container, reporter Reporter) error {
+	return run(cli, c, reporter, nil)
+}
+
+// pullResult is the pull of an image done before the update of its containers.
+type pullResult struct {
+	step history.Step
+	err  error
+}
+
+// run runs the update of the container and records it in History.
+// The image is pulled first, unless pulled is the result of its pull already done.
+func run(cli client.Client, c types.Container, reporter Reporter, pulled *pullResult) error {
+	record := &history.Record{
+		Started:        time.Now(),
+		Host:           cli.Endpoint().Name(),
+		Container:      strings.TrimPrefix(c.Name, "/"),
+		OldContainerID: c.ID,
+		Operator:       history.Operator(),
+		Source:         Source,
+	}
+	u := updater{cli: cli, reporter: &recorder{Reporter: reporter, record: record}, record: record}
+	err := u.update(c, pulled)
+	if err == nil {
+		u.prune()
+	}
+	u.save(err)
+	return err
+}
+
+func (u updater) update(c types.Container, pulled *pullResult) error {
+	containerName := strings.TrimPrefix(c.Name, "/")
+
+	containerConfig, err := u.cli.ContainerInspect(c.ID)
+	if errdefs.IsNotFound(err) && c.HostConfig != nil && c.HostConfig.NetworkMode.IsContainer() {
+		// the container was recreated with the container whose network it joins
+		if containerConfig, err = u.cli.ContainerInspect(containerName); err == nil {
+			u.record.OldContainerID = containerConfig.ID
+		}
+	}
+	if err != nil {
+		log.Printf("Error get config for container %s : %v", c.ID, err)
+		u.reporter.Error(fmt.Sprintf("Error get config: %v", err))
+		return err
+	}
+	u.record.Old = u.imageVersion(containerConfig.Config.Image, containerConfig.Image)
+
+	if pulled == nil {
+		pulled = pullImage(u.cli, c.Config.Image, u.reporter.Pull)
+	}
+	u.record.Steps = append(u.record.Steps, pulled.step)
+	if err := pulled.err; err != nil {
+		log.Printf("Error pull for image %s : %v", c.Config.Image, err)
+		u.reporter.Error(fmt.Sprintf("Error pull image: %v", err))
+		return err
+	}
+	u.record.New = u.imageVersion(c.Config.Image, "")
+
+	// the image reference changes when the container is updated to another tag
+	if containerConfig.Config.Image != c.Config.Image {
+		config := *containerConfig.Config
+		config.Image = c.Config.Image
+		containerConfig.Config = &config
+	}
+
+	strategy := u.strategy(containerConfig)
+	u.record.Strategy = string(strategy)
+	if strategy == types.StrategyStartFirst {
+		return u.startFirst(containerConfig.ID, containerName, containerConfig)
+	}
+	return u.stopFirst(containerConfig.ID, containerName, containerConfig)
+}
+
+// stopFirst stops the container, then creates and starts the new one.
+// The downtime lasts from the stop of the container to the start of the new one.
+func (u updater) stopFirst(id, containerName string, containerConfig container.InspectResponse) error {
+	// the old container is kept under a backup name until the new one is
+	// running and healthy, so that it can be restored if the update fails
+	backupName := containerName + backupSuffix
+	wasRunning := containerConfig.State != nil && containerConfig.State.Running
+
+	down := time.Now()
+	if err := u.step("Stoping container", containerName, func() error {
+		return u.cli.StopContainer(id)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error stop: %v", err))
+		return err
+	}
+
+	if err := u.step("Renaming container", fmt.Sprintf("%s → %s", containerName, backupName), func() error {
+		return u.cli.RenameContainer(id, backupName)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error rename: %v", err))
+		if wasRunning {
+			u.step("Restarting container", containerName, func() error {
+				return u.cli.StartContainer(id)
+			})
+		}
+		return err
+	}
+
+	var newID string
+	if err := u.step("Creating container", containerName, func() error {
+		r, err := u.cli.CreateContainerFromConfig(containerConfig)
+		newID = r.ID
+		u.record.NewContainerID = r.ID
+		return err
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error create: %v", err))
+		return u.rollback(id, "", containerName, wasRunning, err)
+	}
+
+	if err := u.step("Starting container", containerName, func() error {
+		return u.cli.StartContainer(newID)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error start: %v", err))
+		return u.rollback(id, newID, containerName, wasRunning, err)
+	}
+	downtime := time.Since(down)
+
+	if err := u.step("Checking health", containerName, func() error {
+		return u.waitHealthy(newID)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error check: %v", err))
+		return u.rollback(id, newID, containerName, wasRunning, err)
+	}
+
+	u.reporter.Downtime(downtime)
+	if kept := u.recreateDependents(id, newID, containerName); len(kept) > 0 {
+		u.keepPrevious(backupName, kept)
+		return nil
+	}
+
+	if err := u.step("Removing previous container", backupName, func() error {
+		return u.cli.DeleteContainer(id)
+	}); err != nil {
+		// the update itself succeeded, the backup is left for the user to remove
+		u.reporter.Error(fmt.Sprintf("Error remove: %v", err))
+	}
+
+	return nil
+}
+
+// pullImage pulls the image, reporting its progress layer by layer.
+// The pull is only recorded as a step.
+func pullImage(cli client.Client, image string, progress func(layer, status, progress string)) *pullResult {
+	step := history.Step{Label: "Pulling image", Target: image, Started: time.Now()}
+	err := cli.PullImageWithProgress(context.Background(), image, func(msg map[string]interface{}) {
+		status, ok := msg["status"].(string)
+		if !ok {
+			return
+		}
+		layerId, ok := msg["id"].(string)
+		if !ok {
+			return
+		}
+		p, _ := msg["progress"].(string)
+		progress(layerId, status, p)
+	})
+	step.Finished = time.Now()
+	if err != nil {
+		step.Error = err.Error()
+	}
+	return &pullResult{step: step, err: err}
+}
+
+// step runs the action as a step of the update.
+func (u updater) step(label, name string, action func() error) error {
+	done := u.reporter.Step(label, name)
+	err := action()
+	done(err)
+	return err
+}
+
+// rollback removes the new container, if any, and restores the previous one
+// under its original name. It returns an error describing the failed update.
+func (u updater) rollback(oldID, newID, name string, start bool, cause error) error {
+	u.reporter.Warning("Rolling back to the previous container")
+
+	if newID != "" {
+		if err := u.step("Removing new container", name, func() error {
+			if err := u.cli.StopContainer(newID); err != nil {
+				return err
+			}
+			return u.cli.DeleteContainer(newID)
+		}); err != nil {
+			u.reporter.Error(fmt.Sprintf("Error rollback: %v", err))
+			return fmt.Errorf("update failed: %w, rollback failed: %v", cause, err)
+		}
+	}
+
+	if err := u.step("Restoring container", name, func() error {
+		if err := u.cli.RenameContainer(oldID, name); err != nil {
+			return err
+		}
+		if start {
+			return u.cli.StartContainer(oldID)
+		}
+		return nil
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error rollback: %v", err))
+		return fmt.Errorf("update failed: %w, rollback failed: %v", cause, err)
+	}
+
+	u.reporter.Warning(fmt.Sprintf("Update of %s rolled back: %v", name, cause))
+	u.record.Result = history.RolledBack
+	return fmt.Errorf("%w: %w", ErrRolledBack, cause)
+}
+
+// save completes the record of the update with its result and appends it to History.
+func (u updater) save(err error) {
+	r := u.record
+	r.Finished = time.Now()
+	switch {
+	case err == nil:
+		r.Result = history.Updated
+	case r.Result != history.RolledBack:
+		r.Result = history.Failed
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	if History == nil {
+		return
+	}
+	if err := History.Append(*r); err != nil {
+		log.Printf("unable to record the update of %s in %s: %v", r.Container, History.Path(), err)
+	}
+}
+
+// imageVersion returns the local image with the given ID, or tagged ref when
+// the ID is empty, with its digest in the repository of ref.
+func (u updater) imageVersion(ref, id string) history.ImageVersion {
+	v := history.ImageVersion{Ref: ref, ID: id}
+	images, err := u.cli.ImageList()
+	if err != nil {
+		log.Printf("unable to list the images: %v", err)
+		return v
+	}
+	tag, err := name.ParseReference(ref)
+	if err != nil {
+		return v
+	}
+
+	for _, img := range images {
+		if id != "" && img.ID != id {
+			continue
+		}
+		if id == "" && !slices.ContainsFunc(img.RepoTags, func(t string) bool {
+			r, err := name.ParseReference(t)
+			return err == nil && r.Name() == tag.Name()
+		}) {
+			continue
+		}
+		v.ID = img.ID
+		for _, rd := range img.RepoDigests {
+			d, err := name.NewDigest(rd)
+			if err == nil && (v.Digest == "" || d.Context().Name() == tag.Context().Name()) {
+				v.Digest = d.DigestStr()
+			}
+		}
+		break
+	}
+	return v
+}
+
+// waitHealthy waits for the container to be running for StartPeriod and,
+// when it has a healthcheck, to be healthy within HealthTimeout. A container
+// without healthcheck nor restart policy exiting with the code 0 did its job,
+// it is healthy.
+func (u updater) waitHealthy(id string) error {
+	started := time.Now()
+	for {
+		inspect, err := u.cli.ContainerInspect(id)
+		if err != nil {
+			return err
+		}
+		state := inspect.State
+		if state != nil && !state.Running && !state.Restarting && state.ExitCode == 0 && state.Health == nil &&
+			state.Status == container.StateExited && (inspect.HostConfig == nil || inspect.HostConfig.RestartPolicy.IsNone()) {
+			return nil
+		}
+		if state == nil || !state.Running || state.Restarting {
+			if state != nil && state.ExitCode != 0 {
+				return fmt.Errorf("container exited with code %d", state.ExitCode)
+			}
+			return fmt.Errorf("container is not running")
+		}
+
+		elapsed := time.Since(started)
+		if state.Health == nil {
+			if elapsed >= StartPeriod {
+				return nil
+			}
+		} else {
+			switch state.Health.Status {
+			case container.Healthy:
+				return nil
+			case container.Unhealthy:
+				return fmt.Errorf("container is unhealthy")
+			}
+			if elapsed >= HealthTimeout {
+				return fmt.Errorf("container not healthy after %s", HealthTimeout)
+			}
+		}
+		time.Sleep(healthPollInterval)
+	}
+}
+
+// recorder records the steps of an update in its history record before reporting them.
+type recorder struct {
+	Reporter
+	record *history.Record
+}
+
+func (r *recorder) Step(label, name string) func(err error) {
+	done := r.Reporter.Step(label, name)
+	step := history.Step{Label: label, Target: name, Started: time.Now()}
+	return func(err error) {
+		step.Finished = time.Now()
+		if err != nil {
+			step.Error = err.Error()
+		}
+		r.record.Steps = append(r.record.Steps, step)
+		done(err)
+	}
+}
+
+func (r *recorder) Downtime(d time.Duration) {
+	r.record.Downtime = d
+	r.Reporter.Downtime(d)
+}
@@ -0,0 +1,200 @@
+package containerupdate
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/kernaxis/gmd/docker/client/fake"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
+)
+
+// testReporter records the steps, warnings and downtime of the updates.
+type testReporter struct {
+	t        *testing.T
+	mu       sync.Mutex
+	steps    []string
+	warnings []string
+	downtime time.Duration
+}
+
+func (r *testReporter) Pull(layer, status, progress string) {}
+
+func (r *testReporter) Step(label, name string) func(err error) {
+	r.mu.Lock()
+	r.steps = append(r.steps, label+" "+name)
+	r.mu.Unlock()
+	return func(err error) {
+		if err != nil {
+			r.t.Logf("%s %s: %v", label, name, err)
+		}
+	}
+}
+
+func (r *testReporter) Error(text string) { r.t.Log(text) }
+
+func (r *testReporter) Warning(text string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.warnings = append(r.warnings, text)
+}
+
+func (r *testReporter) Downtime(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.downtime = d
+}
+
+// setup makes the updates of the test quick and recorded in a temporary history.
+func setup(t *testing.T) {
+	t.Helper()
+	startPeriod, healthTimeout, store, strategy := StartPeriod, HealthTimeout, History, Strategy
+	t.Cleanup(func() { StartPeriod, HealthTimeout, History, Strategy = startPeriod, healthTimeout, store, strategy })
+	StartPeriod, HealthTimeout = 0, time.Second
+	History = history.New(filepath.Join(t.TempDir(), "history.jsonl"))
+	Strategy = types.StrategyStopFirst
+}
+
+// inspect returns the container with the given name or ID, failing the test when it does not exist.
+func inspect(t *testing.T, e *fake.Engine, name string) types.Container {
+	t.Helper()
+	c, err := e.ContainerInspect(name)
+	if err != nil {
+		t.Fatalf("inspect %s: %v", name, err)
+	}
+	return types.Container{InspectResponse: c}
+}
+
+// running reports whether the container with the given name exists and runs.
+func running(e *fake.Engine, name string) bool {
+	c, err := e.ContainerInspect(name)
+	return err == nil && c.State != nil && c.State.Running
+}
+
+func TestUpdateHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		health  container.HealthStatus
+		exit    int
+		restart container.RestartPolicyMode
+		wantErr string // wantErr is the cause of the rollback, empty when the update succeeds
+	}{
+		{name: "running", exit: -1},
+		{name: "healthy", health: container.Healthy, exit: -1},
+		{name: "unhealthy", health: container.Unhealthy, exit: -1, wantErr: "container is unhealthy"},
+		{name: "timeout", health: container.Starting, exit: -1, wantErr: "container not healthy after 1s"},
+		{name: "one-shot", exit: 0},
+		{name: "one-shot failed", exit: 3, wantErr: "container exited with code 3"},
+		{name: "exited with restart policy", exit: 0, restart: container.RestartPolicyAlways, wantErr: "container is not running"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup(t)
+			e := fake.New()
+			e.AddImage("app:1", "sha256:v1")
+			r, err := e.CreateContainerFromConfig(container.InspectResponse{
+				ContainerJSONBase: &container.ContainerJSONBase{
+					Name:       "/app",
+					HostConfig: &container.HostConfig{RestartPolicy: container.RestartPolicy{Name: tt.restart}},
+				},
+				Config: &container.Config{Image: "app:1"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := e.StartContainer(r.ID); err != nil {
+				t.Fatal(err)
+			}
+			e.SetRemoteDigest("app:1", "sha256:v2")
+			e.SetImageHealth("sha256:v2", tt.health)
+			e.SetImageExit("sha256:v2", tt.exit)
+
+			err = Update(e, inspect(t, e, "app"), &testReporter{t: t})
+			records, loadErr := History.Load()
+			if loadErr != nil || len(records) != 1 {
+				t.Fatalf("history has %d records (%v), want 1", len(records), loadErr)
+			}
+			record := records[0]
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Update() = %v, want no error", err)
+				}
+				if record.Result != history.Updated {
+					t.Errorf("recorded %s, want %s", record.Result, history.Updated)
+				}
+				if app := inspect(t, e, "app"); app.ID == r.ID {
+					t.Error("the container was not recreated")
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrRolledBack) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Update() = %v, want a rollback caused by %q", err, tt.wantErr)
+			}
+			if record.Result != history.RolledBack {
+				t.Errorf("recorded %s, want %s", record.Result, history.RolledBack)
+			}
+			app := inspect(t, e, "app")
+			if app.ID != r.ID || !app.State.Running {
+				t.Errorf("app is %s, running %t, want the previous container running", app.ID, app.State.Running)
+			}
+			if list, _ := e.ContainerList(); len(list) != 1 {
+				t.Errorf("%d containers left, want 1", len(list))
+			}
+		})
+	}
+}
+
+func TestUpdateFailures(t *testing.T) {
+	failure := errors.New("daemon unreachable")
+	tests := []struct {
+		name       string
+		op         fake.Operation
+		result     history.Result
+		rolledBack bool
+		running    bool // running tells whether the previous container runs after the update
+	}{
+		{name: "pull", op: fake.OpImagePull, result: history.Failed, running: true},
+		{name: "stop", op: fake.OpContainerStop, result: history.Failed, running: true},
+		{name: "create", op: fake.OpContainerCreate, result: history.RolledBack, rolledBack: true, running: true},
+		{name: "start of the new container", op: fake.OpContainerStart, result: history.Failed},
+		{name: "rename", op: fake.OpContainerRename, result: history.Failed, running: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setup(t)
+			e := fake.New()
+			e.AddImage("app:1", "sha256:v1")
+			e.SetRemoteDigest("app:1", "sha256:v2")
+			oldID := e.AddContainer("app", "app:1", true)
+			e.FailOn(tt.op, failure)
+
+			err := Update(e, inspect(t, e, "app"), &testReporter{t: t})
+			if !errors.Is(err, failure) {
+				t.Fatalf("Update() = %v, want %v", err, failure)
+			}
+			if errors.Is(err, ErrRolledBack) != tt.rolledBack {
+				t.Errorf("Update() = %v, rolled back %t", err, tt.rolledBack)
+			}
+			records, loadErr := History.Load()
+			if loadErr != nil || len(records) != 1 {
+				t.Fatalf("history has %d records (%v), want 1", len(records), loadErr)
+			}
+			if records[0].Result != tt.result || records[0].Error == "" {
+				t.Errorf("recorded %s (%q), want %s with the error", records[0].Result, records[0].Error, tt.result)
+			}
+			if old, err := e.ContainerInspect(oldID); err != nil || old.State.Running != tt.running {
+				t.Errorf("the previous container is running %t (%v), want %t", old.State != nil && old.State.Running, err, tt.running)
+			}
+			if tt.running && inspect(t, e, "app").ID != oldID {
+				t.Error("app is not the previous container")
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package containerupdate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
+)
+
+// Retention is how the images replaced by the updates are kept to roll back to them.
+var Retention = history.Retention{Keep: 3}
+
+// ErrNoPreviousImage is returned when a container has no retained previous image.
+var ErrNoPreviousImage = errors.New("no previous image kept")
+
+// PreviousImages returns the images replaced by the updates on the host of
+// cli which are still present, most recently replaced first.
+func PreviousImages(cli client.Client) ([]history.PreviousImage, error) {
+	if History == nil {
+		return nil, nil
+	}
+	records, err := History.Load()
+	if err != nil {
+		return nil, err
+	}
+	return retained(cli, history.PreviousImages(records))
+}
+
+// PreviousImage returns the image the container ran before its last update, if still present.
+func PreviousImage(cli client.Client, name string) (history.PreviousImage, error) {
+	previous, err := PreviousImages(cli)
+	if err != nil {
+		return history.PreviousImage{}, err
+	}
+	for _, p := range previous {
+		if p.Container == name {
+			return p, nil
+		}
+	}
+	return history.PreviousImage{}, fmt.Errorf("%w for %s", ErrNoPreviousImage, name)
+}
+
+// retained returns the previous images of the host of cli still present and not used by a container.
+func retained(cli client.Client, previous []history.PreviousImage) ([]history.PreviousImage, error) {
+	images, err := cli.ImageList()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := cli.ContainerList()
+	if err != nil {
+		return nil, err
+	}
+	return retainedIn(cli.Endpoint().Name(), images, containers, previous), nil
+}
+
+// retainedIn returns the previous images of host which are in images and not used by one of the containers.
+func retainedIn(host string, images []image.Summary, containers []container.Summary, previous []history.PreviousImage) []history.PreviousImage {
+	present := make(map[string]bool, len(images))
+	for _, img := range images {
+		present[img.ID] = true
+	}
+	for _, c := range containers {
+		delete(present, c.ImageID)
+	}
+
+	var out []history.PreviousImage
+	for _, p := range previous {
+		if p.Host == host && present[p.Image.ID] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// prune removes the previous images of the host which Retention does not keep
+// anymore, the one replaced by the update included. The images still tagged
+// are left, they are not only previous versions. A failure is reported as a
+// warning, the update is done.
+func (u updater) prune() {
+	if History == nil {
+		return
+	}
+	records, err := History.Load()
+	if err != nil {
+		u.reporter.Warning(fmt.Sprintf("Unable to read the history to prune the previous images: %v", err))
+		return
+	}
+	images, err := u.cli.ImageList()
+	if err != nil {
+		u.reporter.Warning(fmt.Sprintf("Unable to list the images to prune: %v", err))
+		return
+	}
+	containers, err := u.cli.ContainerList()
+	if err != nil {
+		u.reporter.Warning(fmt.Sprintf("Unable to list the containers to prune the images: %v", err))
+		return
+	}
+
+	// the record of the update is saved once the update is over
+	r := *u.record
+	r.Result, r.Finished = history.Updated, time.Now()
+	previous := retainedIn(u.cli.Endpoint().Name(), images, containers, history.PreviousImages(append(records, r)))
+	expired := Retention.Expired(previous, time.Now())
+	if len(expired) == 0 {
+		return
+	}
+
+	tagged := make(map[string]bool, len(images))
+	for _, img := range images {
+		tagged[img.ID] = len(img.RepoTags) > 0
+	}
+	for _, p := range expired {
+		if tagged[p.Image.ID] {
+			continue
+		}
+		if err := u.step("Removing previous image", p.Image.Ref+" "+shortID(p.Image.ID), func() error {
+			return u.cli.DeleteImage(context.Background(), p.Image.ID)
+		}); err != nil {
+			u.reporter.Warning(fmt.Sprintf("Unable to remove the previous image %s: %v", p.Image.Ref, err))
+		}
+	}
+}
+
+// Rollback recreates the container on the previous image, tagged back with
+// the reference the container used. The rollback is recorded in History as an
+// update, the tag is moved back to the current image when it fails.
+func Rollback(cli client.Client, c types.Container, previous history.PreviousImage, reporter Reporter) error {
+	ref := previous.Image.Ref
+	step := history.Step{Label: "Tagging previous image", Target: ref, Started: time.Now()}
+	done := reporter.Step(step.Label, step.Target)
+	err := cli.TagImage(context.Background(), previous.Image.ID, ref)
+	done(err)
+	step.Finished = time.Now()
+	if err != nil {
+		step.Error = err.Error()
+	}
+
+	err = run(cli, WithImage(c, ref), reporter, &pullResult{step: step, err: err})
+	if err != nil && step.Error == "" && c.ContainerJSONBase != nil {
+		if err := cli.TagImage(context.Background(), c.Image, ref); err != nil {
+			log.Printf("unable to tag the image %s back with %s: %v", c.Image, ref, err)
+		}
+	}
+	return err
+}
+
+// shortID returns the short form of an image ID.
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	return id[:min(len(id), 12)]
+}
@@ -0,0 +1,103 @@
+package containerupdate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kernaxis/gmd/docker/client/fake"
+	"github.com/kernaxis/gmd/history"
+)
+
+func TestUpdatePrunesPreviousImages(t *testing.T) {
+	setup(t)
+	retention := Retention
+	t.Cleanup(func() { Retention = retention })
+	Retention = history.Retention{Keep: 1}
+
+	e := fake.New()
+	v1 := e.AddImage("app:1", "sha256:v1")
+	e.AddContainer("app", "app:1", true)
+	for _, digest := range []string{"sha256:v2", "sha256:v3"} {
+		e.SetRemoteDigest("app:1", digest)
+		if err := Update(e, inspect(t, e, "app"), &testReporter{t: t}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	previous, err := PreviousImages(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(previous) != 1 || previous[0].Image.ID == v1 {
+		t.Fatalf("previous images %+v, want the one of v2 only", previous)
+	}
+	images, _ := e.ImageList()
+	for _, img := range images {
+		if img.ID == v1 {
+			t.Error("the image of v1 was not pruned")
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+	setup(t)
+	e := fake.New()
+	v1 := e.AddImage("app:1", "sha256:v1")
+	e.AddContainer("app", "app:1", true)
+	e.SetRemoteDigest("app:1", "sha256:v2")
+	if err := Update(e, inspect(t, e, "app"), &testReporter{t: t}); err != nil {
+		t.Fatal(err)
+	}
+	v2 := inspect(t, e, "app").Image
+
+	previous, err := PreviousImage(e, "app")
+	if err != nil || previous.Image.ID != v1 {
+		t.Fatalf("PreviousImage() = %+v, %v, want v1", previous, err)
+	}
+	// the image of v1 is tagged back, the pull is not done again
+	e.FailOn(fake.OpImagePull, errors.New("unexpected pull"))
+	if err := Rollback(e, inspect(t, e, "app"), previous, &testReporter{t: t}); err != nil {
+		t.Fatal(err)
+	}
+
+	app := inspect(t, e, "app")
+	if app.Image != v1 || !app.State.Running {
+		t.Errorf("app runs %s, running %t, want v1 running", app.Image, app.State.Running)
+	}
+	if previous, err := PreviousImage(e, "app"); err != nil || previous.Image.ID != v2 {
+		t.Errorf("PreviousImage() = %+v, %v, want v2 after the rollback", previous, err)
+	}
+}
+
+func TestRollbackFailure(t *testing.T) {
+	setup(t)
+	e := fake.New()
+	v1 := e.AddImage("app:1", "sha256:v1")
+	e.AddContainer("app", "app:1", true)
+	e.SetRemoteDigest("app:1", "sha256:v2")
+	if err := Update(e, inspect(t, e, "app"), &testReporter{t: t}); err != nil {
+		t.Fatal(err)
+	}
+	v2 := inspect(t, e, "app").Image
+	previous, err := PreviousImage(e, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.FailOn(fake.OpContainerCreate, errors.New("no space left"))
+	if err := Rollback(e, inspect(t, e, "app"), previous, &testReporter{t: t}); err == nil {
+		t.Fatal("Rollback() succeeded, want the create failure")
+	}
+
+	app := inspect(t, e, "app")
+	if app.Image != v2 || !app.State.Running {
+		t.Errorf("app runs %s, running %t, want v2 running", app.Image, app.State.Running)
+	}
+	// the tag is moved back to the current image
+	images, _ := e.ImageList()
+	for _, img := range images {
+		if img.ID == v1 && len(img.RepoTags) > 0 {
+			t.Errorf("the image of v1 is still tagged %v", img.RepoTags)
+		}
+	}
+}
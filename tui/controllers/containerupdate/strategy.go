@@ -0,0 +1,199 @@
+package containerupdate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
+)
+
+// newSuffix is appended to the name of the new container while it runs next to the previous one.
+const newSuffix = "-gmd-new"
+
+// Strategy is the update strategy of the containers whose gmd.update.strategy label does not set one.
+var Strategy = types.StrategyStopFirst
+
+// strategy returns the strategy of the update of the container: the one of its
+// label, or Strategy. Start-first falls back to stop-first when the new
+// container cannot run next to the previous one.
+func (u updater) strategy(c container.InspectResponse) types.UpdateStrategy {
+	strategy := Strategy
+	if c.Config != nil {
+		if s := types.UpdatePolicyOf(c.Config.Labels).Strategy; s != types.StrategyDefault {
+			strategy = s
+		}
+	}
+	if strategy != types.StrategyStartFirst {
+		return types.StrategyStopFirst
+	}
+	if reason := startFirstConflict(c); reason != "" {
+		u.reporter.Warning(fmt.Sprintf("Stopping the container first, it %s", reason))
+		return types.StrategyStopFirst
+	}
+	return strategy
+}
+
+// startFirstConflict tells why a new container cannot run next to the given
+// one, or returns an empty string when it can: the new container would take
+// its host ports, its network stack or its static addresses, or write to its
+// volumes at the same time.
+func startFirstConflict(c container.InspectResponse) string {
+	if c.State == nil || !c.State.Running {
+		return "is not running"
+	}
+
+	if c.HostConfig != nil {
+		mode := c.HostConfig.NetworkMode
+		switch {
+		case mode.IsHost():
+			return "uses the host network"
+		case mode.IsContainer():
+			return "joins the network of " + mode.ConnectedContainer()
+		}
+		for _, port := range slices.Sorted(maps.Keys(c.HostConfig.PortBindings)) {
+			for _, b := range c.HostConfig.PortBindings[port] {
+				if b.HostPort != "" {
+					return fmt.Sprintf("publishes %s on the host port %s", port, b.HostPort)
+				}
+			}
+		}
+	}
+
+	if c.NetworkSettings != nil {
+		for _, name := range slices.Sorted(maps.Keys(c.NetworkSettings.Networks)) {
+			n := c.NetworkSettings.Networks[name]
+			if n != nil && n.IPAMConfig != nil && (n.IPAMConfig.IPv4Address != "" || n.IPAMConfig.IPv6Address != "") {
+				return "has a static address on the network " + name
+			}
+		}
+	}
+
+	for _, m := range c.Mounts {
+		if !m.RW {
+			continue
+		}
+		switch m.Type {
+		case mount.TypeVolume:
+			return fmt.Sprintf("mounts the volume %s read-write", m.Name)
+		case mount.TypeBind:
+			return fmt.Sprintf("mounts %s read-write", m.Source)
+		}
+	}
+	return ""
+}
+
+// startFirst creates and starts the new container under a temporary name
+// while the previous one keeps running. Once the new container is healthy,
+// the names are swapped, then the previous container is stopped and removed.
+// The downtime lasts from the rename of the previous container to the rename of the new one.
+func (u updater) startFirst(id, containerName string, containerConfig container.InspectResponse) error {
+	newName := containerName + newSuffix
+	backupName := containerName + backupSuffix
+
+	// the MAC addresses of the previous container are still in use
+	containerConfig.Name = "/" + newName
+	if containerConfig.NetworkSettings != nil {
+		networks := make(map[string]*network.EndpointSettings, len(containerConfig.NetworkSettings.Networks))
+		for name, n := range containerConfig.NetworkSettings.Networks {
+			if n != nil {
+				n = n.Copy()
+				n.MacAddress = ""
+			}
+			networks[name] = n
+		}
+		settings := *containerConfig.NetworkSettings
+		settings.Networks = networks
+		containerConfig.NetworkSettings = &settings
+	}
+
+	var newID string
+	if err := u.step("Creating container", newName, func() error {
+		r, err := u.cli.CreateContainerFromConfig(containerConfig)
+		newID = r.ID
+		u.record.NewContainerID = r.ID
+		return err
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error create: %v", err))
+		return err
+	}
+
+	if err := u.step("Starting container", newName, func() error {
+		return u.cli.StartContainer(newID)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error start: %v", err))
+		return u.discard(newID, newName, containerName, err)
+	}
+
+	if err := u.step("Checking health", newName, func() error {
+		return u.waitHealthy(newID)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error check: %v", err))
+		return u.discard(newID, newName, containerName, err)
+	}
+
+	// the names are swapped back to back, the name has no container in between
+	down := time.Now()
+	if err := u.step("Renaming container", fmt.Sprintf("%s → %s", containerName, backupName), func() error {
+		return u.cli.RenameContainer(id, backupName)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error rename: %v", err))
+		return u.discard(newID, newName, containerName, err)
+	}
+	if err := u.step("Renaming container", fmt.Sprintf("%s → %s", newName, containerName), func() error {
+		return u.cli.RenameContainer(newID, containerName)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error rename: %v", err))
+		if err := u.step("Restoring container", containerName, func() error {
+			return u.cli.RenameContainer(id, containerName)
+		}); err != nil {
+			u.reporter.Error(fmt.Sprintf("Error rollback: %v", err))
+		}
+		return u.discard(newID, newName, containerName, err)
+	}
+	u.reporter.Downtime(time.Since(down))
+	if kept := u.recreateDependents(id, newID, containerName); len(kept) > 0 {
+		// the previous container keeps running for them
+		u.keepPrevious(backupName, kept)
+		return nil
+	}
+
+	if err := u.step("Stoping previous container", backupName, func() error {
+		return u.cli.StopContainer(id)
+	}); err != nil {
+		// the update itself succeeded, the backup is left for the user to remove
+		u.reporter.Error(fmt.Sprintf("Error stop: %v", err))
+		return nil
+	}
+	if err := u.step("Removing previous container", backupName, func() error {
+		return u.cli.DeleteContainer(id)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error remove: %v", err))
+	}
+	return nil
+}
+
+// discard removes the new container, named newName, of a failed start-first update,
+// the previous container having kept running. It returns an error describing the failed update.
+func (u updater) discard(newID, newName, name string, cause error) error {
+	u.reporter.Warning("Keeping the previous container")
+
+	if err := u.step("Removing new container", newName, func() error {
+		if err := u.cli.StopContainer(newID); err != nil {
+			return err
+		}
+		return u.cli.DeleteContainer(newID)
+	}); err != nil {
+		u.reporter.Error(fmt.Sprintf("Error rollback: %v", err))
+		return fmt.Errorf("update failed: %w, rollback failed: %v", cause, err)
+	}
+
+	u.reporter.Warning(fmt.Sprintf("Update of %s rolled back: %v", name, cause))
+	u.record.Result = history.RolledBack
+	return fmt.Errorf("%w: %w", ErrRolledBack, cause)
+}
@@ -0,0 +1,173 @@
+package containerupdate
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/kernaxis/gmd/docker/client/fake"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
+)
+
+func TestStartFirstConflict(t *testing.T) {
+	up := &container.State{Running: true}
+	tests := []struct {
+		name       string
+		state      *container.State
+		hostConfig *container.HostConfig
+		networks   map[string]*network.EndpointSettings
+		mounts     []container.MountPoint
+		want       string
+	}{
+		{
+			name:  "no conflict",
+			state: up,
+			hostConfig: &container.HostConfig{
+				NetworkMode:  "bridge",
+				PortBindings: nat.PortMap{"80/tcp": {{HostIP: "", HostPort: ""}}},
+			},
+			networks: map[string]*network.EndpointSettings{"bridge": {}, "front": {IPAMConfig: &network.EndpointIPAMConfig{}}},
+			mounts: []container.MountPoint{
+				{Type: mount.TypeVolume, Name: "data", RW: false},
+				{Type: mount.TypeTmpfs, Destination: "/tmp", RW: true},
+			},
+			want: "",
+		},
+		{name: "not running", state: &container.State{}, want: "is not running"},
+		{name: "no state", want: "is not running"},
+		{
+			name:       "host network",
+			state:      up,
+			hostConfig: &container.HostConfig{NetworkMode: "host"},
+			want:       "uses the host network",
+		},
+		{
+			name:       "network of another container",
+			state:      up,
+			hostConfig: &container.HostConfig{NetworkMode: "container:vpn"},
+			want:       "joins the network of vpn",
+		},
+		{
+			name:  "published port",
+			state: up,
+			hostConfig: &container.HostConfig{PortBindings: nat.PortMap{
+				"80/tcp":  {{HostPort: ""}},
+				"443/tcp": {{HostIP: "0.0.0.0", HostPort: "8443"}},
+			}},
+			want: "publishes 443/tcp on the host port 8443",
+		},
+		{
+			name:     "static IPv4 address",
+			state:    up,
+			networks: map[string]*network.EndpointSettings{"front": {IPAMConfig: &network.EndpointIPAMConfig{IPv4Address: "172.20.0.10"}}},
+			want:     "has a static address on the network front",
+		},
+		{
+			name:     "static IPv6 address",
+			state:    up,
+			networks: map[string]*network.EndpointSettings{"front": {IPAMConfig: &network.EndpointIPAMConfig{IPv6Address: "fd00::10"}}},
+			want:     "has a static address on the network front",
+		},
+		{
+			name:   "read-write volume",
+			state:  up,
+			mounts: []container.MountPoint{{Type: mount.TypeVolume, Name: "data", RW: true}},
+			want:   "mounts the volume data read-write",
+		},
+		{
+			name:   "read-write bind mount",
+			state:  up,
+			mounts: []container.MountPoint{{Type: mount.TypeBind, Source: "/srv/app", RW: true}},
+			want:   "mounts /srv/app read-write",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := container.InspectResponse{
+				ContainerJSONBase: &container.ContainerJSONBase{State: tt.state, HostConfig: tt.hostConfig},
+				Mounts:            tt.mounts,
+				NetworkSettings:   &container.NetworkSettings{Networks: tt.networks},
+			}
+			if got := startFirstConflict(c); got != tt.want {
+				t.Errorf("startFirstConflict() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateStartFirst(t *testing.T) {
+	setup(t)
+	StartPeriod = 300 * time.Millisecond
+	e := fake.New()
+	e.AddImage("app:1", "sha256:v1")
+	e.SetRemoteDigest("app:1", "sha256:v2")
+	oldID := e.AddContainer("app", "app:1", true)
+	if err := e.SetLabels(oldID, map[string]string{types.UpdateStrategyLabel: string(types.StrategyStartFirst)}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &testReporter{t: t}
+	if err := Update(e, inspect(t, e, "app"), r); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := History.Load()
+	if err != nil || len(records) != 1 {
+		t.Fatalf("history has %d records (%v), want 1", len(records), err)
+	}
+	record := records[0]
+	if record.Strategy != string(types.StrategyStartFirst) {
+		t.Errorf("strategy %q, want %q", record.Strategy, types.StrategyStartFirst)
+	}
+	// the previous container keeps running while the new one proves healthy
+	if record.Downtime <= 0 || record.Downtime >= StartPeriod || record.Downtime != r.downtime {
+		t.Errorf("downtime %s, reported %s, want less than the start period %s", record.Downtime, r.downtime, StartPeriod)
+	}
+	checked := slices.Index(r.steps, "Checking health app-gmd-new")
+	renamed := slices.Index(r.steps, "Renaming container app → app-gmd-backup")
+	stopped := slices.Index(r.steps, "Stoping previous container app-gmd-backup")
+	if checked < 0 || renamed < checked || stopped < renamed {
+		t.Errorf("steps %q, want the health checked before the rename and the stop of the previous container", r.steps)
+	}
+
+	app := inspect(t, e, "app")
+	if app.ID == oldID || !app.State.Running {
+		t.Errorf("app is %s, running %t, want the new container running", app.ID, app.State.Running)
+	}
+	if _, err := e.ContainerInspect(oldID); err == nil {
+		t.Error("the previous container was not removed")
+	}
+	if record.Result != history.Updated {
+		t.Errorf("recorded %s, want %s", record.Result, history.Updated)
+	}
+}
+
+func TestUpdateStartFirstFallback(t *testing.T) {
+	setup(t)
+	Strategy = types.StrategyStartFirst
+	e := fake.New()
+	e.AddImage("app:1", "sha256:v1")
+	e.SetRemoteDigest("app:1", "sha256:v2")
+	e.AddContainer("vpn", "app:1", true)
+	addDependent(t, e, "app", "app:1", "vpn")
+
+	r := &testReporter{t: t}
+	if err := Update(e, inspect(t, e, "app"), r); err != nil {
+		t.Fatal(err)
+	}
+	records, err := History.Load()
+	if err != nil || len(records) != 1 {
+		t.Fatalf("history has %d records (%v), want 1", len(records), err)
+	}
+	if records[0].Strategy != string(types.StrategyStopFirst) {
+		t.Errorf("strategy %q, want %q", records[0].Strategy, types.StrategyStopFirst)
+	}
+	if !slices.Contains(r.warnings, "Stopping the container first, it joins the network of vpn") {
+		t.Errorf("warnings %q, want the fallback reported", r.warnings)
+	}
+}
@@ -1,11 +1,14 @@
 package tui
 
 import (
+	"fmt"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kernaxis/gmd/docker/cache"
 	"github.com/kernaxis/gmd/docker/client"
 	"github.com/kernaxis/gmd/tui/commands"
 	"github.com/kernaxis/gmd/tui/componants"
+	"github.com/kernaxis/gmd/tui/controllers/containerstats"
 	"github.com/kernaxis/gmd/tui/models/containers"
 	"github.com/kernaxis/gmd/tui/models/maintab"
 )
@@ -15,26 +18,29 @@ import (
 // ---------------------------------------------------
 
 type Model struct {
-	cli         *client.Client
-	dockerCache *cache.Cache
-	stack       []tea.Model
+	caches []*cache.Cache
+	stack  []tea.Model
 
 	screeWidth   int
 	screenHeight int
 }
 
-func NewModel() (Model, error) {
-	cli, err := client.NewClient()
-	if err != nil {
-		return Model{}, err
+// NewModel returns the root model managing the Docker hosts reachable at the given endpoints.
+// Each endpoint gets its own client and cache.
+func NewModel(endpoints []client.Endpoint) (Model, error) {
+	caches := make([]*cache.Cache, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		cli, err := client.NewClient(endpoint)
+		if err != nil {
+			return Model{}, fmt.Errorf("unable to connect to %s: %w", endpoint, err)
+		}
+		caches = append(caches, cache.NewCache(cli))
 	}
-	cache := cache.NewCache(cli)
 
-	mainModel := maintab.New(cli, cache)
+	mainModel := maintab.New(caches)
 
 	m := Model{
-		cli:         cli,
-		dockerCache: cache,
+		caches: caches,
 	}
 
 	m.stack = []tea.Model{
@@ -46,11 +52,22 @@ func NewModel() (Model, error) {
 
 func (m Model) Init() tea.Cmd {
 	top := m.stack[len(m.stack)-1]
-	return tea.Batch(
-		StartMonitorCache(m.dockerCache),
-		WaitDockerEvent(m.dockerCache.Events()),
-		top.Init(),
-	)
+	cmds := make([]tea.Cmd, 0, 2*len(m.caches)+1)
+	for _, c := range m.caches {
+		cmds = append(cmds, StartMonitorCache(c), WaitDockerEvent(c.Events()))
+	}
+	cmds = append(cmds, top.Init())
+	return tea.Batch(cmds...)
+}
+
+// cache returns the cache of the given host.
+func (m Model) cache(host string) *cache.Cache {
+	for _, c := range m.caches {
+		if c.Host() == host {
+			return c
+		}
+	}
+	return nil
 }
 
 // ---------------------------------------------------
@@ -79,13 +96,26 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case cache.Event:
 		var cmd tea.Cmd
 		m.stack[0], cmd = m.stack[0].Update(msg)
-		return m, tea.Batch(WaitDockerEvent(m.dockerCache.Events()), cmd)
+		if c := m.cache(msg.Host); c != nil {
+			cmd = tea.Batch(WaitDockerEvent(c.Events()), cmd)
+		}
+		return m, cmd
+
+	case commands.CacheStartMsg:
+		var cmd tea.Cmd
+		m.stack[0], cmd = m.stack[0].Update(msg)
+		return m, cmd
 
 	case containers.ContainerUpdateMsg:
 		var cmd tea.Cmd
 		m.stack[0], cmd = m.stack[0].Update(msg)
 		return m, cmd
 
+	case containerstats.StatsMsg:
+		var cmd tea.Cmd
+		m.stack[0], cmd = m.stack[0].Update(msg)
+		return m, cmd
+
 	case commands.SwitchPageMsg:
 		model := msg.Model
 		if model == nil {
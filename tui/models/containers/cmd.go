@@ -3,7 +3,9 @@ package containers
 import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/history"
 	"github.com/kernaxis/gmd/tui/controllers/containerstats"
+	updatectl "github.com/kernaxis/gmd/tui/controllers/containerupdate"
 )
 
 type ContainerActionMsg struct {
@@ -13,12 +15,14 @@ type ContainerActionMsg struct {
 }
 
 type ContainerUpdateMsg struct {
+	Host        string
 	ContainerID string
 	Update      bool
+	Tags        client.TagUpdates
 	Err         error
 }
 
-// func StartContainerCmd(cli *client.Client, id string) tea.Cmd {
+// func StartContainerCmd(cli client.Client, id string) tea.Cmd {
 // 	return func() tea.Msg {
 // 		msg := ContainerActionMsg{ContainerID: id, Action: "start"}
 // 		msg.Err = cli.StartContainer(id)
@@ -26,7 +30,7 @@ type ContainerUpdateMsg struct {
 // 	}
 // }
 
-func RestartContainerCmd(cli *client.Client, id string) tea.Cmd {
+func RestartContainerCmd(cli client.Client, id string) tea.Cmd {
 	return func() tea.Msg {
 		msg := ContainerActionMsg{ContainerID: id, Action: "restart"}
 		msg.Err = cli.RestartContainer(id)
@@ -34,10 +38,26 @@ func RestartContainerCmd(cli *client.Client, id string) tea.Cmd {
 	}
 }
 
-func CheckContainerUpdate(cli *client.Client, id string) tea.Cmd {
+func CheckContainerUpdate(cli client.Client, id string) tea.Cmd {
 	return func() tea.Msg {
-		update, err := cli.CheckUpdate(id)
-		return ContainerUpdateMsg{ContainerID: id, Update: update, Err: err}
+		check, err := cli.CheckUpdate(id)
+		return ContainerUpdateMsg{Host: cli.Endpoint().Name(), ContainerID: id, Update: check.Update, Tags: check.Tags, Err: err}
+	}
+}
+
+// PreviousImageMsg delivers the image a container ran before its last update.
+type PreviousImageMsg struct {
+	Host        string
+	ContainerID string
+	Previous    history.PreviousImage
+	Err         error
+}
+
+// PreviousImageCmd looks for the image kept from the last update of the container.
+func PreviousImageCmd(cli client.Client, id, name string) tea.Cmd {
+	return func() tea.Msg {
+		previous, err := updatectl.PreviousImage(cli, name)
+		return PreviousImageMsg{Host: cli.Endpoint().Name(), ContainerID: id, Previous: previous, Err: err}
 	}
 }
 
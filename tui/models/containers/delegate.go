@@ -26,6 +26,15 @@ func (d ItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
 }
 
 func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if p, ok := item.(ProjectItem); ok {
+		content := p.content
+		if index == m.Index() {
+			content = style.ListSelectedLine().Inherit(style.Bold()).Render(p.Render(true))
+		}
+		fmt.Fprint(w, lipgloss.JoinHorizontal(lipgloss.Center, content, " "))
+		return
+	}
+
 	c, ok := item.(ContainerItem)
 	if !ok {
 		return
@@ -44,5 +53,5 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 		//prefix = style.SelectedBar.Render(lipgloss.JoinVertical(lipgloss.Center, "▍ ", "▍ "))
 	}
 
-	fmt.Fprint(w, lipgloss.JoinHorizontal(lipgloss.Center, content, " " /*, c.statsContent*/))
+	fmt.Fprint(w, lipgloss.JoinHorizontal(lipgloss.Center, content, " "))
 }
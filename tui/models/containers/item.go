@@ -1,36 +1,50 @@
 package containers
 
 import (
+	"errors"
 	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/docker/docker/api/types/container"
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/compose"
 	"github.com/kernaxis/gmd/docker/types"
 	style "github.com/kernaxis/gmd/tui/styles"
 )
 
 type ContainerItem struct {
-	id           string
-	name         string
-	state        container.ContainerState
-	actionState  container.ContainerState
-	update       *bool
-	content      string
-	statsContent string
-	image        string
-	ip4Address   string
-	ip6Address   string
+	host        string
+	showHost    bool
+	id          string
+	name        string
+	state       container.ContainerState
+	actionState container.ContainerState
+	update      *bool
+	tags        client.TagUpdates  // tags are the newer tags of the image of the container.
+	checkErr    error              // checkErr is the error of the last update check, nil when it succeeded.
+	policy      types.UpdatePolicy // policy is the update policy set by the labels of the container.
+	content     string
+	stats       *types.Stats // stats are the last stats of the container, nil until received or when stopped.
+	image       string
+	ip4Address  string
+	ip6Address  string
+	project     string // project is the compose project of the container, if any.
+	grouped     bool   // grouped is set when the container is shown under its project.
+	marked      bool   // marked is set when the container is marked for a batch update.
 
 	show bool
 }
 
-func NewContainerItem(dc types.Container) ContainerItem {
+func NewContainerItem(host string, dc types.Container) ContainerItem {
 	c := ContainerItem{
+		host:       host,
 		id:         dc.ID,
 		name:       dc.Name,
 		state:      dc.State.Status,
 		image:      dc.Config.Image,
+		project:    compose.Project(dc),
+		policy:     types.UpdatePolicyOf(dc.Config.Labels),
 		ip4Address: "-",
 		ip6Address: "-",
 	}
@@ -56,95 +70,111 @@ func NewContainerItem(dc types.Container) ContainerItem {
 		}
 	}
 
-	// if dc.Stats.ID != "" {
-
-	// 	cpuPct := docker.CPUPercent(dc.Stats)
-	// 	// 	//memPct := docker.MemoryPercent(c.Stats)
-	// 	usedMem := dc.Stats.MemoryStats.Usage
-	// 	limitMem := dc.Stats.MemoryStats.Limit
-
-	// 	cpuBar := htopCpuBar(cpuPct, 20) // 20 = largeur de la barre
-	// 	memBar := htopMemBar(usedMem, limitMem, 20)
-
-	// 	statsContent = lipgloss.JoinHorizontal(
-	// 		lipgloss.Left,
-	// 		cpuBar, "   ", memBar,
-	// 	)
-	// }
-
 	return c
 }
 
 func (c *ContainerItem) RenderContent() {
 
-	title := style.Title().Render(c.Name())
-	shortID := style.Subtitle().Render(c.ShortID())
-
-	// statsContent := "CPU[ -- ]   RAM[ -- ]"
-	// c.statsContent = col3Style.Render(statsContent)
+	title := style.Title().Render(c.indent() + c.markFlag() + c.Name())
+	shortID := style.Subtitle().Render(c.indent() + c.ShortID() + c.hostTag())
 
 	col1 := lipgloss.JoinVertical(lipgloss.Left, title, shortID)
-	col2 := lipgloss.JoinHorizontal(lipgloss.Center, c.UpdateFlag(), " ", c.Status())
+	col2 := lipgloss.JoinVertical(lipgloss.Left, c.PolicyFlag(), lipgloss.JoinHorizontal(lipgloss.Center, c.UpdateFlag(), " ", c.Status()))
 	col3 := lipgloss.JoinHorizontal(lipgloss.Center, " ", style.Subtitle().Render(c.image))
 	col4 := lipgloss.JoinVertical(lipgloss.Left, style.Normal().Render(c.ip4Address), style.Normal().Render(c.ip6Address))
+	col5 := c.statsColumn()
 
 	col1 = colNameStyle.Render(col1)
 	col2 = colStateStyle.Render(col2)
 	col3 = colImageStyle.Render(col3)
 	col4 = colAddressStyle.Render(col4)
+	col5 = colStatsStyle.Render(col5)
 
-	c.content = lipgloss.JoinHorizontal(lipgloss.Center, col1, " ", col2, " ", col3, " ", col4)
+	c.content = lipgloss.JoinHorizontal(lipgloss.Center, col1, " ", col2, " ", col3, " ", col4, " ", col5)
 }
 
 func (c *ContainerItem) Render(selected bool) string {
 
-	title := style.Title().Render(c.Name())
-	shortID := style.Subtitle().Render(c.ShortID())
+	title := style.Title().Render(c.indent() + c.markFlag() + c.Name())
+	shortID := style.Subtitle().Render(c.indent() + c.ShortID() + c.hostTag())
 
 	col1 := lipgloss.JoinVertical(lipgloss.Left, title, shortID)
-	col2 := lipgloss.JoinHorizontal(lipgloss.Center, c.UpdateFlag(), " ", c.Status())
+	col2 := lipgloss.JoinVertical(lipgloss.Left, c.PolicyFlag(), lipgloss.JoinHorizontal(lipgloss.Center, c.UpdateFlag(), " ", c.Status()))
 	col3 := lipgloss.JoinHorizontal(lipgloss.Center, " ", style.Subtitle().Render(c.image))
 	col4 := lipgloss.JoinVertical(lipgloss.Left, style.Normal().Render(c.ip4Address), style.Normal().Render(c.ip6Address))
+	col5 := c.statsColumn()
 
 	col1 = colNameStyle.Render(col1)
 	col2 = colStateStyle.Render(col2)
 	col3 = colImageStyle.Render(col3)
 	col4 = colAddressStyle.Render(col4)
+	col5 = colStatsStyle.Render(col5)
 
 	if selected {
 		col1 = style.Bold().Render(col1)
 		col2 = style.Bold().Render(col2)
 		col3 = style.Bold().Render(col3)
 		col4 = style.Bold().Render(col4)
+		col5 = style.Bold().Render(col5)
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Center, col1, " ", col2, " ", col3, " ", col4)
+	return lipgloss.JoinHorizontal(lipgloss.Center, col1, " ", col2, " ", col3, " ", col4, " ", col5)
 }
 
-// func (c *ContainerItem) RenderStats(stats container.StatsResponse) {
-// 	if stats.ID == "" {
-// 		c.statsContent = "CPU[ -- ]   RAM[ -- ]"
-// 	}
-
-// 	cpuPct := docker.CPUPercent(stats)
-// 	//memPct := docker.MemoryPercent(c.Stats)
-// 	usedMem := stats.MemoryStats.Usage
-// 	limitMem := stats.MemoryStats.Limit
-
-// 	cpuBar := htopCpuBar(cpuPct, 20) // 20 = largeur de la barre
-// 	memBar := htopMemBar(usedMem, limitMem, 20)
+// SetStats sets the last stats of the container and renders the item again.
+func (c *ContainerItem) SetStats(stats *types.Stats) {
+	c.stats = stats
+	c.RenderContent()
+}
 
-// 	c.statsContent = lipgloss.JoinHorizontal(
-// 		lipgloss.Left,
-// 		cpuBar, "   ", memBar,
-// 	)
-// }
+// statsColumn renders the stats column, empty when the container is not running.
+func (c ContainerItem) statsColumn() string {
+	if c.state != container.StateRunning {
+		return ""
+	}
+	cpu, io := statsLines(c.stats)
+	return lipgloss.JoinVertical(lipgloss.Left, cpu, io)
+}
 
 func (c ContainerItem) Name() string {
 	title := strings.TrimPrefix(c.name, "/")
 	return title
 }
 
+// Host returns the name of the Docker host running the container.
+func (c ContainerItem) Host() string {
+	return c.host
+}
+
+// Project returns the compose project of the container, or an empty string.
+func (c ContainerItem) Project() string {
+	return c.project
+}
+
+// indent returns the prefix shifting the container under its project.
+func (c ContainerItem) indent() string {
+	if !c.grouped {
+		return ""
+	}
+	return "  "
+}
+
+// markFlag returns the flag of a container marked for a batch update.
+func (c ContainerItem) markFlag() string {
+	if !c.marked {
+		return ""
+	}
+	return MarkedFlag
+}
+
+// hostTag returns the host suffix shown next to the ID when several hosts are managed.
+func (c ContainerItem) hostTag() string {
+	if !c.showHost {
+		return ""
+	}
+	return " @" + c.host
+}
+
 func (c ContainerItem) ShortID() string {
 	shortID := c.id
 	if len(shortID) > 12 {
@@ -180,13 +210,49 @@ func (c ContainerItem) Status() string {
 }
 
 func (c ContainerItem) UpdateFlag() string {
+	if !c.policy.Checked() {
+		return UpdateSkippedFlag
+	}
 	if c.update == nil {
+		switch {
+		case errors.Is(c.checkErr, client.ErrAuthRequired):
+			return AuthRequiredFlag
+		case c.checkErr != nil:
+			return CheckFailedFlag
+		}
 		return UpdateUnavailable
 	}
+	flag := UpToDateFlag
 	if *c.update {
-		return UpdateAvailableFlag
-	} else {
-		return UpToDateFlag
+		flag = UpdateAvailableFlag
+	}
+	if level := c.tags.Level(); level != "" {
+		flag += NewerTagFlag.Render("↑" + level)
+	}
+	return flag
+}
+
+// PolicyFlag returns the effective update policy of the container, with its
+// track and a clock when it has its own schedule.
+func (c ContainerItem) PolicyFlag() string {
+	text := c.policy.Name()
+	if c.policy.Checked() {
+		if c.policy.Track != types.TrackDigest {
+			text += " " + strings.TrimPrefix(string(c.policy.Track), "semver-")
+		}
+		if c.policy.Schedule != "" {
+			text += " ⏱"
+		}
+	}
+	switch {
+	case c.policy.Invalid != "":
+		return style.Danger().Render(text)
+	case !c.policy.Checked() || c.policy.Mode == types.UpdateDefault:
+		return style.Inactive().Render(text)
+	case c.policy.Mode == types.UpdateAuto:
+		return style.Success().Render(text)
+	default:
+		return style.Warning().Render(text)
 	}
 }
 
@@ -232,114 +298,4 @@ func (c ContainerItem) UpdateFlag() string {
 // 	)
 // }
 
-func (c ContainerItem) FilterValue() string { return c.Name() }
-
-// func (c ContainerItem) StatsView() string {
-
-// 	if c.dc.Stats.ID == "" {
-// 		return "CPU --%  RAM --"
-// 	}
-
-// 	cpu := fmt.Sprintf("CPU %2.0f%%", docker.CPUPercent(c.dc.Stats))
-
-// 	ram := fmt.Sprintf("RAM %s (%2.0f%%)",
-// 		humanize.Bytes(uint64(c.dc.Stats.MemoryStats.Usage)),
-// 		docker.MemoryPercent(c.dc.Stats),
-// 	)
-
-// 	return lipgloss.JoinHorizontal(lipgloss.Left, cpu, "   ", ram)
-// }
-
-// func (c ContainerItem) StatsView() string {
-// 	if c.Stats.ID == "" {
-// 		return "CPU[ -- ]   RAM[ -- ]"
-// 	}
-
-// 	cpuPct := docker.CPUPercent(c.Stats)
-// 	//memPct := docker.MemoryPercent(c.Stats)
-// 	usedMem := c.Stats.MemoryStats.Usage
-// 	limitMem := c.Stats.MemoryStats.Limit
-
-// 	cpuBar := htopCpuBar(cpuPct, 20) // 20 = largeur de la barre
-// 	memBar := htopMemBar(usedMem, limitMem, 20)
-
-// 	return lipgloss.JoinHorizontal(
-// 		lipgloss.Left,
-// 		cpuBar, "   ", memBar,
-// 	)
-// }
-
-// func htopCpuBar(pct float64, width int) string {
-// 	if pct < 0 {
-// 		pct = 0
-// 	}
-// 	if pct > 100 {
-// 		pct = 100
-// 	}
-
-// 	ratio := pct / 100
-// 	filled := int(ratio * float64(width))
-
-// 	var color lipgloss.AdaptiveColor
-// 	switch {
-// 	case ratio < 0.50:
-// 		color = style.ColorSuccess()
-// 	case ratio < 0.80:
-// 		color = style.ColorWarning()
-// 	default:
-// 		color = style.ColorDanger()
-// 	}
-
-// 	style := lipgloss.NewStyle().Foreground(color)
-
-// 	return fmt.Sprintf(
-// 		"CPU[%s%s %3.0f%%]",
-// 		style.Render(strings.Repeat("|", filled)),
-// 		strings.Repeat(" ", width-filled),
-// 		pct,
-// 	)
-// }
-
-// func htopMemBar(used, total uint64, width int) string {
-// 	title := style.ActiveItem.Render("Mem")
-// 	if total == 0 {
-// 		return "Mem[ no limit ]"
-// 	}
-
-// 	usedGB := float64(used) / (1024 * 1024 * 1024)
-// 	totalGB := float64(total) / (1024 * 1024 * 1024)
-// 	pct := usedGB / totalGB
-
-// 	// Nombre de barres pleines
-// 	filled := int(float64(width) * pct)
-// 	if filled > width {
-// 		filled = width
-// 	}
-
-// 	// Couleur selon seuil
-// 	var color lipgloss.AdaptiveColor
-// 	switch {
-// 	case pct < 0.50:
-// 		color = style.ColorSuccess()
-// 	case pct < 0.80:
-// 		color = style.ColorWarning()
-// 	default:
-// 		color = style.ColorDanger()
-// 	}
-
-// 	style := lipgloss.NewStyle().Foreground(color)
-
-// 	bars := style.Render(strings.Repeat("|", filled))
-// 	padding := strings.Repeat(" ", width-filled)
-
-// 	return fmt.Sprintf(
-// 		"%s%s%s%s %.1fG/%.1fG%s",
-// 		title,
-// 		lipgloss.NewStyle().Width(1).Bold(true).Render("["),
-// 		bars,
-// 		padding,
-// 		usedGB,
-// 		totalGB,
-// 		lipgloss.NewStyle().Width(1).Bold(true).Render("]"),
-// 	)
-// }
+func (c ContainerItem) FilterValue() string { return c.Name() + c.hostTag() }
@@ -1,9 +1,8 @@
 package containers
 
 import (
-	"errors"
+	"fmt"
 	"log"
-	"os/exec"
 	"slices"
 	"strings"
 
@@ -14,22 +13,36 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/kernaxis/gmd/docker/cache"
 	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/docker/compose"
 	"github.com/kernaxis/gmd/docker/types"
 	"github.com/kernaxis/gmd/tui/commands"
 	"github.com/kernaxis/gmd/tui/controllers/containerstats"
+	updatectl "github.com/kernaxis/gmd/tui/controllers/containerupdate"
 	"github.com/kernaxis/gmd/tui/models/containerupdate"
+	"github.com/kernaxis/gmd/tui/models/inspect"
+	"github.com/kernaxis/gmd/tui/models/logs"
+	"github.com/kernaxis/gmd/tui/models/terminal"
+	"github.com/kernaxis/gmd/tui/models/updatehistory"
 	style "github.com/kernaxis/gmd/tui/styles"
 )
 
 type Model struct {
-	cli                   *client.Client
-	cache                 *cache.Cache
+	caches                []*cache.Cache
+	host                  string // host is the name of the selected host, empty when all hosts are shown.
 	list                  list.Model
 	loaded                bool
 	status                string
 	all                   bool
-	statsController       *containerstats.Controller
+	statsControllers      map[string]*containerstats.Controller
 	checkUpdateInProgress map[string]struct{}
+	updates               map[string]bool              // updates holds the result of the update checks by container key.
+	tags                  map[string]client.TagUpdates // tags holds the newer tags found by the update checks by container key.
+	checkErrors           map[string]error             // checkErrors holds the errors of the failed update checks by container key.
+	actions               map[string]string            // actions holds the state of the actions in progress by container key.
+	grouped               bool                         // grouped is set when the containers are grouped by compose project.
+	collapsed             map[string]bool              // collapsed holds the collapsed compose projects by project key.
+	stats                 map[string]*types.Stats      // stats holds the last stats of the running containers by container key.
+	marked                map[string]bool              // marked holds the containers marked for a batch update by container key.
 }
 
 type listKeyMap struct {
@@ -41,6 +54,13 @@ type listKeyMap struct {
 	updateContainer   key.Binding
 	recreateContainer key.Binding
 	execTerminal      key.Binding
+	toggleProject     key.Binding
+	showDetails       key.Binding
+	toggleGrouping    key.Binding
+	showHistory       key.Binding
+	markContainer     key.Binding
+	markUpdates       key.Binding
+	rollback          key.Binding
 }
 
 var keyMap = &listKeyMap{
@@ -76,9 +96,37 @@ var keyMap = &listKeyMap{
 		key.WithKeys("x"),
 		key.WithHelp("x", "open terminal"),
 	),
+	toggleProject: key.NewBinding(
+		key.WithKeys("enter", " "),
+		key.WithHelp("enter", "expand/collapse project"),
+	),
+	showDetails: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i/enter", "show details"),
+	),
+	toggleGrouping: key.NewBinding(
+		key.WithKeys("g"),
+		key.WithHelp("g", "toggle compose grouping"),
+	),
+	showHistory: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "update history"),
+	),
+	markContainer: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "mark for batch update"),
+	),
+	markUpdates: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "mark all with updates"),
+	),
+	rollback: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "roll back to previous image"),
+	),
 }
 
-func New(cli *client.Client, cache *cache.Cache) Model {
+func New(caches []*cache.Cache) Model {
 
 	items := []list.Item{}
 
@@ -94,26 +142,69 @@ func New(cli *client.Client, cache *cache.Cache) Model {
 			keyMap.startContainer,
 			keyMap.stopContainer,
 			keyMap.execTerminal,
+			keyMap.toggleProject,
+			keyMap.showDetails,
+			keyMap.toggleGrouping,
+			keyMap.showHistory,
+			keyMap.markContainer,
+			keyMap.markUpdates,
+			keyMap.rollback,
 		}
 	}
 
 	m := Model{
-		cli:                   cli,
-		cache:                 cache,
+		caches:                caches,
 		list:                  l,
 		all:                   false,
+		statsControllers:      make(map[string]*containerstats.Controller, len(caches)),
 		checkUpdateInProgress: make(map[string]struct{}),
+		updates:               make(map[string]bool),
+		tags:                  make(map[string]client.TagUpdates),
+		checkErrors:           make(map[string]error),
+		actions:               make(map[string]string),
+		grouped:               true,
+		collapsed:             make(map[string]bool),
+		stats:                 make(map[string]*types.Stats),
+		marked:                make(map[string]bool),
 		//imgs:   images,
 	}
 
-	m.statsController = containerstats.New(cli)
-	//m.statsController.Start()
+	for _, c := range caches {
+		m.statsControllers[c.Host()] = containerstats.New(c.Client())
+	}
 
 	return m
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(WaitStatsEvent(m.statsController.Events()))
+	cmds := make([]tea.Cmd, 0, len(m.statsControllers))
+	for _, c := range m.statsControllers {
+		cmds = append(cmds, WaitStatsEvent(c.Events()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// cache returns the cache of the given host.
+func (m Model) cache(host string) *cache.Cache {
+	for _, c := range m.caches {
+		if c.Host() == host {
+			return c
+		}
+	}
+	return nil
+}
+
+// client returns the Docker client of the given host.
+func (m Model) client(host string) client.Client {
+	if c := m.cache(host); c != nil {
+		return c.Client()
+	}
+	return nil
+}
+
+// visible reports whether the containers of the given host are shown.
+func (m Model) visible(host string) bool {
+	return m.host == "" || m.host == host
 }
 
 func (m Model) IsSearching() bool {
@@ -139,53 +230,127 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.ToggleAll()
 			return m, nil
 
+		case key.Matches(msg, keyMap.toggleGrouping):
+			m.grouped = !m.grouped
+			m.reload()
+			return m, nil
+
+		case key.Matches(msg, keyMap.toggleProject):
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				k := projectKey(p.host, p.name)
+				m.collapsed[k] = !m.collapsed[k]
+				m.reload()
+				return m, nil
+			}
+			return m, m.showDetails()
+
+		case key.Matches(msg, keyMap.showDetails):
+			return m, m.showDetails()
+
 		case key.Matches(msg, keyMap.showLogs):
-			cmd := exec.Command("docker", "logs", "-f", "--tail=200", m.list.SelectedItem().(ContainerItem).id)
-			return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
-				return nil
+			c, ok := m.list.SelectedItem().(ContainerItem)
+			if !ok {
+				return m, nil
+			}
+			cli := m.client(c.host)
+			return m, commands.SwitchPageCmd(func() tea.Model {
+				return logs.New(cli, c.id, c.name)
+			})
+
+		case key.Matches(msg, keyMap.showHistory):
+			host, name := "", ""
+			if c, ok := m.list.SelectedItem().(ContainerItem); ok {
+				host, name = c.host, strings.TrimPrefix(c.name, "/")
+			}
+			return m, commands.SwitchPageCmd(func() tea.Model {
+				return updatehistory.New(host, name)
 			})
 
 		case key.Matches(msg, keyMap.restartContainer):
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				return m, m.projectAction(p, commands.RestartContainerAction)
+			}
 			if c, ok := m.list.SelectedItem().(ContainerItem); ok && c.state == container.StateRunning {
-				m.updateContainerActionState(c.id, container.StateRestarting)
+				m.updateContainerActionState(c.host, c.id, container.StateRestarting)
 				m.status = style.StatusBar().Render("Restarting container " + m.list.SelectedItem().(ContainerItem).name)
-				return m, commands.ContainerCmd(m.cli, commands.RestartContainerAction, c.id)
+				return m, commands.ContainerCmd(m.client(c.host), commands.RestartContainerAction, c.id)
 			}
 			return m, nil
 
 		case key.Matches(msg, keyMap.startContainer):
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				return m, m.projectAction(p, commands.StartContainerAction)
+			}
 			if c, ok := m.list.SelectedItem().(ContainerItem); ok && !slices.Contains([]string{container.StateRunning, container.StateRestarting}, c.state) {
-				return m, commands.ContainerCmd(m.cli, commands.StartContainerAction, c.id)
+				return m, commands.ContainerCmd(m.client(c.host), commands.StartContainerAction, c.id)
 			}
 			return m, nil
 
 		case key.Matches(msg, keyMap.stopContainer):
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				return m, m.projectAction(p, commands.StopContainerAction)
+			}
 			if c, ok := m.list.SelectedItem().(ContainerItem); ok {
-				return m, commands.ContainerCmd(m.cli, commands.StopContainerAction, c.id)
+				return m, commands.ContainerCmd(m.client(c.host), commands.StopContainerAction, c.id)
 			}
 			return m, nil
 
+		case key.Matches(msg, keyMap.markContainer):
+			m.toggleMark()
+			return m, nil
+
+		case key.Matches(msg, keyMap.markUpdates):
+			m.markUpdates()
+			return m, nil
+
 		case key.Matches(msg, keyMap.updateContainer):
+			if len(m.marked) > 0 {
+				return m, m.markedUpdate()
+			}
+			if p, ok := m.list.SelectedItem().(ProjectItem); ok {
+				return m, m.projectUpdate(p)
+			}
 			if c, ok := m.list.SelectedItem().(ContainerItem); ok {
-				if c.update != nil && *c.update {
-					c, _ := m.cache.Container(c.id)
+				if !c.policy.Checked() {
+					m.status = style.StatusBar().Render(fmt.Sprintf("Updates of %s are disabled by its update policy (%s)", c.Name(), c.policy.Name()))
+					return m, nil
+				}
+				if c.update == nil && c.checkErr != nil {
+					m.status = style.Danger().Render(fmt.Sprintf("Update check of %s failed: %s", c.Name(), c.checkErr))
+					return m, nil
+				}
+				update := c.update != nil && *c.update
+				if update || c.tags.Level() != "" {
+					cli := m.client(c.host)
+					tags := c.tags
+					c, _ := m.cache(c.host).Container(c.id)
 					return m, commands.SwitchPageCmd(func() tea.Model {
-						u := containerupdate.New(c, m.cli)
+						u := containerupdate.NewWithTags(c, tags, update, cli)
 						return u
 					})
 				}
 			}
 			return m, nil
 
+		case key.Matches(msg, keyMap.rollback):
+			if c, ok := m.list.SelectedItem().(ContainerItem); ok {
+				return m, PreviousImageCmd(m.client(c.host), c.id, strings.TrimPrefix(c.name, "/"))
+			}
+			return m, nil
+
 		case key.Matches(msg, keyMap.recreateContainer):
 			if c, ok := m.list.SelectedItem().(ContainerItem); ok {
-				return m, commands.ContainerCmd(m.cli, commands.RecreateContainerAction, c.id)
+				return m, commands.ContainerCmd(m.client(c.host), commands.RecreateContainerAction, c.id)
 			}
 			return m, nil
 		case key.Matches(msg, keyMap.execTerminal):
-			cmd := exec.Command("docker", "exec", "-it", m.list.SelectedItem().(ContainerItem).id, "/bin/sh")
-			return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
-				return nil
+			c, ok := m.list.SelectedItem().(ContainerItem)
+			if !ok {
+				return m, nil
+			}
+			cli := m.client(c.host)
+			return m, commands.SwitchPageCmd(func() tea.Model {
+				return terminal.New(cli, c.id, c.name)
 			})
 		}
 	case cache.Event:
@@ -194,7 +359,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.loaded = true
 			}
 			log.Printf("received container event %+v", msg)
-			cmds = append(cmds, m.initialLoad())
+			cmds = append(cmds, m.initialLoad(msg.Host))
 		}
 		if msg.EventType == cache.ContainerEventType {
 			if m.loaded {
@@ -203,38 +368,55 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		}
+	case commands.SelectHostMsg:
+		m.host = msg.Host
+		m.reload()
 	case ContainerUpdateMsg:
 		log.Printf("received container update event %+v", msg)
+		k := containerKey(msg.Host, msg.ContainerID)
 		if msg.Err == nil {
-			for i, c := range m.list.Items() {
-				if container, ok := c.(ContainerItem); ok && container.id == msg.ContainerID {
-					b := msg.Update
-					container.update = &b
-					container.RenderContent()
-					m.list.SetItem(i, container)
-					break
-				}
-			}
+			m.updates[k] = msg.Update
+			m.tags[k] = msg.Tags
+			delete(m.checkErrors, k)
 		} else {
 			log.Printf("error checking update for container %s: %s", msg.ContainerID, msg.Err)
+			m.checkErrors[k] = msg.Err
 		}
-		delete(m.checkUpdateInProgress, msg.ContainerID)
+		m.reload()
+		delete(m.checkUpdateInProgress, containerKey(msg.Host, msg.ContainerID))
+	case PreviousImageMsg:
+		if msg.Err != nil {
+			m.status = style.StatusBar().Render(msg.Err.Error())
+			return m, nil
+		}
+		c, err := m.cache(msg.Host).Container(msg.ContainerID)
+		if err != nil {
+			m.status = style.Danger().Render(err.Error())
+			return m, nil
+		}
+		cli := m.client(msg.Host)
+		return m, commands.SwitchPageCmd(func() tea.Model {
+			return containerupdate.NewRollback(c, msg.Previous, cli)
+		})
 	case commands.ContainerActionMsg:
 		if msg.Err != nil {
 			m.status = style.Danger().Render(msg.Err.Error())
 		} else {
 			m.status = ""
-			m.updateContainerActionState(msg.ContainerID, "")
+			m.updateContainerActionState(msg.Host, msg.ContainerID, "")
+		}
+	case commands.ProjectActionMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.status = style.Success().Render(fmt.Sprintf("Project %s: %s done", msg.Project, msg.Action))
 		}
-		// case containerstats.StatsMsg:
-		// 	for i, c := range m.list.Items() {
-		// 		if container, ok := c.(ContainerItem); ok && container.id == msg.ID {
-		// 			container.RenderStats(msg.Stats)
-		// 			m.list.SetItem(i, container)
-		// 			break
-		// 		}
-		// 	}
-		// 	return m, WaitStatsEvent(m.statsController.Events())
+	case containerstats.StatsMsg:
+		m.setStats(msg)
+		if c, ok := m.statsControllers[msg.Host]; ok {
+			return m, WaitStatsEvent(c.Events())
+		}
+		return m, nil
 	}
 
 	newList, cmd := m.list.Update(msg)
@@ -254,154 +436,404 @@ func (m Model) View() string {
 	)
 }
 
-// initialLoad loads all containers from the cache and sets the list with the
-// retrieved containers. It also checks for updates for all containers
-func (m *Model) initialLoad() tea.Cmd {
+// initialLoad loads all containers of the given host from its cache and sets
+// the list with the retrieved containers. It also checks for updates for all
+// containers of the host.
+func (m *Model) initialLoad(host string) tea.Cmd {
+	c := m.cache(host)
+	if c == nil {
+		return nil
+	}
 
-	var containers = m.cache.Containers()
+	var containers = c.Containers()
 
 	var cmds = make([]tea.Cmd, 0, len(containers))
 
-	slices.SortFunc(containers, func(a, b types.Container) int {
-		return strings.Compare(a.Name, b.Name)
-	})
-
-	itemList := make([]list.Item, 0, len(containers))
 	for _, item := range containers {
-		container := NewContainerItem(item)
-		if m.all {
-			container.show = true
-		} else {
-			container.show = item.State.Running || item.State.Restarting
+		if !types.UpdatePolicyOf(item.Config.Labels).Checked() {
+			continue
 		}
-		container.RenderContent()
-		//m.statsController.AddContainer(container.id)
-		itemList = append(itemList, container)
-		m.checkUpdateInProgress[container.id] = struct{}{}
-		cmds = append(cmds, CheckContainerUpdate(m.cli, container.id))
+		m.checkUpdateInProgress[containerKey(host, item.ID)] = struct{}{}
+		cmds = append(cmds, CheckContainerUpdate(c.Client(), item.ID))
 	}
-	m.list.SetItems(itemList)
+
+	m.reload()
 	return tea.Batch(cmds...)
 }
 
+// reload rebuilds the list from the caches of the visible hosts.
+// The result of the update checks already done and the actions in progress are kept.
+//
+// When the containers are grouped, the containers of a compose project are
+// listed under a header summarizing the project, projects first, then the
+// standalone containers.
+func (m *Model) reload() {
+	var projects []list.Item
+	var standalone []list.Item
+	previous := m.stats
+	m.stats = make(map[string]*types.Stats, len(previous))
+	for _, c := range m.caches {
+		if !m.visible(c.Host()) {
+			m.statsControllers[c.Host()].Sync(nil)
+			continue
+		}
+
+		running := make([]string, 0)
+		for _, item := range c.Containers() {
+			if item.State.Running {
+				running = append(running, item.ID)
+				if s, ok := previous[containerKey(c.Host(), item.ID)]; ok {
+					m.stats[containerKey(c.Host(), item.ID)] = s
+				}
+			}
+		}
+		m.statsControllers[c.Host()].Sync(running)
+
+		members := make(map[string][]ContainerItem)
+		for _, item := range c.Containers() {
+			container := m.newContainerItem(c.Host(), item)
+			if m.all {
+				container.show = true
+			} else {
+				container.show = item.State.Running || item.State.Restarting
+			}
+			if m.grouped && container.project != "" {
+				container.grouped = true
+				container.RenderContent()
+				members[container.project] = append(members[container.project], container)
+				continue
+			}
+			standalone = append(standalone, container)
+		}
+
+		for name, containers := range members {
+			slices.SortFunc(containers, func(a, b ContainerItem) int {
+				return strings.Compare(a.Name(), b.Name())
+			})
+			p := NewProjectItem(c.Host(), name, containers)
+			p.showHost = len(m.caches) > 1
+			p.collapsed = m.collapsed[projectKey(c.Host(), name)]
+			p.RenderContent()
+			projects = append(projects, p)
+		}
+	}
+
+	slices.SortFunc(projects, compareItems)
+	slices.SortFunc(standalone, compareItems)
+
+	itemList := make([]list.Item, 0, len(projects)+len(standalone))
+	for _, item := range projects {
+		p := item.(ProjectItem)
+		itemList = append(itemList, p)
+		if !p.collapsed {
+			for _, c := range p.containers {
+				itemList = append(itemList, c)
+			}
+		}
+	}
+	itemList = append(itemList, standalone...)
+
+	m.list.SetItems(itemList)
+}
+
+// newContainerItem returns the rendered item of the given container,
+// with the result of its last update check and its action in progress.
+func (m *Model) newContainerItem(host string, container types.Container) ContainerItem {
+	c := NewContainerItem(host, container)
+	c.showHost = len(m.caches) > 1
+	if update, ok := m.updates[containerKey(host, c.id)]; ok {
+		c.update = &update
+	}
+	c.tags = m.tags[containerKey(host, c.id)]
+	c.checkErr = m.checkErrors[containerKey(host, c.id)]
+	c.actionState = m.actions[containerKey(host, c.id)]
+	c.stats = m.stats[containerKey(host, c.id)]
+	c.marked = m.marked[containerKey(host, c.id)]
+	c.RenderContent()
+	return c
+}
+
+// showDetails pushes the detail page of the selected container.
+func (m Model) showDetails() tea.Cmd {
+	c, ok := m.list.SelectedItem().(ContainerItem)
+	if !ok {
+		return nil
+	}
+	cc := m.cache(c.host)
+	return commands.SwitchPageCmd(func() tea.Model {
+		return inspect.New(cc, c.id, c.name)
+	})
+}
+
+// setStats records the stats of a container and renders its item again.
+func (m *Model) setStats(msg containerstats.StatsMsg) {
+	for i, item := range m.list.Items() {
+		c, ok := item.(ContainerItem)
+		if !ok || c.host != msg.Host || c.id != msg.ID {
+			continue
+		}
+		// ignore a late sample of a stopped container
+		if c.state == container.StateRunning {
+			stats := msg.Stats
+			m.stats[containerKey(msg.Host, msg.ID)] = &stats
+			c.SetStats(&stats)
+			m.list.SetItem(i, c)
+		}
+		return
+	}
+}
+
+// compareItems orders the containers and the projects by name, then by host.
+func compareItems(a, b list.Item) int {
+	na, ha := itemName(a)
+	nb, hb := itemName(b)
+	if r := strings.Compare(na, nb); r != 0 {
+		return r
+	}
+	return strings.Compare(ha, hb)
+}
+
+// itemName returns the name and the host of a container or a project item.
+func itemName(item list.Item) (string, string) {
+	switch i := item.(type) {
+	case ProjectItem:
+		return i.name, i.host
+	case ContainerItem:
+		return i.Name(), i.host
+	}
+	return "", ""
+}
+
 // handleContainerEvent handles a container event from the cache.
 //
-// The function first retrieves the ContainerItem from the cache with the given id.
-// If the container is not found, it removes the container from the list.
-// If the container is found, it gets the old ContainerItem from the list with the given id.
-// If the old container is not found, it adds the new container to the list.
-// If the old container is found, it updates the old container with the new container.
+// The function first retrieves the container from the cache with the given id.
+// If the container is not found, its update check result is forgotten.
+// If the container was never checked for update, a check is started.
+// The list is then rebuilt, as the event may change the aggregated state of a project.
 //
-// The function returns a tea.Cmd that executes the update if needed.
+// The function returns a tea.Cmd that checks the update if needed.
 func (m *Model) handleContainerEvent(msg cache.Event) tea.Cmd {
-	newContainer, err := m.cache.Container(msg.ActorID)
-
-	if err != nil {
-		m.removeContainer(msg.ActorID)
+	c := m.cache(msg.Host)
+	if c == nil {
 		return nil
 	}
 
-	oldContainer, oldContainerIdx, err := m.getContainerWithIndex(msg.ActorID)
+	k := containerKey(msg.Host, msg.ActorID)
 
+	cont, err := c.Container(msg.ActorID)
 	if err != nil {
-		return m.addNewContainer(newContainer)
+		delete(m.updates, k)
+		delete(m.tags, k)
+		delete(m.checkErrors, k)
+		delete(m.actions, k)
+		delete(m.marked, k)
+		m.reload()
+		return nil
 	}
 
-	return m.updateContainer(newContainer, oldContainer, oldContainerIdx)
-}
+	if !m.visible(msg.Host) {
+		return nil
+	}
 
-// getContainerWithIndex returns the ContainerItem with the given id and its index in the model's list.
-// If the container is not found, it returns an empty ContainerItem, -1 as the index, and an error.
-func (m *Model) getContainerWithIndex(id string) (ContainerItem, int, error) {
-	for i, item := range m.list.Items() {
-		if item.(ContainerItem).id == id {
-			return item.(ContainerItem), i, nil
+	var cmd tea.Cmd
+	if _, ok := m.updates[k]; !ok && types.UpdatePolicyOf(cont.Config.Labels).Checked() {
+		if _, ok := m.checkUpdateInProgress[k]; !ok {
+			m.checkUpdateInProgress[k] = struct{}{}
+			cmd = CheckContainerUpdate(c.Client(), msg.ActorID)
 		}
 	}
-	return ContainerItem{}, -1, errors.New("container not found")
+
+	m.reload()
+	return cmd
 }
 
-// removeContainer removes a container from the list.
-//
-// The function iterates over the list of containers and removes the first container that matches the given id.
-// If no container is found, the function does nothing.
-// The function does not return anything.
-func (m *Model) removeContainer(id string) {
-	for i, item := range m.list.Items() {
-		if item.(ContainerItem).id == id {
-			m.list.RemoveItem(i)
-			return
+// containerKey returns the key identifying a container across all the hosts.
+func containerKey(host, id string) string {
+	return host + "/" + id
+}
+
+// projectKey returns the key identifying a compose project across all the hosts.
+func projectKey(host, name string) string {
+	return host + "/" + name
+}
+
+// projectContainers returns the containers of the given compose project from the cache.
+func (m *Model) projectContainers(p ProjectItem) []types.Container {
+	c := m.cache(p.host)
+	if c == nil {
+		return nil
+	}
+	var out []types.Container
+	for _, cont := range c.Containers() {
+		if compose.Project(cont) == p.name {
+			out = append(out, cont)
 		}
 	}
+	return out
 }
 
-// addNewContainer adds a new container to the list and checks for update.
-//
-// The function sorts the list by container name and adds the new container at the end.
-// It also adds the container to the list of containers to check for update.
-// The function returns a command to check for container update.
-//
-// The function also renders the content of the new container.
-func (m *Model) addNewContainer(container types.Container) tea.Cmd {
-	newContainer := NewContainerItem(container)
-	newContainer.RenderContent()
-	items := m.list.Items()
-	items = append(items, newContainer)
-	slices.SortFunc(items, func(a, b list.Item) int {
-		return strings.Compare(a.(ContainerItem).Name(), b.(ContainerItem).Name())
-	})
-	m.list.SetItems(items)
-	m.checkUpdateInProgress[newContainer.id] = struct{}{}
-	return CheckContainerUpdate(m.cli, newContainer.id)
+// projectAction runs the action on all the containers of the project, in dependency order.
+func (m *Model) projectAction(p ProjectItem, action commands.Action) tea.Cmd {
+	containers := m.projectContainers(p)
+	if _, err := compose.Order(containers); err != nil {
+		m.status = style.Danger().Render(err.Error())
+		return nil
+	}
+	m.status = style.StatusBar().Render(fmt.Sprintf("Project %s: %s in progress", p.name, action))
+	return commands.ProjectCmd(m.client(p.host), action, p.name, containers)
 }
 
-func (m *Model) updateContainer(newContainer types.Container, oldContainer ContainerItem, index int) tea.Cmd {
-	var cmd tea.Cmd = nil
-	c := NewContainerItem(newContainer)
+// projectUpdate opens the batch update screen for the containers of the
+// project having an update available.
+func (m *Model) projectUpdate(p ProjectItem) tea.Cmd {
+	updates := make(map[string]bool)
+	for _, c := range p.Updates() {
+		updates[c.id] = true
+	}
+	if len(updates) == 0 {
+		m.status = style.StatusBar().Render(fmt.Sprintf("No update available for project %s", p.name))
+		return nil
+	}
 
-	// update flag
-	if oldContainer.update != nil {
-		c.update = oldContainer.update
-	} else {
-		if _, ok := m.checkUpdateInProgress[c.id]; !ok {
-			m.checkUpdateInProgress[c.id] = struct{}{}
-			cmd = CheckContainerUpdate(m.cli, c.id)
+	var targets []updatectl.Target
+	for _, c := range m.projectContainers(p) {
+		if updates[c.ID] {
+			targets = append(targets, m.updateTarget(p.host, c))
 		}
 	}
+	return commands.SwitchPageCmd(func() tea.Model {
+		return containerupdate.NewBatch("project "+p.name, targets)
+	})
+}
 
-	c.actionState = oldContainer.actionState
+// updateTarget returns the container to update in a batch, with the image it
+// is updated to: the newest tag followed by its update policy, if any, or its
+// current image.
+func (m *Model) updateTarget(host string, c types.Container) updatectl.Target {
+	image := c.Config.Image
+	if tag := m.tags[containerKey(host, c.ID)].Target(types.UpdatePolicyOf(c.Config.Labels).Track); tag != "" {
+		image = client.WithTag(image, tag)
+	}
+	return updatectl.Target{Client: m.client(host), Container: updatectl.WithImage(c, image)}
+}
 
-	c.RenderContent()
-	m.list.SetItem(index, c)
-	return cmd
+// available reports whether the container has an update to apply in a batch.
+func (m *Model) available(c ContainerItem) bool {
+	return (c.update != nil && *c.update) || c.tags.Target(c.policy.Track) != ""
+}
 
+// toggleMark marks the selected container for a batch update, or unmarks it.
+// On a project, the containers of the project having an update are marked.
+func (m *Model) toggleMark() {
+	var items []ContainerItem
+	switch i := m.list.SelectedItem().(type) {
+	case ProjectItem:
+		for _, c := range i.containers {
+			if m.available(c) {
+				items = append(items, c)
+			}
+		}
+		if len(items) == 0 {
+			m.status = style.StatusBar().Render(fmt.Sprintf("No update available for project %s", i.name))
+			return
+		}
+	case ContainerItem:
+		if !i.policy.Checked() {
+			m.status = style.StatusBar().Render(fmt.Sprintf("Updates of %s are disabled by its update policy (%s)", i.Name(), i.policy.Name()))
+			return
+		}
+		items = append(items, i)
+	default:
+		return
+	}
+
+	// a project is unmarked when all its containers are marked
+	mark := slices.ContainsFunc(items, func(c ContainerItem) bool { return !c.marked })
+	for _, c := range items {
+		if mark {
+			m.marked[containerKey(c.host, c.id)] = true
+		} else {
+			delete(m.marked, containerKey(c.host, c.id))
+		}
+	}
+	m.markStatus()
+	m.reload()
 }
 
-func (m *Model) updateContainerActionState(id string, state string) {
-	for i, item := range m.list.Items() {
-		if c := item.(ContainerItem); c.id == id {
-			c.actionState = state
-			c.RenderContent()
-			m.list.SetItem(i, c)
+// markUpdates marks all the shown containers having an update, or unmarks
+// them all when they are already marked.
+func (m *Model) markUpdates() {
+	var items []ContainerItem
+	for _, item := range m.list.Items() {
+		if c, ok := item.(ContainerItem); ok && c.show && m.available(c) {
+			items = append(items, c)
+		}
+	}
+	for _, item := range m.list.Items() {
+		if p, ok := item.(ProjectItem); ok && p.collapsed {
+			for _, c := range p.containers {
+				if m.available(c) {
+					items = append(items, c)
+				}
+			}
+		}
+	}
+	if len(items) == 0 {
+		m.status = style.StatusBar().Render("No update available")
+		return
+	}
+
+	if !slices.ContainsFunc(items, func(c ContainerItem) bool { return !c.marked }) {
+		clear(m.marked)
+	} else {
+		for _, c := range items {
+			m.marked[containerKey(c.host, c.id)] = true
 		}
 	}
+	m.markStatus()
+	m.reload()
 }
 
-func (m *Model) ToggleAll() {
-	m.all = !m.all
+// markStatus tells how many containers are marked for a batch update.
+func (m *Model) markStatus() {
+	if len(m.marked) == 0 {
+		m.status = ""
+		return
+	}
+	m.status = style.StatusBar().Render(fmt.Sprintf("%d container(s) marked, press u to update them", len(m.marked)))
+}
 
-	for i, item := range m.list.Items() {
-		if c, ok := item.(ContainerItem); ok {
-			if m.all {
-				c.show = true
-				m.list.SetItem(i, c)
-			} else {
-				c.show = false
-				if c.state == container.StateRunning || c.state == container.StateRestarting {
-					c.show = true
-				}
-				m.list.SetItem(i, c)
+// markedUpdate opens the batch update screen for the marked containers, and clears the marks.
+func (m *Model) markedUpdate() tea.Cmd {
+	var targets []updatectl.Target
+	for _, c := range m.caches {
+		for _, cont := range c.Containers() {
+			if m.marked[containerKey(c.Host(), cont.ID)] {
+				targets = append(targets, m.updateTarget(c.Host(), cont))
 			}
 		}
 	}
+	clear(m.marked)
+	m.status = ""
+	m.reload()
+	if len(targets) == 0 {
+		return nil
+	}
+	return commands.SwitchPageCmd(func() tea.Model {
+		return containerupdate.NewBatch(fmt.Sprintf("%d container(s)", len(targets)), targets)
+	})
+}
+
+func (m *Model) updateContainerActionState(host, id string, state string) {
+	if state == "" {
+		delete(m.actions, containerKey(host, id))
+	} else {
+		m.actions[containerKey(host, id)] = state
+	}
+	m.reload()
+}
+
+func (m *Model) ToggleAll() {
+	m.all = !m.all
+	m.reload()
 }
@@ -0,0 +1,139 @@
+package containers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types/container"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// ProjectItem is the header of the containers of a compose project.
+type ProjectItem struct {
+	host       string
+	showHost   bool
+	name       string
+	containers []ContainerItem
+	collapsed  bool
+	content    string
+}
+
+func NewProjectItem(host, name string, containers []ContainerItem) ProjectItem {
+	return ProjectItem{host: host, name: name, containers: containers}
+}
+
+func (p *ProjectItem) RenderContent() {
+	p.content = p.Render(false)
+}
+
+func (p ProjectItem) Render(selected bool) string {
+	arrow := "▾ "
+	if p.collapsed {
+		arrow = "▸ "
+	}
+
+	title := style.Title().Render(arrow + p.name)
+	services := fmt.Sprintf("compose · %d container(s)", len(p.containers))
+	if p.showHost {
+		services += " @" + p.host
+	}
+	subtitle := style.Subtitle().Render(services)
+
+	col1 := lipgloss.JoinVertical(lipgloss.Left, title, subtitle)
+	col2 := lipgloss.JoinHorizontal(lipgloss.Center, p.UpdateFlag(), " ", p.Status())
+	col3 := lipgloss.JoinHorizontal(lipgloss.Center, " ", style.Subtitle().Render(strings.Join(p.Names(), ", ")))
+
+	col1 = colNameStyle.Render(col1)
+	col2 = colStateStyle.Render(col2)
+	col3 = colImageStyle.Render(col3)
+
+	if selected {
+		col1 = style.Bold().Render(col1)
+		col2 = style.Bold().Render(col2)
+		col3 = style.Bold().Render(col3)
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Center, col1, " ", col2, " ", col3)
+}
+
+// Name returns the name of the compose project.
+func (p ProjectItem) Name() string {
+	return p.name
+}
+
+// Host returns the name of the Docker host running the project.
+func (p ProjectItem) Host() string {
+	return p.host
+}
+
+// Names returns the names of the containers of the project.
+func (p ProjectItem) Names() []string {
+	names := make([]string, len(p.containers))
+	for i, c := range p.containers {
+		names[i] = c.Name()
+	}
+	return names
+}
+
+// Running returns the number of running containers of the project.
+func (p ProjectItem) Running() int {
+	n := 0
+	for _, c := range p.containers {
+		if c.state == container.StateRunning {
+			n++
+		}
+	}
+	return n
+}
+
+// Status returns the aggregated status of the containers of the project.
+func (p ProjectItem) Status() string {
+	running := p.Running()
+	count := fmt.Sprintf(" %d/%d", running, len(p.containers))
+	switch running {
+	case len(p.containers):
+		return ContainerRuningState + count
+	case 0:
+		return ContainerExitedState + count
+	default:
+		return ProjectPartialState + count
+	}
+}
+
+// UpdateFlag returns the update flag of the project: an update is available
+// when one of its containers has one, and the project is up to date when all
+// its containers have been checked and are. The containers whose policy
+// disables the checks are left out.
+func (p ProjectItem) UpdateFlag() string {
+	checked := true
+	for _, c := range p.containers {
+		if !c.policy.Checked() {
+			continue
+		}
+		if c.update == nil {
+			checked = false
+			continue
+		}
+		if *c.update {
+			return UpdateAvailableFlag
+		}
+	}
+	if !checked {
+		return UpdateUnavailable
+	}
+	return UpToDateFlag
+}
+
+// Updates returns the containers of the project having an update available.
+func (p ProjectItem) Updates() []ContainerItem {
+	var out []ContainerItem
+	for _, c := range p.containers {
+		if c.update != nil && *c.update {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (p ProjectItem) FilterValue() string { return p.name + strings.Join(p.Names(), " ") }
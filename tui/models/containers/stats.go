@@ -0,0 +1,63 @@
+package containers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/kernaxis/gmd/docker/types"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// barWidth is the number of cells of the CPU and memory bars.
+const barWidth = 10
+
+// statsLines renders the stats of a container on two lines: htop-like CPU and
+// memory bars, then the network and block IO rates.
+func statsLines(s *types.Stats) (string, string) {
+	if s == nil {
+		return style.Inactive().Render("CPU[" + strings.Repeat(" ", barWidth) + "  --]  Mem[ -- ]"), ""
+	}
+
+	// a container using all its CPUs is full, not only one of them
+	cpu := s.CPUPercent
+	if s.CPUs > 1 {
+		cpu /= float64(s.CPUs)
+	}
+	cpuBar := fmt.Sprintf("CPU[%s %3.0f%%]", bar(cpu, barWidth), s.CPUPercent)
+
+	memBar := "Mem[ no limit ]"
+	if s.MemoryLimit > 0 {
+		memBar = fmt.Sprintf("Mem[%s %s/%s]", bar(s.MemoryPercent(), barWidth),
+			humanize.IBytes(s.MemoryUsage), humanize.IBytes(s.MemoryLimit))
+	}
+
+	io := style.Subtitle().Render(fmt.Sprintf("↓%s ↑%s  r %s w %s",
+		rateString(s.NetRx), rateString(s.NetTx), rateString(s.BlockRead), rateString(s.BlockWrite)))
+
+	return cpuBar + "  " + memBar, io
+}
+
+// bar renders a bar filled at pct percents, colored by threshold like htop.
+func bar(pct float64, width int) string {
+	pct = max(0, min(pct, 100))
+	filled := int(pct / 100 * float64(width))
+
+	var color lipgloss.AdaptiveColor
+	switch {
+	case pct < 50:
+		color = style.ColorSuccess()
+	case pct < 80:
+		color = style.ColorWarning()
+	default:
+		color = style.ColorDanger()
+	}
+
+	return lipgloss.NewStyle().Foreground(color).Render(strings.Repeat("|", filled)) + strings.Repeat(" ", width-filled)
+}
+
+// rateString renders a rate in bytes per second.
+func rateString(rate float64) string {
+	return humanize.Bytes(uint64(rate)) + "/s"
+}
@@ -8,14 +8,20 @@ import (
 var (
 	colNameStyle    = lipgloss.NewStyle().Width(40)
 	colStateStyle   = lipgloss.NewStyle().Width(20)
-	colImageStyle   = lipgloss.NewStyle().Width(70)
-	colAddressStyle = lipgloss.NewStyle().Width(40)
+	colImageStyle   = lipgloss.NewStyle().Width(50)
+	colAddressStyle = lipgloss.NewStyle().Width(28)
+	colStatsStyle   = lipgloss.NewStyle().Width(58)
 )
 
 var (
 	UpdateUnavailable   = style.Inactive().Render("-")
 	UpToDateFlag        = style.Success().Render("✓")
 	UpdateAvailableFlag = style.Danger().Render("⚠")
+	UpdateSkippedFlag   = style.Inactive().Render("⊘")
+	NewerTagFlag        = style.Warning()
+	AuthRequiredFlag    = style.Warning().Render("🔒")
+	CheckFailedFlag     = style.Danger().Render("✗")
+	MarkedFlag          = style.Warning().Render("● ")
 )
 
 var (
@@ -24,4 +30,5 @@ var (
 	ContainerCreatedState    = style.Inactive().Render("created")
 	ContainerPausedState     = style.Inactive().Render("paused")
 	ContainerRestartingState = style.Warning().Render("restarting")
+	ProjectPartialState      = style.Warning().Render("partial")
 )
@@ -0,0 +1,263 @@
+package containerupdate
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kernaxis/gmd/tui/commands"
+	"github.com/kernaxis/gmd/tui/controllers/containerupdate"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// BatchModel updates several containers, showing the progress of each of them and a final report.
+// The update order is shown first, the update starts once confirmed.
+type BatchModel struct {
+	name        string // name describes the updated containers in the title.
+	batch       *containerupdate.Batch
+	err         error // err is the error ordering the containers, the batch cannot run.
+	parallelism int   // parallelism is the number of containers recreated at the same time.
+	showHost    bool  // showHost is set when the containers run on several hosts.
+
+	started   time.Time
+	completed time.Time
+	offset    int // offset is the index of the first job shown.
+	width     int
+	height    int
+}
+
+type batchKeyMapping struct {
+	start key.Binding
+	close key.Binding
+	more  key.Binding
+	less  key.Binding
+	up    key.Binding
+	down  key.Binding
+}
+
+var batchKeyMap = &batchKeyMapping{
+	start: key.NewBinding(
+		key.WithKeys("enter", "y"),
+		key.WithHelp("enter", "update"),
+	),
+	close: key.NewBinding(
+		key.WithKeys("esc", "enter"),
+		key.WithHelp("esc", "back"),
+	),
+	more: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+/-", "parallel recreations"),
+	),
+	less: key.NewBinding(
+		key.WithKeys("-"),
+	),
+	up: key.NewBinding(
+		key.WithKeys("up", "k"),
+	),
+	down: key.NewBinding(
+		key.WithKeys("down", "j"),
+	),
+}
+
+// NewBatch returns a model updating the targets, in dependency order.
+// The name describes the updated containers in the title.
+func NewBatch(name string, targets []containerupdate.Target) BatchModel {
+	m := BatchModel{name: name, parallelism: containerupdate.Parallelism}
+	m.batch, m.err = containerupdate.NewBatch(targets)
+	for _, t := range targets {
+		if t.Client.Endpoint().Name() != targets[0].Client.Endpoint().Name() {
+			m.showHost = true
+		}
+	}
+	return m
+}
+
+func (m BatchModel) Init() tea.Cmd {
+	return nil
+}
+
+func startBatch(b *containerupdate.Batch, parallelism int) tea.Cmd {
+	return func() tea.Msg {
+		b.Start(parallelism)
+		return containerupdate.ControllerUpdateMsg{}
+	}
+}
+
+func (m BatchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height - 3
+	case containerupdate.ControllerUpdateMsg:
+		return m, waitUpdateEvent(m.batch.Events())
+	case UpdateFinishedMsg:
+		m.completed = time.Now()
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, batchKeyMap.up):
+			m.offset = max(m.offset-1, 0)
+			return m, nil
+		case key.Matches(msg, batchKeyMap.down):
+			m.offset = min(m.offset+1, max(len(m.batch.Jobs())-m.rows(), 0))
+			return m, nil
+		}
+
+		switch {
+		case m.err != nil || !m.completed.IsZero():
+			if key.Matches(msg, batchKeyMap.close) {
+				return m, commands.SwitchPageCmd(nil)
+			}
+		case m.started.IsZero():
+			switch {
+			case key.Matches(msg, batchKeyMap.start):
+				m.started = time.Now()
+				return m, startBatch(m.batch, m.parallelism)
+			case key.Matches(msg, batchKeyMap.more):
+				m.parallelism = min(m.parallelism+1, len(m.batch.Jobs()))
+			case key.Matches(msg, batchKeyMap.less):
+				m.parallelism = max(m.parallelism-1, 1)
+			case key.Matches(msg, keyMap.cancel):
+				return m, commands.SwitchPageCmd(nil)
+			}
+		}
+	}
+	return m, nil
+}
+
+// rows returns the number of jobs shown at the same time.
+func (m BatchModel) rows() int {
+	return max(m.height-2, 1)
+}
+
+func (m BatchModel) View() string {
+	if m.err != nil {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			style.Title().Render("Batch update · "+m.name),
+			style.Danger().Render(m.err.Error()),
+			style.Subtitle().Render("esc back"))
+	}
+
+	jobs := m.batch.Jobs()
+	lines := make([]string, 0, m.rows())
+	for _, j := range jobs[min(m.offset, len(jobs)):min(m.offset+m.rows(), len(jobs))] {
+		lines = append(lines, m.jobLine(j))
+	}
+	for len(lines) < m.rows() {
+		lines = append(lines, "")
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.viewHeader(len(jobs)),
+		strings.Join(lines, "\n"),
+		m.viewReport(jobs),
+		m.viewFooter(),
+	)
+}
+
+func (m BatchModel) viewHeader(count int) string {
+	mode := "one at a time"
+	if m.parallelism > 1 {
+		mode = fmt.Sprintf("%d at a time", m.parallelism)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Left,
+		style.Title().Render("Batch update · "+m.name), "  ",
+		style.Subtitle().Render(fmt.Sprintf("%d container(s) · images pulled concurrently · recreated %s", count, mode)))
+}
+
+// jobLine renders the state of the update of a container.
+func (m BatchModel) jobLine(j containerupdate.Job) string {
+	name := j.Name()
+	if m.showHost {
+		name = j.Host + "/" + name
+	}
+
+	detail := ""
+	switch {
+	case j.Err != nil:
+		detail = style.Danger().Render(j.Err.Error())
+	case j.State == containerupdate.JobPending && len(j.DependsOn) > 0:
+		detail = style.Inactive().Render("after " + strings.Join(j.DependsOn, ", "))
+	case j.Step != "":
+		detail = j.Step
+	case j.State == containerupdate.JobUpdated:
+		detail = style.Inactive().Render(fmt.Sprintf("downtime %s", j.Downtime.Round(time.Millisecond)))
+	}
+	if !j.Started.IsZero() {
+		detail = j.Duration().Round(100*time.Millisecond).String() + "  " + detail
+	}
+
+	line := fmt.Sprintf("%s %-30s %-40s %s  %s", stateMark(j.State), name, j.Image(), stateLabel(j.State), detail)
+	if m.width > 0 {
+		line = lipgloss.NewStyle().MaxWidth(m.width - 1).Render(line)
+	}
+	return line
+}
+
+// stateMark returns the mark of a state of a job.
+func stateMark(s containerupdate.JobState) string {
+	switch s {
+	case containerupdate.JobPulling:
+		return style.Warning().Render("↓")
+	case containerupdate.JobUpdating:
+		return style.Spinner().Render("●")
+	case containerupdate.JobUpdated:
+		return style.Success().Render("✓")
+	case containerupdate.JobRolledBack:
+		return style.Warning().Render("↺")
+	case containerupdate.JobFailed:
+		return style.Danger().Render("✗")
+	case containerupdate.JobSkipped:
+		return style.Inactive().Render("⊘")
+	}
+	return style.Inactive().Render("·")
+}
+
+// stateLabel returns the state of a job padded to the longest one.
+func stateLabel(s containerupdate.JobState) string {
+	label := fmt.Sprintf("%-11s", s)
+	switch s {
+	case containerupdate.JobUpdated:
+		return style.Success().Render(label)
+	case containerupdate.JobRolledBack:
+		return style.Warning().Render(label)
+	case containerupdate.JobFailed:
+		return style.Danger().Render(label)
+	case containerupdate.JobPending, containerupdate.JobWaiting, containerupdate.JobSkipped:
+		return style.Inactive().Render(label)
+	}
+	return label
+}
+
+// viewReport renders the count of the containers by result once the batch is done.
+func (m BatchModel) viewReport(jobs []containerupdate.Job) string {
+	if m.completed.IsZero() {
+		return ""
+	}
+	counts := make(map[containerupdate.JobState]int)
+	for _, j := range jobs {
+		counts[j.State]++
+	}
+	report := fmt.Sprintf("%d updated, %d rolled back, %d failed, %d skipped in %s",
+		counts[containerupdate.JobUpdated], counts[containerupdate.JobRolledBack],
+		counts[containerupdate.JobFailed], counts[containerupdate.JobSkipped],
+		m.completed.Sub(m.started).Round(time.Second))
+	if counts[containerupdate.JobUpdated] == len(jobs) {
+		return style.Success().Render(report)
+	}
+	return style.Warning().Render(report)
+}
+
+func (m BatchModel) viewFooter() string {
+	help := "update in progress..."
+	switch {
+	case !m.completed.IsZero():
+		help = "enter/esc back"
+	case m.started.IsZero():
+		help = "enter update • +/- parallel recreations • esc cancel"
+	}
+	return style.Subtitle().Render(help)
+}
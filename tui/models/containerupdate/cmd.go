@@ -2,16 +2,40 @@ package containerupdate
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kernaxis/gmd/docker/client"
 	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
 	"github.com/kernaxis/gmd/tui/controllers/containerupdate"
 )
 
 type UpdateFinishedMsg struct {
 }
 
-func startUpdate(c *containerupdate.Controller, container types.Container) tea.Cmd {
+// ImageDiffMsg delivers the comparison of the image of the container with the image of its update.
+type ImageDiffMsg struct {
+	Image string
+	Diff  client.ImageDiff
+	Err   error
+}
+
+// fetchDiff compares the local image of the container with the given image of the registry.
+func fetchDiff(cli client.Client, containerID, image string) tea.Cmd {
+	return func() tea.Msg {
+		diff, err := cli.DiffImage(containerID, image)
+		return ImageDiffMsg{Image: image, Diff: diff, Err: err}
+	}
+}
+
+func startUpdate(c *containerupdate.Controller, containers []types.Container) tea.Cmd {
+	return func() tea.Msg {
+		c.StartUpdates(containers)
+		return containerupdate.ControllerUpdateMsg{}
+	}
+}
+
+func startRollback(c *containerupdate.Controller, container types.Container, previous history.PreviousImage) tea.Cmd {
 	return func() tea.Msg {
-		c.StartUpdate(container)
+		c.StartRollback(container, previous)
 		return containerupdate.ControllerUpdateMsg{}
 	}
 }
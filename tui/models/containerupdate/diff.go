@@ -0,0 +1,160 @@
+package containerupdate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/kernaxis/gmd/docker/client"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// maxChanges is the number of changes shown by section, the other ones are counted.
+const maxChanges = 4
+
+// viewDiff renders the image diff of the update, followed by the confirmation help.
+func (m Model) viewDiff() string {
+	lines := []string{}
+	switch {
+	case m.diffErr != nil:
+		lines = append(lines,
+			style.Danger().Render("Unable to compare the images: "+m.diffErr.Error()),
+			"")
+	case m.diff == nil:
+		lines = append(lines, style.Inactive().Render("Comparing the images..."), "")
+	default:
+		lines = append(lines, diffLines(*m.diff)...)
+	}
+	lines = append(lines, style.Subtitle().PaddingLeft(0).Render("enter update • esc cancel"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// diffLines renders the sections of an image diff.
+func diffLines(d client.ImageDiff) []string {
+	label := style.Subtitle().PaddingLeft(0).Width(12)
+	image := d.Image
+	if d.Platform != "" {
+		image += " (" + d.Platform + ")"
+	}
+	lines := []string{
+		label.Render("Image") + image,
+		label.Render("Digest") + fmt.Sprintf("%s → %s", shortDigest(d.LocalDigest), shortDigest(d.RemoteDigest)),
+	}
+
+	// the version labels tell what the update brings, they are shown first
+	for _, l := range []struct{ key, name string }{
+		{client.OCIVersionLabel, "Version"},
+		{client.OCIRevisionLabel, "Revision"},
+		{client.OCISourceLabel, "Source"},
+	} {
+		for _, c := range d.Labels {
+			if c.Key == l.key {
+				lines = append(lines, label.Render(l.name)+changeValue(c))
+			}
+		}
+	}
+
+	layers := fmt.Sprintf("%d → %d", d.LocalLayers, d.RemoteLayers)
+	if d.DownloadLayers > 0 {
+		layers += fmt.Sprintf(", %d to download (%s)", d.DownloadLayers, humanize.Bytes(uint64(d.DownloadSize)))
+	}
+	lines = append(lines, label.Render("Layers")+layers)
+	if d.LocalSize > 0 {
+		delta := d.RemoteSize - d.LocalSize
+		sign := "+"
+		if delta < 0 {
+			sign, delta = "-", -delta
+		}
+		lines = append(lines, label.Render("Size")+fmt.Sprintf("%s → %s (%s%s)",
+			humanize.Bytes(uint64(d.LocalSize)), humanize.Bytes(uint64(d.RemoteSize)), sign, humanize.Bytes(uint64(delta))))
+	} else {
+		lines = append(lines, label.Render("Size")+humanize.Bytes(uint64(d.RemoteSize)))
+	}
+	lines = append(lines, "")
+
+	if !d.Changed() {
+		return append(lines, style.Success().Render("Same configuration as the running image"), "")
+	}
+	lines = append(lines, changeSection("Command", d.Command)...)
+	lines = append(lines, changeSection("Environment", d.Env)...)
+	lines = append(lines, changeSection("Ports", d.Ports)...)
+
+	var labels []client.Change
+	for _, c := range d.Labels {
+		if c.Key != client.OCIVersionLabel && c.Key != client.OCIRevisionLabel && c.Key != client.OCISourceLabel {
+			labels = append(labels, c)
+		}
+	}
+	lines = append(lines, changeSection("Labels", labels)...)
+	return lines
+}
+
+// changeSection renders the changes of a section, at most maxChanges of them.
+func changeSection(title string, changes []client.Change) []string {
+	if len(changes) == 0 {
+		return nil
+	}
+	lines := []string{style.Subtitle().PaddingLeft(0).Render(title)}
+	for i, c := range changes {
+		if i == maxChanges {
+			lines = append(lines, style.Inactive().Render(fmt.Sprintf("  … %d more", len(changes)-maxChanges)))
+			break
+		}
+		lines = append(lines, "  "+changeLine(c))
+	}
+	return lines
+}
+
+// changeLine renders a change as an addition, a removal or a modification of its key.
+func changeLine(c client.Change) string {
+	switch {
+	case c.Old == "":
+		return style.Success().Render("+ " + keyValue(c.Key, c.New))
+	case c.New == "":
+		return style.Danger().Render("- " + keyValue(c.Key, c.Old))
+	default:
+		return style.Warning().Render("~ "+c.Key+": ") + truncate(c.Old) + " → " + truncate(c.New)
+	}
+}
+
+// changeValue renders the values of a change.
+func changeValue(c client.Change) string {
+	old, new := c.Old, c.New
+	if old == "" {
+		old = "-"
+	}
+	if new == "" {
+		new = "-"
+	}
+	if old == new {
+		return new
+	}
+	return truncate(old) + " → " + truncate(new)
+}
+
+// keyValue renders a key and its value, only the key when both are the same, as for the ports.
+func keyValue(key, value string) string {
+	if key == value {
+		return key
+	}
+	return key + "=" + truncate(value)
+}
+
+// truncate shortens the long values, such as the revisions and the paths.
+func truncate(s string) string {
+	const max = 30
+	if len(s) > max {
+		return s[:max-1] + "…"
+	}
+	return s
+}
+
+// shortDigest returns the first 12 characters of a digest, or "-".
+func shortDigest(digest string) string {
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if digest == "" {
+		return "-"
+	}
+	return digest[:min(len(digest), 12)]
+}
@@ -8,25 +8,49 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
 	"github.com/kernaxis/gmd/docker/client"
 	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
 	"github.com/kernaxis/gmd/tui/commands"
 	"github.com/kernaxis/gmd/tui/controllers/containerupdate"
+	style "github.com/kernaxis/gmd/tui/styles"
 )
 
 type Model struct {
-	container  types.Container
-	cli        *client.Client
+	containers []types.Container
+	cli        client.Client
 	controller *containerupdate.Controller
 	screenW    int
 	screenH    int
 
 	titleBlock string
 	completed  bool
+
+	choices []Choice // choices are the images the container can be updated to, picked before the update starts.
+	cursor  int      // cursor is the index of the selected choice.
+	started bool
+
+	previewing bool              // previewing is set while the image diff is shown, before the update starts.
+	diff       *client.ImageDiff // diff is the image diff of the update, nil until fetched.
+	diffErr    error
+
+	rollback *history.PreviousImage // rollback is the image the container is rolled back to, nil for an update.
+}
+
+// Choice is an image a container can be updated to.
+type Choice struct {
+	Image       string // Image is the image reference.
+	Description string
 }
 
 type listKeyMap struct {
 	returnKey key.Binding
+	pick      key.Binding
+	confirm   key.Binding
+	cancel    key.Binding
+	up        key.Binding
+	down      key.Binding
 }
 
 var keyMap = &listKeyMap{
@@ -34,35 +58,117 @@ var keyMap = &listKeyMap{
 		key.WithKeys("esc", "enter"),
 		key.WithHelp("enter", "get back to main menu"),
 	),
+	pick: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "update"),
+	),
+	confirm: key.NewBinding(
+		key.WithKeys("enter", "y"),
+		key.WithHelp("enter", "update"),
+	),
+	cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+	up: key.NewBinding(
+		key.WithKeys("up", "k"),
+	),
+	down: key.NewBinding(
+		key.WithKeys("down", "j"),
+	),
 }
 
-func New(c types.Container, client *client.Client) Model {
+// New returns a model updating the container, once its image diff is previewed and the update confirmed.
+func New(c types.Container, client client.Client) Model {
 	controller := containerupdate.New(client)
 	m := Model{
-		container:  c,
+		containers: []types.Container{c},
 		cli:        client,
 		controller: controller,
+		previewing: true,
 	}
 
+	m.titleBlock = titleBlock(fmt.Sprintf("Updating container %s ...", strings.TrimPrefix(c.Name, "/")))
+	return m
+}
+
+// titleBlock renders the title of the page.
+func titleBlock(text string) string {
 	title := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("#88C0D0")).
 		Width(90).
 		Align(lipgloss.Center).
-		Render(fmt.Sprintf("Updating container %s ...", strings.TrimPrefix(m.container.Name, "/")))
+		Render(text)
 
-	m.titleBlock = lipgloss.JoinVertical(
+	return lipgloss.JoinVertical(
 		lipgloss.Center,
 		title,
 		"",
 	)
+}
 
+// NewRollback returns a model recreating the container on its previous image, once confirmed.
+func NewRollback(c types.Container, previous history.PreviousImage, cli client.Client) Model {
+	m := New(c, cli)
+	m.rollback = &previous
+	m.titleBlock = titleBlock(fmt.Sprintf("Rolling back container %s ...", strings.TrimPrefix(c.Name, "/")))
+	return m
+}
+
+// NewWithTags returns a model updating the container to the image picked among
+// its newer tags and, when update is set, the newer image of its current tag.
+func NewWithTags(c types.Container, tags client.TagUpdates, update bool, cli client.Client) Model {
+	m := New(c, cli)
+
+	image := c.Config.Image
+	if update {
+		m.choices = append(m.choices, Choice{Image: image, Description: "newer image of the current tag"})
+	}
+	for _, t := range []struct{ tag, level string }{{tags.Patch, "patch"}, {tags.Minor, "minor"}, {tags.Major, "major"}} {
+		if t.tag != "" {
+			m.choices = append(m.choices, Choice{Image: client.WithTag(image, t.tag), Description: "newer " + t.level + " version"})
+		}
+	}
+	// a single choice is not worth asking
+	if len(m.choices) == 1 {
+		m.containers = []types.Container{containerupdate.WithImage(c, m.choices[0].Image)}
+		m.choices = nil
+	}
+	// the diff is previewed once the image is picked
+	m.previewing = len(m.choices) == 0
 	return m
 }
 
 func (m Model) Init() tea.Cmd {
-	log.Printf("init update for container %s", m.container.Name)
-	return startUpdate(m.controller, m.container)
+	if len(m.choices) > 0 {
+		return nil
+	}
+	if m.previewing && m.rollback == nil {
+		return m.preview()
+	}
+	if m.previewing {
+		return nil
+	}
+	return m.start()
+}
+
+// preview fetches the image diff of the update of the container.
+func (m *Model) preview() tea.Cmd {
+	c := m.containers[0]
+	m.previewing = true
+	m.diff, m.diffErr = nil, nil
+	return fetchDiff(m.cli, c.ID, c.Config.Image)
+}
+
+// start starts the update of the containers.
+func (m *Model) start() tea.Cmd {
+	log.Printf("init update for %d container(s)", len(m.containers))
+	m.started = true
+	if m.rollback != nil {
+		return startRollback(m.controller, m.containers[0], *m.rollback)
+	}
+	return startUpdate(m.controller, m.containers)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -75,7 +181,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, waitUpdateEvent(m.controller.Events())
 	case UpdateFinishedMsg:
 		m.completed = true
+	case ImageDiffMsg:
+		if m.previewing && msg.Image == m.containers[0].Config.Image {
+			m.diff, m.diffErr = &msg.Diff, msg.Err
+		}
 	case tea.KeyMsg:
+		if m.previewing {
+			switch {
+			case key.Matches(msg, keyMap.confirm):
+				m.previewing = false
+				return m, m.start()
+			case key.Matches(msg, keyMap.cancel):
+				return m, commands.SwitchPageCmd(nil)
+			}
+			return m, nil
+		}
+		if !m.started {
+			switch {
+			case key.Matches(msg, keyMap.up):
+				m.cursor = max(m.cursor-1, 0)
+			case key.Matches(msg, keyMap.down):
+				m.cursor = min(m.cursor+1, len(m.choices)-1)
+			case key.Matches(msg, keyMap.pick):
+				m.containers = []types.Container{containerupdate.WithImage(m.containers[0], m.choices[m.cursor].Image)}
+				return m, m.preview()
+			case key.Matches(msg, keyMap.cancel):
+				return m, commands.SwitchPageCmd(nil)
+			}
+			return m, nil
+		}
 		switch {
 		case key.Matches(msg, keyMap.returnKey):
 			if m.completed {
@@ -93,6 +227,14 @@ func (m Model) View() string {
 		lipgloss.Left,
 		m.controller.GetLines()...,
 	)
+	switch {
+	case m.previewing && m.rollback != nil:
+		contentLines = m.viewRollback()
+	case m.previewing:
+		contentLines = m.viewDiff()
+	case !m.started:
+		contentLines = m.viewChoices()
+	}
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -114,3 +256,29 @@ func (m Model) View() string {
 		box.Render(content),
 	)
 }
+
+// viewChoices renders the images the container can be updated to.
+func (m Model) viewChoices() string {
+	lines := []string{"Pick the image to update to:", ""}
+	for i, c := range m.choices {
+		line := fmt.Sprintf("  %-50s %s", c.Image, style.Subtitle().PaddingLeft(0).Render(c.Description))
+		if i == m.cursor {
+			line = style.ListSelectedLine().Render("> " + line[2:])
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "", style.Subtitle().PaddingLeft(0).Render("↑/↓ select • enter update • esc cancel"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// viewRollback renders the image the container is rolled back to, followed by the confirmation help.
+func (m Model) viewRollback() string {
+	label := style.Subtitle().PaddingLeft(0).Width(12)
+	p := m.rollback
+	return lipgloss.JoinVertical(lipgloss.Left,
+		label.Render("Current")+m.containers[0].Config.Image,
+		label.Render("Previous")+fmt.Sprintf("%s (%s)", p.Image.Ref, shortDigest(p.Image.ID)),
+		label.Render("Replaced")+humanize.Time(p.Replaced),
+		"",
+		style.Subtitle().PaddingLeft(0).Render("enter roll back • esc cancel"))
+}
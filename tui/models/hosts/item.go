@@ -0,0 +1,46 @@
+package hosts
+
+import (
+	"fmt"
+
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+type HostItem struct {
+	name     string
+	endpoint string
+	loaded   bool
+	err      error
+	running  int
+	total    int
+	images   int
+	selected bool
+}
+
+func (h HostItem) Title() string {
+	if h.selected {
+		return "● " + h.name
+	}
+	return "  " + h.name
+}
+
+func (h HostItem) Description() string {
+	if h.name == allHostsName {
+		return fmt.Sprintf("%d/%d containers running - %d images", h.running, h.total, h.images)
+	}
+	return fmt.Sprintf("%s - %s", h.endpoint, h.State())
+}
+
+// State returns the rendered connection state of the host.
+func (h HostItem) State() string {
+	switch {
+	case h.err != nil:
+		return style.Danger().Render(h.err.Error())
+	case !h.loaded:
+		return style.Inactive().Render("connecting...")
+	default:
+		return style.Success().Render(fmt.Sprintf("%d/%d containers running - %d images", h.running, h.total, h.images))
+	}
+}
+
+func (h HostItem) FilterValue() string { return h.name }
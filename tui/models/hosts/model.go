@@ -0,0 +1,163 @@
+package hosts
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types/container"
+	"github.com/kernaxis/gmd/docker/cache"
+	"github.com/kernaxis/gmd/tui/commands"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// allHostsName is the name of the entry selecting all the hosts.
+const allHostsName = "All hosts"
+
+type Model struct {
+	caches []*cache.Cache
+	list   list.Model
+	status string
+}
+
+type listKeyMap struct {
+	selectHost key.Binding
+}
+
+var keyMap = &listKeyMap{
+	selectHost: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "show this host"),
+	),
+}
+
+func New(caches []*cache.Cache) Model {
+
+	items := make([]list.Item, 0, len(caches)+1)
+	if len(caches) > 1 {
+		items = append(items, HostItem{name: allHostsName, loaded: true, selected: true})
+	}
+	for _, c := range caches {
+		items = append(items, HostItem{
+			name:     c.Host(),
+			endpoint: c.Client().Endpoint().String(),
+			selected: len(caches) == 1,
+		})
+	}
+
+	l := list.New(items, newItemDelegate(), 0, 0)
+	l.Title = "Hosts"
+	l.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keyMap.selectHost,
+		}
+	}
+
+	return Model{
+		caches: caches,
+		list:   l,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) IsSearching() bool {
+	return m.list.SettingFilter()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height-4)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.IsSearching() {
+			break
+		}
+		switch {
+		case key.Matches(msg, keyMap.selectHost):
+			if h, ok := m.list.SelectedItem().(HostItem); ok {
+				host := h.name
+				if host == allHostsName {
+					host = ""
+				}
+				return m, func() tea.Msg { return commands.SelectHostMsg{Host: host} }
+			}
+			return m, nil
+		}
+
+	case commands.SelectHostMsg:
+		for i, item := range m.list.Items() {
+			h := item.(HostItem)
+			h.selected = h.name == msg.Host || (msg.Host == "" && h.name == allHostsName)
+			m.list.SetItem(i, h)
+		}
+
+	case commands.CacheStartMsg:
+		m.updateHost(msg.Host, func(h *HostItem) {
+			h.err = msg.Err
+			h.loaded = msg.Err == nil
+		})
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		}
+		m.refreshCounts()
+
+	case cache.Event:
+		m.refreshCounts()
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+func (m Model) View() string {
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.list.View(),
+		m.status,
+	)
+}
+
+// updateHost applies f to the item of the given host.
+func (m *Model) updateHost(host string, f func(h *HostItem)) {
+	for i, item := range m.list.Items() {
+		if h := item.(HostItem); h.name == host {
+			f(&h)
+			m.list.SetItem(i, h)
+			return
+		}
+	}
+}
+
+// refreshCounts updates the containers and images counters of all the hosts.
+func (m *Model) refreshCounts() {
+	var all HostItem
+	for _, c := range m.caches {
+		var running, total int
+		for _, cont := range c.Containers() {
+			total++
+			if cont.State != nil && cont.State.Status == container.StateRunning {
+				running++
+			}
+		}
+		images := len(c.Images())
+
+		all.running += running
+		all.total += total
+		all.images += images
+
+		m.updateHost(c.Host(), func(h *HostItem) {
+			h.running, h.total, h.images = running, total, images
+		})
+	}
+	m.updateHost(allHostsName, func(h *HostItem) {
+		h.running, h.total, h.images = all.running, all.total, all.images
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kernaxis/gmd/history"
+	"github.com/kernaxis/gmd/tui/controllers/containerupdate"
 )
 
 type ImagesLoadedMsg struct {
@@ -12,27 +14,46 @@ type ImagesLoadedMsg struct {
 }
 
 type DeleteImageMsg struct {
-	ID  string
-	Err error
+	Host string
+	ID   string
+	Err  error
 }
 
 func (m Model) FetchImagesCmd() tea.Cmd {
 	return func() tea.Msg {
-		images := m.cache.Images()
-		imagesItems := make([]ImageItem, len(images))
-		for i, img := range images {
-			imagesItems[i] = ImageItem(img)
+		var imagesItems []ImageItem
+		for _, c := range m.caches {
+			for _, img := range c.Images() {
+				imagesItems = append(imagesItems, NewImageItem(c.Host(), img))
+			}
 		}
 		return ImagesLoadedMsg{Images: imagesItems, Err: nil}
 	}
 }
 
-func (m Model) DeleteImagesCmd(id string) tea.Cmd {
+func (m Model) DeleteImagesCmd(host string, id string) tea.Cmd {
+	cli := m.cache(host).Client()
 	return func() tea.Msg {
-		err := m.cli.DeleteImage(context.Background(), id)
+		err := cli.DeleteImage(context.Background(), id)
 		if err != nil {
-			return DeleteImageMsg{ID: id, Err: err}
+			return DeleteImageMsg{Host: host, ID: id, Err: err}
 		}
-		return DeleteImageMsg{ID: id, Err: nil}
+		return DeleteImageMsg{Host: host, ID: id, Err: nil}
+	}
+}
+
+// PreviousImagesMsg delivers the images kept from the updates on a host.
+type PreviousImagesMsg struct {
+	Host   string
+	Images []history.PreviousImage
+	Err    error
+}
+
+// FetchPreviousImagesCmd loads the images kept from the updates on the given host.
+func (m Model) FetchPreviousImagesCmd(host string) tea.Cmd {
+	cli := m.cache(host).Client()
+	return func() tea.Msg {
+		images, err := containerupdate.PreviousImages(cli)
+		return PreviousImagesMsg{Host: host, Images: images, Err: err}
 	}
 }
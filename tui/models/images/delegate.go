@@ -32,6 +32,9 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 	}
 
 	title := style.Title().Render(c.Title())
+	if c.previous != nil {
+		title = lipgloss.JoinHorizontal(lipgloss.Left, title, " ", style.Warning().Render("⟲ old version"))
+	}
 	desc := style.Subtitle().Render(c.Description())
 
 	content := lipgloss.JoinVertical(lipgloss.Left, title, desc)
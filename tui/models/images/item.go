@@ -5,12 +5,29 @@ import (
 
 	"github.com/dustin/go-humanize"
 	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
 )
 
-type ImageItem types.Image
+type ImageItem struct {
+	types.Image
+	host     string
+	showHost bool
+	previous *history.PreviousImage // previous is set when the image is kept from an update of a container.
+}
+
+func NewImageItem(host string, img types.Image) ImageItem {
+	return ImageItem{Image: img, host: host}
+}
 
-func (i ImageItem) Title() string { return types.Image(i).Tag() }
+func (i ImageItem) Title() string { return i.Image.Tag() }
 func (i ImageItem) Description() string {
-	return fmt.Sprintf("%s - %s", i.ID, humanize.Bytes(uint64(i.Size)))
+	desc := fmt.Sprintf("%s - %s", i.ID, humanize.Bytes(uint64(i.Size)))
+	if i.showHost {
+		desc += " - " + i.host
+	}
+	if i.previous != nil {
+		desc += fmt.Sprintf(" - %s of %s, replaced %s", i.previous.Image.Ref, i.previous.Container, humanize.Time(i.previous.Replaced))
+	}
+	return desc
 }
 func (i ImageItem) FilterValue() string { return i.Title() }
@@ -10,23 +10,28 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kernaxis/gmd/docker/cache"
-	"github.com/kernaxis/gmd/docker/client"
 	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/history"
+	"github.com/kernaxis/gmd/tui/commands"
+	"github.com/kernaxis/gmd/tui/models/containerupdate"
 	style "github.com/kernaxis/gmd/tui/styles"
 )
 
 type Model struct {
-	cli    *client.Client
-	cache  *cache.Cache
+	caches []*cache.Cache
+	host   string // host is the name of the selected host, empty when all hosts are shown.
 	list   list.Model
 	loaded bool
 	unused bool
 	status string
+
+	previous map[string]history.PreviousImage // previous are the images kept from the updates, by host and ID.
 }
 
 type listKeyMap struct {
 	toggleUnused key.Binding
 	delete       key.Binding
+	rollback     key.Binding
 }
 
 var keyMap = &listKeyMap{
@@ -38,9 +43,13 @@ var keyMap = &listKeyMap{
 		key.WithKeys("u"),
 		key.WithHelp("u", "toggle unused only"),
 	),
+	rollback: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "roll back to old version"),
+	),
 }
 
-func New(cli *client.Client, cache *cache.Cache) Model {
+func New(caches []*cache.Cache) Model {
 
 	items := []list.Item{}
 
@@ -50,17 +59,33 @@ func New(cli *client.Client, cache *cache.Cache) Model {
 		return []key.Binding{
 			keyMap.delete,
 			keyMap.toggleUnused,
+			keyMap.rollback,
 		}
 	}
 
 	return Model{
-		cli:   cli,
-		cache: cache,
-		list:  l,
+		caches:   caches,
+		list:     l,
+		previous: make(map[string]history.PreviousImage),
 		//imgs:   images,
 	}
 }
 
+// cache returns the cache of the given host.
+func (m Model) cache(host string) *cache.Cache {
+	for _, c := range m.caches {
+		if c.Host() == host {
+			return c
+		}
+	}
+	return nil
+}
+
+// visible reports whether the images of the given host are shown.
+func (m Model) visible(host string) bool {
+	return m.host == "" || m.host == host
+}
+
 func (m Model) Init() tea.Cmd {
 	return nil
 }
@@ -92,8 +117,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.applyFilter()
 			return m, nil
 		case key.Matches(msg, keyMap.delete):
-			m.status = style.StatusBar().Render("Deleting image " + m.list.SelectedItem().(ImageItem).Title())
-			return m, m.DeleteImagesCmd(m.list.SelectedItem().(ImageItem).ID)
+			if i, ok := m.list.SelectedItem().(ImageItem); ok {
+				m.status = style.StatusBar().Render("Deleting image " + i.Title())
+				return m, m.DeleteImagesCmd(i.host, i.ID)
+			}
+			return m, nil
+		case key.Matches(msg, keyMap.rollback):
+			return m, m.rollback()
 		}
 
 	case DeleteImageMsg:
@@ -103,6 +133,23 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.status = style.Success().Render("Image supprimée")
 		}
 		m.applyFilter()
+	case PreviousImagesMsg:
+		if msg.Err != nil {
+			log.Printf("unable to load the previous images of %s: %v", msg.Host, msg.Err)
+			break
+		}
+		for k, p := range m.previous {
+			if p.Host == msg.Host {
+				delete(m.previous, k)
+			}
+		}
+		for _, p := range msg.Images {
+			m.previous[p.Host+"/"+p.Image.ID] = p
+		}
+		m.applyFilter()
+	case commands.SelectHostMsg:
+		m.host = msg.Host
+		m.applyFilter()
 	case cache.Event:
 		if msg.EventType == cache.ImagesLoadedEventType {
 			if !m.loaded {
@@ -110,12 +157,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			log.Printf("received images loaded event: %+v", msg)
 			m.applyFilter()
+			return m, m.FetchPreviousImagesCmd(msg.Host)
 		}
 		if msg.EventType == cache.ImageEventType {
-			if m.loaded {
+			if m.loaded && m.visible(msg.Host) {
 				log.Printf("received image event: %+v", msg)
-				m.updateImage(msg.ActorID)
+				m.updateImage(msg.Host, msg.ActorID)
 				// m.applyFilter()
+				return m, m.FetchPreviousImagesCmd(msg.Host)
 			}
 
 		}
@@ -141,43 +190,92 @@ func (m *Model) applyFilter() {
 
 	log.Printf("image applying filter")
 
-	var images []types.Image
-	if m.unused {
-		images = m.cache.ImagesUnused()
-	} else {
-		images = m.cache.Images()
+	itemList := make([]list.Item, 0, len(m.list.Items()))
+	for _, c := range m.caches {
+		if !m.visible(c.Host()) {
+			continue
+		}
+
+		var images []types.Image
+		if m.unused {
+			images = c.ImagesUnused()
+		} else {
+			images = c.Images()
+		}
+
+		for _, item := range images {
+			itemList = append(itemList, m.newImageItem(c.Host(), item))
+		}
 	}
 
-	slices.SortFunc(images, func(a, b types.Image) int {
-		return strings.Compare(a.Tag(), b.Tag())
-	})
+	slices.SortFunc(itemList, compareItems)
+	m.list.SetItems(itemList)
+}
 
-	itemList := make([]list.Item, 0, len(images))
-	for _, item := range images {
+// newImageItem returns the item of the given image of host.
+func (m *Model) newImageItem(host string, img types.Image) ImageItem {
+	i := NewImageItem(host, img)
+	i.showHost = len(m.caches) > 1
+	if p, ok := m.previous[host+"/"+img.ID]; ok {
+		i.previous = &p
+	}
+	return i
+}
 
-		itemList = append(itemList, ImageItem(item))
+// rollback opens the rollback of the container the selected old version was replaced in.
+func (m *Model) rollback() tea.Cmd {
+	i, ok := m.list.SelectedItem().(ImageItem)
+	if !ok {
+		return nil
+	}
+	if i.previous == nil {
+		m.status = style.StatusBar().Render(i.Title() + " is not an old version of a container")
+		return nil
+	}
+	c := m.cache(i.host)
+	for _, cont := range c.Containers() {
+		if strings.TrimPrefix(cont.Name, "/") == i.previous.Container {
+			previous, cli := *i.previous, c.Client()
+			return commands.SwitchPageCmd(func() tea.Model {
+				return containerupdate.NewRollback(cont, previous, cli)
+			})
+		}
+	}
+	m.status = style.Danger().Render("Container " + i.previous.Container + " not found")
+	return nil
+}
 
+// compareItems orders the images by tag, then by host.
+func compareItems(a, b list.Item) int {
+	ia, ib := a.(ImageItem), b.(ImageItem)
+	if r := strings.Compare(ia.Title(), ib.Title()); r != 0 {
+		return r
 	}
-	m.list.SetItems(itemList)
+	return strings.Compare(ia.host, ib.host)
 }
 
-func (m *Model) updateImage(id string) {
-	newImage, err := m.cache.Image(id)
+func (m *Model) updateImage(host string, id string) {
+	c := m.cache(host)
+	if c == nil {
+		return
+	}
+	newImage, err := c.Image(id)
 	for i, item := range m.list.Items() {
-		if item.(ImageItem).ID == id {
+		if img := item.(ImageItem); img.host == host && img.ID == id {
 			switch err {
 			case cache.ErrImageNotFound:
 				m.list.RemoveItem(i)
 			case nil:
-				m.list.SetItem(i, ImageItem(newImage))
+				m.list.SetItem(i, m.newImageItem(host, newImage))
 			}
 			return
 		}
 	}
+	if err != nil {
+		return
+	}
 	items := m.list.Items()
-	items = append(items, ImageItem(newImage))
-	slices.SortFunc(items, func(a, b list.Item) int {
-		return strings.Compare(a.(ImageItem).Title(), b.(ImageItem).Title())
-	})
+	items = append(items, m.newImageItem(host, newImage))
+	slices.SortFunc(items, compareItems)
 	m.list.SetItems(items)
 }
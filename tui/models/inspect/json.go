@@ -0,0 +1,144 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// node is a value of a JSON document, keeping the order of the object keys.
+type node struct {
+	key      string  // key is the object key of the value, empty in arrays and for the root.
+	value    string  // value is the JSON text of a scalar.
+	open     string  // open is "{" or "[" for an object or an array, empty for a scalar.
+	children []*node // children are the members of an object or an array.
+	folded   bool
+	parent   *node
+}
+
+// line is a line of the rendered document.
+type line struct {
+	node    *node
+	depth   int
+	closing bool // closing is set on the line closing an object or an array.
+	text    string
+}
+
+// parseJSON parses the JSON document into a tree.
+func parseJSON(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return parseValue(dec, "", nil)
+}
+
+func parseValue(dec *json.Decoder, key string, parent *node) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	n := &node{key: key, parent: parent}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		n.open = t.String()
+		for dec.More() {
+			childKey := ""
+			if n.open == "{" {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				childKey = fmt.Sprint(k)
+			}
+			child, err := parseValue(dec, childKey, n)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		}
+		// closing delimiter
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		b, _ := json.Marshal(t)
+		n.value = string(b)
+	case nil:
+		n.value = "null"
+	default:
+		n.value = fmt.Sprint(t)
+	}
+	return n, nil
+}
+
+// close returns the delimiter closing the object or the array.
+func (n *node) close() string {
+	if n.open == "{" {
+		return "}"
+	}
+	return "]"
+}
+
+// lines flattens the tree into the lines to show, folded values on a single line.
+func (n *node) lines() []line {
+	var out []line
+	n.appendLines(&out, 0, true)
+	return out
+}
+
+func (n *node) appendLines(out *[]line, depth int, last bool) {
+	prefix := strings.Repeat("  ", depth)
+	if n.parent != nil && n.parent.open == "{" {
+		b, _ := json.Marshal(n.key)
+		prefix += string(b) + ": "
+	}
+	comma := ","
+	if last {
+		comma = ""
+	}
+
+	switch {
+	case n.open == "":
+		*out = append(*out, line{node: n, depth: depth, text: prefix + n.value + comma})
+	case len(n.children) == 0:
+		*out = append(*out, line{node: n, depth: depth, text: prefix + n.open + n.close() + comma})
+	case n.folded:
+		*out = append(*out, line{node: n, depth: depth, text: fmt.Sprintf("%s%s…%s%s  (%d)", prefix, n.open, n.close(), comma, len(n.children))})
+	default:
+		*out = append(*out, line{node: n, depth: depth, text: prefix + n.open})
+		for i, child := range n.children {
+			child.appendLines(out, depth+1, i == len(n.children)-1)
+		}
+		*out = append(*out, line{node: n, depth: depth, closing: true, text: strings.Repeat("  ", depth) + n.close() + comma})
+	}
+}
+
+// foldable reports whether the value can be folded.
+func (n *node) foldable() bool {
+	return len(n.children) > 0
+}
+
+// setFolded folds or unfolds the values of the tree from the given depth.
+func (n *node) setFolded(folded bool, fromDepth, depth int) {
+	if n.foldable() && depth >= fromDepth {
+		n.folded = folded
+	}
+	for _, child := range n.children {
+		child.setFolded(folded, fromDepth, depth+1)
+	}
+}
+
+// search unfolds the parents of the values whose key or text matches term,
+// so that they are shown.
+func (n *node) search(term *regexp.Regexp) {
+	if term.MatchString(n.key) || term.MatchString(n.value) {
+		for p := n.parent; p != nil; p = p.parent {
+			p.folded = false
+		}
+	}
+	for _, child := range n.children {
+		child.search(term)
+	}
+}
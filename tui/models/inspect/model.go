@@ -0,0 +1,468 @@
+// Package inspect provides the page showing the configuration of a container.
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kernaxis/gmd/docker/cache"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/tui/commands"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+var highlightStyle = lipgloss.NewStyle().Reverse(true)
+
+type Model struct {
+	cache     *cache.Cache
+	id        string
+	name      string
+	container types.Container
+	err       error
+
+	raw     bool // raw is set when the JSON document is shown instead of the sections.
+	masked  bool // masked is set when the values of the secret environment variables are hidden.
+	details viewport.Model
+
+	root     *node  // root is the JSON document of the container.
+	lines    []line // lines are the shown lines of the JSON document.
+	cursor   int    // cursor is the index of the selected line.
+	offset   int    // offset is the index of the first line on screen.
+	height   int
+	width    int
+	search   string         // search is the searched text.
+	searchRe *regexp.Regexp // searchRe finds the search, ignoring the case, nil without search.
+	matches  []int          // matches holds the index of the lines matching the search.
+	match    int            // match is the index in matches of the current match.
+
+	input     textinput.Model
+	searching bool
+}
+
+type listKeyMap struct {
+	back     key.Binding
+	toggle   key.Binding
+	mask     key.Binding
+	reload   key.Binding
+	fold     key.Binding
+	foldAll  key.Binding
+	expand   key.Binding
+	search   key.Binding
+	next     key.Binding
+	previous key.Binding
+}
+
+var keyMap = &listKeyMap{
+	back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	toggle: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "details/json"),
+	),
+	mask: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "show/mask secrets"),
+	),
+	reload: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reload"),
+	),
+	fold: key.NewBinding(
+		key.WithKeys("enter", " "),
+		key.WithHelp("enter", "fold"),
+	),
+	foldAll: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "collapse all"),
+	),
+	expand: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "expand all"),
+	),
+	search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	next: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n/N", "next/prev"),
+	),
+	previous: key.NewBinding(
+		key.WithKeys("N"),
+	),
+}
+
+// New returns the page showing the container with the given ID and name from the cache.
+func New(c *cache.Cache, id, name string) Model {
+	input := textinput.New()
+	input.Prompt = "/"
+	input.CharLimit = 256
+
+	m := Model{
+		cache:   c,
+		id:      id,
+		name:    strings.TrimPrefix(name, "/"),
+		masked:  true,
+		details: viewport.New(0, 0),
+		input:   input,
+	}
+	m.load()
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// IsSearching reports whether the keys are sent to the search input.
+func (m Model) IsSearching() bool {
+	return m.searching
+}
+
+// load reads the container from the cache and renders it.
+func (m *Model) load() {
+	m.container, m.err = m.cache.Container(m.id)
+	if m.err != nil {
+		return
+	}
+	m.renderDetails()
+	m.renderJSON()
+}
+
+// renderDetails renders the sections into the details view.
+func (m *Model) renderDetails() {
+	var b strings.Builder
+	for i, s := range sections(m.container, m.masked) {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(style.Title().Render(s.title))
+		b.WriteString("\n")
+		for _, l := range s.lines {
+			b.WriteString("  " + l + "\n")
+		}
+	}
+	m.details.SetContent(b.String())
+}
+
+// renderJSON builds the JSON document of the container, its values folded.
+func (m *Model) renderJSON() {
+	c := m.container.InspectResponse
+	if m.masked && c.Config != nil {
+		config := *c.Config
+		config.Env = maskEnv(config.Env)
+		c.Config = &config
+	}
+	data, err := json.Marshal(c)
+	if err == nil {
+		m.root, err = parseJSON(data)
+	}
+	if err != nil {
+		m.err = err
+		return
+	}
+	m.root.setFolded(true, 1, 0)
+	m.refreshLines()
+}
+
+// refreshLines flattens the document again after a fold change.
+func (m *Model) refreshLines() {
+	m.lines = m.root.lines()
+	m.cursor = min(m.cursor, len(m.lines)-1)
+	m.findMatches()
+	m.scroll()
+}
+
+// findMatches looks for the lines containing the search.
+func (m *Model) findMatches() {
+	m.matches = nil
+	if m.searchRe == nil {
+		return
+	}
+	for i, l := range m.lines {
+		if m.searchRe.MatchString(l.text) {
+			m.matches = append(m.matches, i)
+		}
+	}
+	m.match = min(m.match, max(len(m.matches)-1, 0))
+}
+
+// gotoMatch moves the cursor to the match with the given index, wrapping around.
+func (m *Model) gotoMatch(i int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.match = (i + len(m.matches)) % len(m.matches)
+	m.cursor = m.matches[m.match]
+	m.scroll()
+}
+
+// submitSearch searches the text in the whole document, unfolding the matching values.
+func (m *Model) submitSearch(text string) {
+	m.setSearch(strings.TrimSpace(text))
+	if m.searchRe != nil {
+		m.root.search(m.searchRe)
+	}
+	m.refreshLines()
+	for i, l := range m.matches {
+		if l >= m.cursor {
+			m.gotoMatch(i)
+			return
+		}
+	}
+	m.gotoMatch(0)
+}
+
+// move moves the cursor by delta lines.
+func (m *Model) move(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.lines)-1))
+	m.scroll()
+}
+
+// scroll keeps the cursor on screen.
+func (m *Model) scroll() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.height > 0 && m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.lines)-m.height))
+}
+
+// toggleFold folds or unfolds the value under the cursor, or the value containing it.
+func (m *Model) toggleFold() {
+	if len(m.lines) == 0 {
+		return
+	}
+	n := m.lines[m.cursor].node
+	if !n.foldable() {
+		n = n.parent
+	}
+	if n == nil || n.parent == nil {
+		return
+	}
+	n.folded = !n.folded
+	m.refreshLines()
+	for i, l := range m.lines {
+		if l.node == n && !l.closing {
+			m.cursor = i
+			break
+		}
+	}
+	m.scroll()
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height - 3
+		m.details.Width = msg.Width
+		m.details.Height = msg.Height - 3
+		m.input.Width = msg.Width - 10
+		m.scroll()
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.searching {
+			switch msg.String() {
+			case "enter":
+				m.searching = false
+				m.input.Blur()
+				m.submitSearch(m.input.Value())
+				return m, nil
+			case "esc":
+				m.searching = false
+				m.input.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, keyMap.back):
+			if m.raw && m.search != "" {
+				m.setSearch("")
+				m.findMatches()
+				return m, nil
+			}
+			return m, commands.SwitchPageCmd(nil)
+
+		case key.Matches(msg, keyMap.toggle):
+			m.raw = !m.raw
+			return m, nil
+
+		case key.Matches(msg, keyMap.mask):
+			m.masked = !m.masked
+			m.load()
+			return m, nil
+
+		case key.Matches(msg, keyMap.reload):
+			m.load()
+			return m, nil
+		}
+
+		if !m.raw {
+			break
+		}
+
+		switch {
+		case key.Matches(msg, keyMap.fold):
+			m.toggleFold()
+		case key.Matches(msg, keyMap.foldAll):
+			m.root.setFolded(true, 1, 0)
+			m.cursor = 0
+			m.refreshLines()
+		case key.Matches(msg, keyMap.expand):
+			m.root.setFolded(false, 0, 0)
+			m.refreshLines()
+		case key.Matches(msg, keyMap.search):
+			m.searching = true
+			m.input.SetValue(m.search)
+			return m, m.input.Focus()
+		case key.Matches(msg, keyMap.next):
+			m.gotoMatch(m.match + 1)
+		case key.Matches(msg, keyMap.previous):
+			m.gotoMatch(m.match - 1)
+		default:
+			switch msg.String() {
+			case "up", "k":
+				m.move(-1)
+			case "down", "j":
+				m.move(1)
+			case "pgup", "b":
+				m.move(-m.height)
+			case "pgdown", "f":
+				m.move(m.height)
+			case "home", "g":
+				m.move(-len(m.lines))
+			case "end", "G":
+				m.move(len(m.lines))
+			}
+		}
+		return m, nil
+	}
+
+	if m.raw {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.details, cmd = m.details.Update(msg)
+	return m, cmd
+}
+
+func (m Model) View() string {
+	body := ""
+	switch {
+	case m.err != nil:
+		body = style.Danger().Render(m.err.Error())
+	case m.raw:
+		body = m.viewJSON()
+	default:
+		body = m.details.View()
+	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.viewHeader(),
+		body,
+		m.viewFooter(),
+	)
+}
+
+func (m Model) viewHeader() string {
+	title := style.Title().Render("Container · " + m.name)
+
+	flags := []string{}
+	if m.raw {
+		flags = append(flags, "json")
+	} else {
+		flags = append(flags, "details")
+	}
+	if m.masked {
+		flags = append(flags, style.Success().Render("secrets masked"))
+	} else {
+		flags = append(flags, style.Warning().Render("secrets shown"))
+	}
+	if m.raw && m.search != "" {
+		if len(m.matches) == 0 {
+			flags = append(flags, style.Danger().Render(fmt.Sprintf("%q not found", m.search)))
+		} else {
+			flags = append(flags, fmt.Sprintf("%q %d/%d", m.search, m.match+1, len(m.matches)))
+		}
+	}
+
+	return lipgloss.JoinHorizontal(lipgloss.Left, title, "  ", style.Subtitle().Render(strings.Join(flags, " · ")))
+}
+
+func (m Model) viewJSON() string {
+	end := min(m.offset+m.height, len(m.lines))
+	out := make([]string, 0, m.height)
+	for i := m.offset; i < end; i++ {
+		text := m.highlight(m.lines[i].text)
+		if m.width > 0 {
+			text = lipgloss.NewStyle().MaxWidth(m.width - 1).Render(text)
+		}
+		if i == m.cursor {
+			text = style.ListSelectedLine().Render(text)
+		}
+		out = append(out, text)
+	}
+	for len(out) < m.height {
+		out = append(out, "")
+	}
+	return strings.Join(out, "\n")
+}
+
+// setSearch sets the searched text, empty to stop searching.
+func (m *Model) setSearch(text string) {
+	m.search, m.searchRe = text, nil
+	if text != "" {
+		m.searchRe = regexp.MustCompile("(?i)" + regexp.QuoteMeta(text))
+	}
+}
+
+// highlight renders the text with the searched text highlighted.
+func (m Model) highlight(text string) string {
+	if m.searchRe == nil {
+		return text
+	}
+	// the matches are found in the text itself, its lower case may not have the same length
+	var b strings.Builder
+	last := 0
+	for _, match := range m.searchRe.FindAllStringIndex(text, -1) {
+		b.WriteString(text[last:match[0]])
+		b.WriteString(highlightStyle.Render(text[match[0]:match[1]]))
+		last = match[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func (m Model) viewFooter() string {
+	if m.searching {
+		return m.input.View()
+	}
+
+	bindings := []key.Binding{keyMap.back, keyMap.toggle, keyMap.mask, keyMap.reload}
+	if m.raw {
+		bindings = append(bindings, keyMap.fold, keyMap.foldAll, keyMap.expand, keyMap.search, keyMap.next)
+	}
+	help := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		help = append(help, b.Help().Key+" "+b.Help().Desc)
+	}
+	return style.Subtitle().Render(strings.Join(help, " • "))
+}
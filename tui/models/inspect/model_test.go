@@ -0,0 +1,46 @@
+package inspect
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	// the lower case of Ⱥ is longer than Ⱥ
+	doc := `{"Config":{"Env":["NAME=ȺȺx","PATH=/bin"],"Labels":{"ȺȺȺ":"x","Title":"Straße"}},"Name":"/web"}`
+	tests := []struct {
+		search string
+		want   []string // want are the texts of the matching lines.
+	}{
+		{"ⱥⱥX", []string{`"NAME=ȺȺx",`}},
+		{"ȺȺȺ", []string{`"ȺȺȺ": "x",`}},
+		{"x", []string{`"NAME=ȺȺx",`, `"ȺȺȺ": "x",`}},
+		{"STRASSE", nil},
+		{"/WEB", []string{`"Name": "/web"`}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		root, err := parseJSON([]byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		root.setFolded(true, 1, 0)
+		m := Model{root: root, height: 40}
+		m.refreshLines()
+		m.submitSearch(tt.search)
+
+		var got []string
+		for _, i := range m.matches {
+			got = append(got, m.lines[i].text[2*m.lines[i].depth:])
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("search %q matched %q, want %q", tt.search, got, tt.want)
+		}
+		// the tests have no colors, the lines are rendered as is
+		for _, l := range m.lines {
+			if h := m.highlight(l.text); h != l.text {
+				t.Errorf("search %q highlight(%q) = %q", tt.search, l.text, h)
+			}
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package inspect
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/dustin/go-humanize"
+	"github.com/kernaxis/gmd/docker/types"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// secretMask replaces the value of the masked environment variables.
+const secretMask = "••••••••"
+
+// secretPattern matches the names of the environment variables holding secrets.
+var secretPattern = regexp.MustCompile(`(?i)(pass|secret|token|key|credential|auth|private|cert|salt|dsn)`)
+
+// healthLogSize is the maximum number of health checks shown.
+const healthLogSize = 5
+
+// section is a titled block of the details page.
+type section struct {
+	title string
+	lines []string
+}
+
+// sections returns the sections describing the container.
+func sections(c types.Container, masked bool) []section {
+	return []section{
+		{"General", general(c)},
+		{"Environment", env(c, masked)},
+		{"Mounts", mounts(c)},
+		{"Ports", ports(c)},
+		{"Networks", networks(c)},
+		{"Labels", labels(c)},
+		{"Restart policy", restartPolicy(c)},
+		{"Health", health(c)},
+		{"Resources", resources(c)},
+	}
+}
+
+// keyStyle is the style of the names, without the padding of the subtitles.
+func keyStyle() lipgloss.Style {
+	return style.Subtitle().PaddingLeft(0)
+}
+
+// field renders a name and value line.
+func field(name, value string) string {
+	return keyStyle().Render(fmt.Sprintf("%-16s", name)) + " " + value
+}
+
+// none renders the line of an empty section.
+func none(text string) []string {
+	return []string{style.Inactive().Render(text)}
+}
+
+func general(c types.Container) []string {
+	lines := []string{
+		field("ID", c.ID),
+		field("Image", c.Config.Image),
+		field("Image ID", c.Image),
+		field("Created", c.Created),
+	}
+	if c.State != nil {
+		lines = append(lines, field("State", c.State.Status))
+		if c.State.StartedAt != "" {
+			lines = append(lines, field("Started", c.State.StartedAt))
+		}
+		if !c.State.Running && c.State.FinishedAt != "" {
+			lines = append(lines, field("Finished", fmt.Sprintf("%s (exit code %d)", c.State.FinishedAt, c.State.ExitCode)))
+		}
+		if c.State.Error != "" {
+			lines = append(lines, field("Error", style.Danger().Render(c.State.Error)))
+		}
+	}
+	if len(c.Config.Entrypoint) > 0 {
+		lines = append(lines, field("Entrypoint", strings.Join(c.Config.Entrypoint, " ")))
+	}
+	if len(c.Config.Cmd) > 0 {
+		lines = append(lines, field("Command", strings.Join(c.Config.Cmd, " ")))
+	}
+	if c.Config.WorkingDir != "" {
+		lines = append(lines, field("Working dir", c.Config.WorkingDir))
+	}
+	if c.Config.User != "" {
+		lines = append(lines, field("User", c.Config.User))
+	}
+	policy := types.UpdatePolicyOf(c.Config.Labels)
+	if policy.Invalid != "" {
+		lines = append(lines, field("Update policy", style.Danger().Render(policy.Invalid)))
+	} else {
+		lines = append(lines, field("Update policy", policy.String()))
+	}
+	return lines
+}
+
+// isSecret reports whether the environment variable holds a secret.
+func isSecret(name string) bool {
+	return secretPattern.MatchString(name)
+}
+
+// maskEnv returns the environment with the values of the secrets masked.
+func maskEnv(vars []string) []string {
+	out := make([]string, len(vars))
+	for i, v := range vars {
+		name, _, found := strings.Cut(v, "=")
+		if found && isSecret(name) {
+			v = name + "=" + secretMask
+		}
+		out[i] = v
+	}
+	return out
+}
+
+func env(c types.Container, masked bool) []string {
+	if len(c.Config.Env) == 0 {
+		return none("no environment variables")
+	}
+	vars := c.Config.Env
+	if masked {
+		vars = maskEnv(vars)
+	}
+	vars = append([]string(nil), vars...)
+	sort.Strings(vars)
+
+	lines := make([]string, 0, len(vars))
+	for _, v := range vars {
+		name, value, _ := strings.Cut(v, "=")
+		lines = append(lines, keyStyle().Render(name)+"="+value)
+	}
+	return lines
+}
+
+func mounts(c types.Container) []string {
+	if len(c.Mounts) == 0 {
+		return none("no mounts")
+	}
+	lines := make([]string, 0, len(c.Mounts))
+	for _, mp := range c.Mounts {
+		source := mp.Source
+		if mp.Name != "" {
+			source = mp.Name
+		}
+		mode := "rw"
+		if !mp.RW {
+			mode = "ro"
+		}
+		lines = append(lines, fmt.Sprintf("%-6s %s → %s %s", mp.Type, source, mp.Destination, keyStyle().Render(mode)))
+	}
+	return lines
+}
+
+func ports(c types.Container) []string {
+	bindings := nat.PortMap{}
+	if c.HostConfig != nil {
+		for p, b := range c.HostConfig.PortBindings {
+			bindings[p] = b
+		}
+	}
+	// the published ports of a running container carry the allocated host ports
+	if c.NetworkSettings != nil {
+		for p, b := range c.NetworkSettings.Ports {
+			if len(b) > 0 {
+				bindings[p] = b
+			}
+		}
+	}
+	for p := range c.Config.ExposedPorts {
+		if _, ok := bindings[p]; !ok {
+			bindings[p] = nil
+		}
+	}
+	if len(bindings) == 0 {
+		return none("no ports")
+	}
+
+	keys := make([]nat.Port, 0, len(bindings))
+	for p := range bindings {
+		keys = append(keys, p)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Int() != keys[j].Int() {
+			return keys[i].Int() < keys[j].Int()
+		}
+		return keys[i].Proto() < keys[j].Proto()
+	})
+
+	lines := make([]string, 0, len(keys))
+	for _, p := range keys {
+		if len(bindings[p]) == 0 {
+			lines = append(lines, fmt.Sprintf("%s %s", p, style.Inactive().Render("exposed")))
+			continue
+		}
+		hosts := make([]string, 0, len(bindings[p]))
+		for _, b := range bindings[p] {
+			ip := b.HostIP
+			if ip == "" {
+				ip = "0.0.0.0"
+			}
+			hosts = append(hosts, ip+":"+b.HostPort)
+		}
+		lines = append(lines, fmt.Sprintf("%s → %s", strings.Join(hosts, ", "), p))
+	}
+	return lines
+}
+
+func networks(c types.Container) []string {
+	if c.NetworkSettings == nil || len(c.NetworkSettings.Networks) == 0 {
+		return none("no networks")
+	}
+	names := make([]string, 0, len(c.NetworkSettings.Networks))
+	for name := range c.NetworkSettings.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		ep := c.NetworkSettings.Networks[name]
+		parts := []string{}
+		if ep.IPAddress != "" {
+			parts = append(parts, ep.IPAddress)
+		}
+		if ep.GlobalIPv6Address != "" {
+			parts = append(parts, ep.GlobalIPv6Address)
+		}
+		if ep.Gateway != "" {
+			parts = append(parts, "gw "+ep.Gateway)
+		}
+		if ep.MacAddress != "" {
+			parts = append(parts, "mac "+ep.MacAddress)
+		}
+		if len(ep.Aliases) > 0 {
+			parts = append(parts, "aliases "+strings.Join(ep.Aliases, ","))
+		}
+		lines = append(lines, keyStyle().Render(name)+" "+strings.Join(parts, " · "))
+	}
+	return lines
+}
+
+func labels(c types.Container) []string {
+	if len(c.Config.Labels) == 0 {
+		return none("no labels")
+	}
+	keys := make([]string, 0, len(c.Config.Labels))
+	for k := range c.Config.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, keyStyle().Render(k)+"="+c.Config.Labels[k])
+	}
+	return lines
+}
+
+func restartPolicy(c types.Container) []string {
+	if c.HostConfig == nil || c.HostConfig.RestartPolicy.Name == "" {
+		return []string{field("Policy", string(container.RestartPolicyDisabled))}
+	}
+	policy := c.HostConfig.RestartPolicy
+	lines := []string{field("Policy", string(policy.Name))}
+	if policy.IsOnFailure() && policy.MaximumRetryCount > 0 {
+		lines = append(lines, field("Max retries", fmt.Sprint(policy.MaximumRetryCount)))
+	}
+	if c.RestartCount > 0 {
+		lines = append(lines, field("Restarts", fmt.Sprint(c.RestartCount)))
+	}
+	return lines
+}
+
+func health(c types.Container) []string {
+	var lines []string
+	if hc := c.Config.Healthcheck; hc != nil && len(hc.Test) > 0 {
+		lines = append(lines, field("Test", strings.Join(hc.Test, " ")))
+		if hc.Interval > 0 {
+			lines = append(lines, field("Interval", hc.Interval.String()))
+		}
+		if hc.Timeout > 0 {
+			lines = append(lines, field("Timeout", hc.Timeout.String()))
+		}
+		if hc.Retries > 0 {
+			lines = append(lines, field("Retries", fmt.Sprint(hc.Retries)))
+		}
+	}
+	if c.State == nil || c.State.Health == nil {
+		if len(lines) == 0 {
+			return none("no healthcheck")
+		}
+		return lines
+	}
+
+	h := c.State.Health
+	lines = append(lines, field("Status", healthStatus(h.Status)))
+	if h.FailingStreak > 0 {
+		lines = append(lines, field("Failing streak", fmt.Sprint(h.FailingStreak)))
+	}
+	logs := h.Log
+	if len(logs) > healthLogSize {
+		logs = logs[len(logs)-healthLogSize:]
+	}
+	for _, r := range logs {
+		output := strings.Join(strings.Fields(r.Output), " ")
+		result := style.Success().Render(fmt.Sprintf("exit %d", r.ExitCode))
+		if r.ExitCode != 0 {
+			result = style.Danger().Render(fmt.Sprintf("exit %d", r.ExitCode))
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s", keyStyle().Render(r.Start.Local().Format(time.DateTime)), result, output))
+	}
+	return lines
+}
+
+// healthStatus renders the health status with its color.
+func healthStatus(status container.HealthStatus) string {
+	switch status {
+	case container.Healthy:
+		return style.Success().Render(status)
+	case container.Unhealthy:
+		return style.Danger().Render(status)
+	default:
+		return style.Warning().Render(status)
+	}
+}
+
+func resources(c types.Container) []string {
+	if c.HostConfig == nil {
+		return none("no limits")
+	}
+	r := c.HostConfig.Resources
+	var lines []string
+	if r.NanoCPUs > 0 {
+		lines = append(lines, field("CPUs", fmt.Sprintf("%.2f", float64(r.NanoCPUs)/1e9)))
+	}
+	if r.CPUQuota > 0 {
+		period := r.CPUPeriod
+		if period == 0 {
+			period = 100000
+		}
+		lines = append(lines, field("CPU quota", fmt.Sprintf("%d/%dµs (%.2f CPUs)", r.CPUQuota, period, float64(r.CPUQuota)/float64(period))))
+	}
+	if r.CPUShares > 0 {
+		lines = append(lines, field("CPU shares", fmt.Sprint(r.CPUShares)))
+	}
+	if r.CpusetCpus != "" {
+		lines = append(lines, field("CPU set", r.CpusetCpus))
+	}
+	if r.Memory > 0 {
+		lines = append(lines, field("Memory", humanize.IBytes(uint64(r.Memory))))
+	}
+	if r.MemoryReservation > 0 {
+		lines = append(lines, field("Memory reserved", humanize.IBytes(uint64(r.MemoryReservation))))
+	}
+	if r.MemorySwap > 0 {
+		lines = append(lines, field("Memory + swap", humanize.IBytes(uint64(r.MemorySwap))))
+	}
+	if r.PidsLimit != nil && *r.PidsLimit > 0 {
+		lines = append(lines, field("PIDs", fmt.Sprint(*r.PidsLimit)))
+	}
+	if len(lines) == 0 {
+		return none("no limits")
+	}
+	return lines
+}
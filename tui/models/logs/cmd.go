@@ -0,0 +1,75 @@
+package logs
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kernaxis/gmd/docker/client"
+)
+
+// maxBatch is the maximum number of lines delivered by a single LogLinesMsg.
+const maxBatch = 500
+
+// logStream is a running read of the logs of a container.
+type logStream struct {
+	lines  chan client.LogLine
+	cancel context.CancelFunc
+	err    error // err is the error returned by the read, set before lines is closed.
+}
+
+// LogLinesMsg delivers the lines read by a stream.
+type LogLinesMsg struct {
+	stream *logStream
+	Lines  []client.LogLine
+}
+
+// LogsEndMsg is sent once a stream is over.
+type LogsEndMsg struct {
+	stream *logStream
+	Err    error
+}
+
+// startStream starts reading the logs of the container in the background.
+func startStream(cli client.Client, id string, options client.LogsOptions) *logStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &logStream{
+		lines:  make(chan client.LogLine, 1024),
+		cancel: cancel,
+	}
+
+	go func() {
+		s.err = cli.ContainerLogs(ctx, id, options, func(l client.LogLine) {
+			select {
+			case s.lines <- l:
+			case <-ctx.Done():
+			}
+		})
+		close(s.lines)
+	}()
+
+	return s
+}
+
+// waitLines waits for the next lines of the stream and returns them by batch.
+func waitLines(s *logStream) tea.Cmd {
+	return func() tea.Msg {
+		l, ok := <-s.lines
+		if !ok {
+			return LogsEndMsg{stream: s, Err: s.err}
+		}
+
+		lines := []client.LogLine{l}
+		for len(lines) < maxBatch {
+			select {
+			case l, ok := <-s.lines:
+				if !ok {
+					return LogLinesMsg{stream: s, Lines: lines}
+				}
+				lines = append(lines, l)
+			default:
+				return LogLinesMsg{stream: s, Lines: lines}
+			}
+		}
+		return LogLinesMsg{stream: s, Lines: lines}
+	}
+}
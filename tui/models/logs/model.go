@@ -0,0 +1,597 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/tui/commands"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+const (
+	// tailLines is the number of lines read from the end of the logs when no window is set.
+	tailLines = 1000
+	// maxLines is the number of lines kept in the buffer, the oldest lines are dropped first.
+	maxLines = 10000
+)
+
+// inputMode is the purpose of the input line.
+type inputMode int
+
+const (
+	inputNone inputMode = iota
+	inputSearch
+	inputFilter
+	inputWindow
+	inputSave
+)
+
+var highlightStyle = lipgloss.NewStyle().Reverse(true)
+
+type Model struct {
+	cli  client.Client
+	id   string
+	name string
+
+	viewport viewport.Model
+	input    textinput.Model
+	mode     inputMode
+	status   string
+
+	stream     *logStream
+	streaming  bool
+	lines      []client.LogLine
+	shown      []int    // shown holds the index in lines of the lines passing the filter.
+	rendered   []string // rendered holds the rendering of the shown lines.
+	matches    []int    // matches holds the index in shown of the lines matching the search.
+	match      int      // match is the index in matches of the current match.
+	follow     bool
+	timestamps bool
+	since      time.Time
+	until      time.Time
+	filter     *regexp.Regexp
+	search     string
+	searchRe   *regexp.Regexp // searchRe finds the search in the lines, ignoring the case.
+}
+
+type keyMapping struct {
+	back       key.Binding
+	follow     key.Binding
+	timestamps key.Binding
+	search     key.Binding
+	next       key.Binding
+	previous   key.Binding
+	filter     key.Binding
+	window     key.Binding
+	save       key.Binding
+}
+
+var keyMap = &keyMapping{
+	back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	follow: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "follow"),
+	),
+	timestamps: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "timestamps"),
+	),
+	search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	next: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n/N", "next/prev match"),
+	),
+	previous: key.NewBinding(
+		key.WithKeys("N"),
+	),
+	filter: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "regex filter"),
+	),
+	window: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "time window"),
+	),
+	save: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "save"),
+	),
+}
+
+// New returns a log viewer following the logs of the container with the given ID.
+func New(cli client.Client, id, name string) Model {
+	vp := viewport.New(0, 0)
+	vp.SetHorizontalStep(8)
+
+	input := textinput.New()
+	input.CharLimit = 256
+
+	m := Model{
+		cli:      cli,
+		id:       id,
+		name:     strings.TrimPrefix(name, "/"),
+		viewport: vp,
+		input:    input,
+		follow:   true,
+	}
+	m.restart()
+	return m
+}
+
+func (m Model) Init() tea.Cmd {
+	return waitLines(m.stream)
+}
+
+// IsSearching reports whether the keys are sent to the input line.
+func (m Model) IsSearching() bool {
+	return m.mode != inputNone
+}
+
+// restart drops the buffer and reads the logs again with the current window.
+func (m *Model) restart() tea.Cmd {
+	m.lines = nil
+	m.rebuild()
+	return m.startStream(time.Time{})
+}
+
+// startStream starts a new read of the logs, replacing the current one.
+// When after is not zero, only the lines written after it are read.
+func (m *Model) startStream(after time.Time) tea.Cmd {
+	m.stopStream()
+
+	options := client.LogsOptions{
+		Follow: m.follow && m.until.IsZero(),
+		Since:  m.since,
+		Until:  m.until,
+	}
+	if !after.IsZero() {
+		options.Since = after.Add(time.Nanosecond)
+	} else if m.since.IsZero() {
+		options.Tail = tailLines
+	}
+
+	m.stream = startStream(m.cli, m.id, options)
+	m.streaming = true
+	return waitLines(m.stream)
+}
+
+// stopStream cancels the current read of the logs, if any.
+func (m *Model) stopStream() {
+	if m.stream != nil {
+		m.stream.cancel()
+		m.stream = nil
+	}
+	m.streaming = false
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.viewport.Width = msg.Width
+		m.viewport.Height = msg.Height - 3
+		m.input.Width = msg.Width - 30
+		m.viewport.SetContent(strings.Join(m.rendered, "\n"))
+		return m, nil
+
+	case LogLinesMsg:
+		if msg.stream != m.stream {
+			return m, nil
+		}
+		m.appendLines(msg.Lines)
+		return m, waitLines(m.stream)
+
+	case LogsEndMsg:
+		if msg.stream != m.stream {
+			return m, nil
+		}
+		m.streaming = false
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else if m.follow {
+			m.follow = false
+			m.status = style.Inactive().Render("Log stream ended")
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.mode != inputNone {
+			return m, m.updateInput(msg)
+		}
+
+		switch {
+		case key.Matches(msg, keyMap.back):
+			if m.search != "" {
+				m.search = ""
+				m.rebuild()
+				return m, nil
+			}
+			m.stopStream()
+			return m, commands.SwitchPageCmd(nil)
+
+		case key.Matches(msg, keyMap.follow):
+			if m.follow {
+				m.follow = false
+				m.stopStream()
+				return m, nil
+			}
+			if !m.until.IsZero() {
+				m.status = style.Warning().Render("Follow is not available with an until bound")
+				return m, nil
+			}
+			m.follow = true
+			m.viewport.GotoBottom()
+			var after time.Time
+			if len(m.lines) > 0 {
+				after = m.lines[len(m.lines)-1].Timestamp
+			}
+			return m, m.startStream(after)
+
+		case key.Matches(msg, keyMap.timestamps):
+			m.timestamps = !m.timestamps
+			m.rebuild()
+			return m, nil
+
+		case key.Matches(msg, keyMap.search):
+			return m, m.openInput(inputSearch, "/", "")
+
+		case key.Matches(msg, keyMap.next):
+			m.gotoMatch(m.match + 1)
+			return m, nil
+
+		case key.Matches(msg, keyMap.previous):
+			m.gotoMatch(m.match - 1)
+			return m, nil
+
+		case key.Matches(msg, keyMap.filter):
+			value := ""
+			if m.filter != nil {
+				value = m.filter.String()
+			}
+			return m, m.openInput(inputFilter, "Filter (regex): ", value)
+
+		case key.Matches(msg, keyMap.window):
+			return m, m.openInput(inputWindow, "Window (since [until], e.g. 1h or 2024-01-02T15:04:05Z): ", m.windowString())
+
+		case key.Matches(msg, keyMap.save):
+			return m, m.openInput(inputSave, "Save to: ", fmt.Sprintf("%s-%s.log", m.name, time.Now().Format("20060102-150405")))
+		}
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+// openInput shows the input line for the given mode, filled with value.
+func (m *Model) openInput(mode inputMode, prompt, value string) tea.Cmd {
+	m.mode = mode
+	m.input.Prompt = prompt
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+// updateInput handles a key stroke while the input line is shown.
+func (m *Model) updateInput(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		if m.mode == inputSearch {
+			m.search = ""
+			m.rebuild()
+		}
+		m.closeInput()
+		return nil
+	case "enter":
+		mode, value := m.mode, strings.TrimSpace(m.input.Value())
+		m.closeInput()
+		return m.submit(mode, value)
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+
+	// incremental search
+	if m.mode == inputSearch && m.input.Value() != m.search {
+		m.search = m.input.Value()
+		m.rebuild()
+		m.gotoMatch(m.firstMatchFromTop())
+	}
+	return cmd
+}
+
+func (m *Model) closeInput() {
+	m.mode = inputNone
+	m.input.Blur()
+}
+
+// submit applies the value entered for the given mode.
+func (m *Model) submit(mode inputMode, value string) tea.Cmd {
+	m.status = ""
+
+	switch mode {
+	case inputFilter:
+		m.filter = nil
+		if value != "" {
+			re, err := regexp.Compile(value)
+			if err != nil {
+				m.status = style.Danger().Render(err.Error())
+				return nil
+			}
+			m.filter = re
+		}
+		m.rebuild()
+
+	case inputWindow:
+		since, until, err := parseWindow(value, time.Now())
+		if err != nil {
+			m.status = style.Danger().Render(err.Error())
+			return nil
+		}
+		m.since, m.until = since, until
+		if !m.until.IsZero() {
+			m.follow = false
+		}
+		return m.restart()
+
+	case inputSave:
+		if value == "" {
+			return nil
+		}
+		if err := m.save(value); err != nil {
+			m.status = style.Danger().Render(err.Error())
+			return nil
+		}
+		m.status = style.Success().Render(fmt.Sprintf("%d lines saved to %s", len(m.shown), value))
+	}
+	return nil
+}
+
+// appendLines adds the lines to the buffer and to the view.
+func (m *Model) appendLines(lines []client.LogLine) {
+	atBottom := m.viewport.AtBottom()
+
+	m.lines = append(m.lines, lines...)
+	if len(m.lines) > maxLines {
+		m.lines = m.lines[len(m.lines)-maxLines:]
+		m.rebuild()
+	} else {
+		m.addShown(len(m.lines) - len(lines))
+		m.viewport.SetContent(strings.Join(m.rendered, "\n"))
+	}
+
+	if m.follow && atBottom {
+		m.viewport.GotoBottom()
+	}
+}
+
+// rebuild renders the whole buffer again, after a change of the filter, the search or the timestamps.
+func (m *Model) rebuild() {
+	m.searchRe = searchPattern(m.search)
+	m.shown = m.shown[:0]
+	m.rendered = m.rendered[:0]
+	m.matches = m.matches[:0]
+	m.addShown(0)
+	m.viewport.SetContent(strings.Join(m.rendered, "\n"))
+	if m.match >= len(m.matches) {
+		m.match = 0
+	}
+}
+
+// addShown renders the lines of the buffer from index from.
+func (m *Model) addShown(from int) {
+	for i := from; i < len(m.lines); i++ {
+		l := m.lines[i]
+		if m.filter != nil && !m.filter.MatchString(l.Text) {
+			continue
+		}
+		if m.searchRe != nil && m.searchRe.MatchString(l.Text) {
+			m.matches = append(m.matches, len(m.shown))
+		}
+		m.shown = append(m.shown, i)
+		m.rendered = append(m.rendered, m.renderLine(l))
+	}
+}
+
+// renderLine renders a log line, stderr in red, with the search matches highlighted.
+func (m *Model) renderLine(l client.LogLine) string {
+	lineStyle := style.Normal()
+	if l.Stream == client.Stderr {
+		lineStyle = style.Danger()
+	}
+
+	var b strings.Builder
+	if m.timestamps {
+		b.WriteString(style.Subtitle().Render(l.Timestamp.Local().Format(time.RFC3339Nano)))
+		b.WriteString(" ")
+	}
+
+	text := l.Text
+	if m.searchRe == nil {
+		b.WriteString(lineStyle.Render(text))
+		return b.String()
+	}
+
+	// the matches are found in the line itself, its lower case may not have the same length
+	last := 0
+	for _, match := range m.searchRe.FindAllStringIndex(text, -1) {
+		b.WriteString(lineStyle.Render(text[last:match[0]]))
+		b.WriteString(highlightStyle.Render(text[match[0]:match[1]]))
+		last = match[1]
+	}
+	b.WriteString(lineStyle.Render(text[last:]))
+	return b.String()
+}
+
+// searchPattern returns the expression finding the search text, ignoring the case, nil without search.
+func searchPattern(search string) *regexp.Regexp {
+	if search == "" {
+		return nil
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(search))
+}
+
+// firstMatchFromTop returns the index of the first match shown at or below the top of the view.
+func (m *Model) firstMatchFromTop() int {
+	for i, line := range m.matches {
+		if line >= m.viewport.YOffset {
+			return i
+		}
+	}
+	return 0
+}
+
+// gotoMatch scrolls the view to the match with the given index, wrapping around.
+func (m *Model) gotoMatch(i int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.match = (i + len(m.matches)) % len(m.matches)
+	m.viewport.SetYOffset(m.matches[m.match] - m.viewport.Height/2)
+}
+
+// save writes the shown lines to the file at path, without colors.
+func (m *Model) save(path string) error {
+	var b strings.Builder
+	for _, i := range m.shown {
+		l := m.lines[i]
+		if m.timestamps {
+			b.WriteString(l.Timestamp.Format(time.RFC3339Nano))
+			b.WriteString(" ")
+		}
+		b.WriteString(l.Text)
+		b.WriteString("\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o600)
+}
+
+// windowString returns the current time window as typed in the window input.
+func (m Model) windowString() string {
+	var parts []string
+	if !m.since.IsZero() || !m.until.IsZero() {
+		parts = append(parts, formatBound(m.since))
+	}
+	if !m.until.IsZero() {
+		parts = append(parts, formatBound(m.until))
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseWindow parses "since [until]" where each bound is a duration before
+// now, a RFC3339 time or "-" for no bound. An empty value removes the window.
+func parseWindow(value string, now time.Time) (time.Time, time.Time, error) {
+	fields := strings.Fields(value)
+	if len(fields) > 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid window %q: expected since [until]", value)
+	}
+
+	var bounds [2]time.Time
+	for i, f := range fields {
+		t, err := parseBound(f, now)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		bounds[i] = t
+	}
+
+	if !bounds[0].IsZero() && !bounds[1].IsZero() && bounds[1].Before(bounds[0]) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid window %q: until is before since", value)
+	}
+	return bounds[0], bounds[1], nil
+}
+
+func parseBound(s string, now time.Time) (time.Time, error) {
+	if s == "-" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected a duration like 15m or a RFC3339 time", s)
+}
+
+func formatBound(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func (m Model) View() string {
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.viewHeader(),
+		m.viewport.View(),
+		m.viewFooter(),
+	)
+}
+
+func (m Model) viewHeader() string {
+	title := style.Title().Render("Logs · " + m.name)
+
+	flags := []string{}
+	if m.follow {
+		flags = append(flags, style.Success().Render("follow"))
+	} else if m.streaming {
+		flags = append(flags, style.Warning().Render("loading"))
+	} else {
+		flags = append(flags, style.Inactive().Render("paused"))
+	}
+	if m.timestamps {
+		flags = append(flags, "timestamps")
+	}
+	if w := m.windowString(); w != "" {
+		flags = append(flags, "window "+w)
+	}
+	if m.filter != nil {
+		flags = append(flags, "filter /"+m.filter.String()+"/")
+	}
+	if m.search != "" {
+		if len(m.matches) == 0 {
+			flags = append(flags, style.Danger().Render(fmt.Sprintf("%q not found", m.search)))
+		} else {
+			flags = append(flags, fmt.Sprintf("%q %d/%d", m.search, m.match+1, len(m.matches)))
+		}
+	}
+	flags = append(flags, fmt.Sprintf("%d lines", len(m.shown)))
+
+	return lipgloss.JoinHorizontal(lipgloss.Left, title, "  ", style.Subtitle().Render(strings.Join(flags, " · ")))
+}
+
+func (m Model) viewFooter() string {
+	if m.mode != inputNone {
+		return m.input.View()
+	}
+	if m.status != "" {
+		return m.status
+	}
+
+	bindings := []key.Binding{
+		keyMap.back, keyMap.follow, keyMap.timestamps, keyMap.search, keyMap.next,
+		keyMap.filter, keyMap.window, keyMap.save,
+	}
+	help := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		help = append(help, b.Help().Key+" "+b.Help().Desc)
+	}
+	return style.Subtitle().Render(strings.Join(help, " • "))
+}
@@ -0,0 +1,46 @@
+package logs
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/kernaxis/gmd/docker/client"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		text, search string
+		want         []string
+	}{
+		{"error: disk full", "ERROR", []string{"error"}},
+		{"Error, error and ERROR", "error", []string{"Error", "error", "ERROR"}},
+		// the lower case of Ⱥ is longer than Ⱥ
+		{"ȺȺx failed", "ⱥⱥX", []string{"ȺȺx"}},
+		{"ȺȺȺ ȺȺȺ x", "x", []string{"x"}},
+		{"straße 1.5", "STRASSE", nil},
+		{"a.b axb", ".", []string{"."}},
+		{"no match", "", nil},
+	}
+	for _, tt := range tests {
+		m := Model{viewport: viewport.New(80, 10), lines: []client.LogLine{{Text: tt.text}}, search: tt.search}
+		m.rebuild()
+
+		var got []string
+		if m.searchRe != nil {
+			for _, match := range m.searchRe.FindAllStringIndex(tt.text, -1) {
+				got = append(got, tt.text[match[0]:match[1]])
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("search %q in %q = %q, want %q", tt.search, tt.text, got, tt.want)
+		}
+		if matched := len(m.matches) > 0; matched != (len(tt.want) > 0) {
+			t.Errorf("search %q in %q matched %t, want %t", tt.search, tt.text, matched, len(tt.want) > 0)
+		}
+		// the tests have no colors, the line is rendered as is
+		if len(m.rendered) != 1 || m.rendered[0] != tt.text {
+			t.Errorf("search %q in %q rendered %q", tt.search, tt.text, m.rendered)
+		}
+	}
+}
@@ -1,13 +1,18 @@
 package maintab
 
 import (
+	"fmt"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/kernaxis/gmd/docker/cache"
-	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/tui/commands"
 	"github.com/kernaxis/gmd/tui/componants"
 	"github.com/kernaxis/gmd/tui/models/containers"
+	"github.com/kernaxis/gmd/tui/models/hosts"
 	"github.com/kernaxis/gmd/tui/models/images"
+	"github.com/kernaxis/gmd/tui/models/networks"
+	"github.com/kernaxis/gmd/tui/models/volumes"
 	style "github.com/kernaxis/gmd/tui/styles"
 )
 
@@ -24,26 +29,47 @@ type SwitchTabMsg int
 const (
 	imagesTabIndex     = 0
 	containersTabIndex = 1
+	volumesTabIndex    = 2
+	networksTabIndex   = 3
+	hostsTabIndex      = 4
 )
 
 type Model struct {
-	cache     *cache.Cache
+	caches    []*cache.Cache
 	lists     []componants.ListModel
 	activeTab int
+	endpoint  string
 }
 
-func New(cli *client.Client, cache *cache.Cache) Model {
+func New(caches []*cache.Cache) Model {
 
 	m := Model{
-		cache: cache,
-		lists: make([]componants.ListModel, 2),
+		caches: caches,
+		lists:  make([]componants.ListModel, 5),
 	}
+	m.endpoint = m.hostLabel("")
 
-	m.lists[imagesTabIndex] = images.New(cli, cache)
-	m.lists[containersTabIndex] = containers.New(cli, cache)
+	m.lists[imagesTabIndex] = images.New(caches)
+	m.lists[containersTabIndex] = containers.New(caches)
+	m.lists[volumesTabIndex] = volumes.New(caches)
+	m.lists[networksTabIndex] = networks.New(caches)
+	m.lists[hostsTabIndex] = hosts.New(caches)
 	return m
 }
 
+// hostLabel returns the header label describing the selected host.
+func (m Model) hostLabel(host string) string {
+	if len(m.caches) == 1 {
+		return m.caches[0].Client().Endpoint().String()
+	}
+	for _, c := range m.caches {
+		if c.Host() == host {
+			return c.Client().Endpoint().String()
+		}
+	}
+	return fmt.Sprintf("all hosts (%d)", len(m.caches))
+}
+
 func (m Model) Init() tea.Cmd {
 	cmds := make([]tea.Cmd, 0, 2)
 	for i := range m.lists {
@@ -65,6 +91,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case tea.KeyMsg:
+		if m.IsSearching() {
+			l, cmd := m.lists[m.activeTab].Update(msg)
+			m.lists[m.activeTab] = l
+			return m, cmd
+		}
 		switch msg.String() {
 
 		case "tab", "ctrl+tab":
@@ -86,17 +117,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.lists[m.activeTab] = l
 		return m, cmd
 
+	case commands.SelectHostMsg:
+		m.endpoint = m.hostLabel(msg.Host)
+		m.activeTab = containersTabIndex
+
 	case cache.Event:
+		hl, hcmd := m.lists[hostsTabIndex].Update(msg)
+		m.lists[hostsTabIndex] = hl
+
 		switch msg.EventType {
 		case cache.ImagesLoadedEventType, cache.ImageEventType:
 			l, cmd := m.lists[imagesTabIndex].Update(msg)
 			m.lists[imagesTabIndex] = l
-			return m, cmd
+			return m, tea.Batch(hcmd, cmd)
 		case cache.ContainersLoadedEventType, cache.ContainerEventType /*cache.ContainerStatsEventType*/ :
 			l, cmd := m.lists[containersTabIndex].Update(msg)
 			m.lists[containersTabIndex] = l
-			return m, cmd
+			// volumes show the containers using them
+			vl, vcmd := m.lists[volumesTabIndex].Update(msg)
+			m.lists[volumesTabIndex] = vl
+			return m, tea.Batch(hcmd, cmd, vcmd)
+		case cache.NetworksLoadedEventType, cache.NetworkEventType:
+			l, cmd := m.lists[networksTabIndex].Update(msg)
+			m.lists[networksTabIndex] = l
+			return m, tea.Batch(hcmd, cmd)
+		case cache.VolumesLoadedEventType, cache.VolumeEventType:
+			l, cmd := m.lists[volumesTabIndex].Update(msg)
+			m.lists[volumesTabIndex] = l
+			return m, tea.Batch(hcmd, cmd)
 		}
+		return m, hcmd
 	}
 
 	// pass all events to all lists
@@ -127,6 +177,9 @@ func (m Model) viewTabs() string {
 	var (
 		tabImages     = style.Inactive().Render(" Images ")
 		tabContainers = style.Inactive().Render(" Containers ")
+		tabVolumes    = style.Inactive().Render(" Volumes ")
+		tabNetworks   = style.Inactive().Render(" Networks ")
+		tabHosts      = style.Inactive().Render(" Hosts ")
 	)
 
 	switch m.activeTab {
@@ -134,9 +187,17 @@ func (m Model) viewTabs() string {
 		tabImages = style.Success().Render(" Images ")
 	case containersTabIndex:
 		tabContainers = style.Success().Render(" Containers ")
+	case volumesTabIndex:
+		tabVolumes = style.Success().Render(" Volumes ")
+	case networksTabIndex:
+		tabNetworks = style.Success().Render(" Networks ")
+	case hostsTabIndex:
+		tabHosts = style.Success().Render(" Hosts ")
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Left, tabImages, tabContainers)
+	endpoint := style.Subtitle().Render("⎈ " + m.endpoint)
+
+	return lipgloss.JoinHorizontal(lipgloss.Left, tabImages, tabContainers, tabVolumes, tabNetworks, tabHosts, "  ", endpoint)
 }
 
 func (m Model) viewContent() string {
@@ -0,0 +1,56 @@
+package networks
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kernaxis/gmd/docker/cache"
+)
+
+type CreateNetworkMsg struct {
+	Host string
+	Name string
+	Err  error
+}
+
+type DeleteNetworkMsg struct {
+	Host string
+	Name string
+	Err  error
+}
+
+type ConnectNetworkMsg struct {
+	Host       string
+	Network    string
+	Container  string
+	Disconnect bool
+	Err        error
+}
+
+func CreateNetworkCmd(c *cache.Cache, name, driver, subnet string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := c.Client().CreateNetwork(context.Background(), name, driver, subnet)
+		return CreateNetworkMsg{Host: c.Host(), Name: name, Err: err}
+	}
+}
+
+func DeleteNetworkCmd(c *cache.Cache, id, name string) tea.Cmd {
+	return func() tea.Msg {
+		err := c.Client().DeleteNetwork(context.Background(), id)
+		return DeleteNetworkMsg{Host: c.Host(), Name: name, Err: err}
+	}
+}
+
+func ConnectNetworkCmd(c *cache.Cache, network, container string) tea.Cmd {
+	return func() tea.Msg {
+		err := c.Client().ConnectNetwork(context.Background(), network, container)
+		return ConnectNetworkMsg{Host: c.Host(), Network: network, Container: container, Err: err}
+	}
+}
+
+func DisconnectNetworkCmd(c *cache.Cache, network, container string) tea.Cmd {
+	return func() tea.Msg {
+		err := c.Client().DisconnectNetwork(context.Background(), network, container)
+		return ConnectNetworkMsg{Host: c.Host(), Network: network, Container: container, Disconnect: true, Err: err}
+	}
+}
@@ -0,0 +1,81 @@
+package networks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+type NetworkItem struct {
+	types.Network
+	host     string
+	showHost bool
+}
+
+func NewNetworkItem(host string, n types.Network) NetworkItem {
+	return NetworkItem{Network: n, host: host}
+}
+
+func (i NetworkItem) Title() string { return i.Name }
+
+func (i NetworkItem) Description() string {
+	parts := []string{i.Driver}
+	if subnet := i.Subnet(); subnet != "" {
+		parts = append(parts, subnet)
+	}
+	if gateway := i.Gateway(); gateway != "" {
+		parts = append(parts, "gw "+gateway)
+	}
+	parts = append(parts, i.Scope, i.ConnectedString())
+	if i.showHost {
+		parts = append(parts, i.host)
+	}
+	return strings.Join(parts, " - ")
+}
+
+// ConnectedString returns the number of containers connected to the network.
+func (i NetworkItem) ConnectedString() string {
+	switch len(i.Containers) {
+	case 0:
+		return "no container"
+	case 1:
+		return "1 container"
+	default:
+		return fmt.Sprintf("%d containers", len(i.Containers))
+	}
+}
+
+// Details returns at most max lines describing the connected containers of the network.
+func (i NetworkItem) Details(max int) []string {
+	endpoints := i.Endpoints()
+	if len(endpoints) == 0 {
+		return []string{"No connected container"}
+	}
+
+	lines := []string{"Connected containers:"}
+	for n, ep := range endpoints {
+		if len(lines) == max-1 && n < len(endpoints)-1 {
+			lines = append(lines, fmt.Sprintf("  … %d more", len(endpoints)-n))
+			break
+		}
+		addresses := []string{}
+		if ep.IPv4Address != "" {
+			addresses = append(addresses, ep.IPv4Address)
+		}
+		if ep.IPv6Address != "" {
+			addresses = append(addresses, ep.IPv6Address)
+		}
+		lines = append(lines, fmt.Sprintf("  %-24s %s", ep.Name, valueOrDash(strings.Join(addresses, " "))))
+	}
+	return lines
+}
+
+func (i NetworkItem) FilterValue() string { return i.Name }
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,326 @@
+package networks
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kernaxis/gmd/docker/cache"
+	"github.com/kernaxis/gmd/tui/commands"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// detailsHeight is the number of lines used below the list to show the selected network.
+const detailsHeight = 7
+
+// promptAction is the action the input prompt has been opened for.
+type promptAction int
+
+const (
+	promptNone promptAction = iota
+	promptCreate
+	promptConnect
+	promptDisconnect
+)
+
+type Model struct {
+	caches []*cache.Cache
+	host   string // host is the name of the selected host, empty when all hosts are shown.
+	list   list.Model
+	loaded bool
+	status string
+	input  textinput.Model
+	action promptAction
+}
+
+type listKeyMap struct {
+	create     key.Binding
+	delete     key.Binding
+	connect    key.Binding
+	disconnect key.Binding
+}
+
+var keyMap = &listKeyMap{
+	create: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "create network"),
+	),
+	delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete selection"),
+	),
+	connect: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "connect container"),
+	),
+	disconnect: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "disconnect container"),
+	),
+}
+
+func New(caches []*cache.Cache) Model {
+
+	items := []list.Item{}
+
+	l := list.New(items, newItemDelegate(), 0, 0)
+	l.Title = "Networks"
+	l.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keyMap.create,
+			keyMap.delete,
+			keyMap.connect,
+			keyMap.disconnect,
+		}
+	}
+
+	input := textinput.New()
+	input.CharLimit = 128
+
+	return Model{
+		caches: caches,
+		list:   l,
+		input:  input,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// IsSearching reports whether keys must be sent to the list filter or to the input prompt.
+func (m Model) IsSearching() bool {
+	return m.list.SettingFilter() || m.action != promptNone
+}
+
+// cache returns the cache of the given host.
+func (m Model) cache(host string) *cache.Cache {
+	for _, c := range m.caches {
+		if c.Host() == host {
+			return c
+		}
+	}
+	return nil
+}
+
+// targetCache returns the cache new networks are created on: the selected
+// host, the host of the selected network or the first host.
+func (m Model) targetCache() *cache.Cache {
+	if m.host != "" {
+		return m.cache(m.host)
+	}
+	if n, ok := m.list.SelectedItem().(NetworkItem); ok {
+		return m.cache(n.host)
+	}
+	return m.caches[0]
+}
+
+// visible reports whether the networks of the given host are shown.
+func (m Model) visible(host string) bool {
+	return m.host == "" || m.host == host
+}
+
+// openPrompt shows the input prompt for the given action.
+func (m *Model) openPrompt(action promptAction, prompt, placeholder string) tea.Cmd {
+	m.action = action
+	m.input.Prompt = prompt
+	m.input.Placeholder = placeholder
+	m.input.SetValue("")
+	return m.input.Focus()
+}
+
+// submitPrompt runs the action of the prompt with the entered value.
+func (m *Model) submitPrompt() tea.Cmd {
+	action := m.action
+	value := strings.TrimSpace(m.input.Value())
+	m.action = promptNone
+	m.input.Blur()
+
+	if value == "" {
+		return nil
+	}
+
+	if action == promptCreate {
+		fields := strings.Fields(value)
+		name, driver, subnet := fields[0], "", ""
+		if len(fields) > 1 {
+			driver = fields[1]
+		}
+		if len(fields) > 2 {
+			subnet = fields[2]
+		}
+		c := m.targetCache()
+		m.status = style.StatusBar().Render(fmt.Sprintf("Creating network %s on %s", name, c.Host()))
+		return CreateNetworkCmd(c, name, driver, subnet)
+	}
+
+	n, ok := m.list.SelectedItem().(NetworkItem)
+	if !ok {
+		return nil
+	}
+	if action == promptConnect {
+		m.status = style.StatusBar().Render(fmt.Sprintf("Connecting %s to %s", value, n.Name))
+		return ConnectNetworkCmd(m.cache(n.host), n.ID, value)
+	}
+	m.status = style.StatusBar().Render(fmt.Sprintf("Disconnecting %s from %s", value, n.Name))
+	return DisconnectNetworkCmd(m.cache(n.host), n.ID, value)
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height-4-detailsHeight)
+		m.input.Width = msg.Width - 40
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.action != promptNone {
+			switch msg.String() {
+			case "enter":
+				return m, m.submitPrompt()
+			case "esc":
+				m.action = promptNone
+				m.input.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+		}
+		if m.IsSearching() {
+			break
+		}
+		switch {
+		case key.Matches(msg, keyMap.create):
+			return m, m.openPrompt(promptCreate, "New network: ", "name [driver] [subnet]")
+
+		case key.Matches(msg, keyMap.delete):
+			if n, ok := m.list.SelectedItem().(NetworkItem); ok {
+				if len(n.Containers) > 0 {
+					m.status = style.Danger().Render(fmt.Sprintf("Network %s has %s", n.Name, n.ConnectedString()))
+					return m, nil
+				}
+				m.status = style.StatusBar().Render("Deleting network " + n.Name)
+				return m, DeleteNetworkCmd(m.cache(n.host), n.ID, n.Name)
+			}
+			return m, nil
+
+		case key.Matches(msg, keyMap.connect):
+			if n, ok := m.list.SelectedItem().(NetworkItem); ok {
+				return m, m.openPrompt(promptConnect, fmt.Sprintf("Connect to %s: ", n.Name), "container name or ID")
+			}
+			return m, nil
+
+		case key.Matches(msg, keyMap.disconnect):
+			if n, ok := m.list.SelectedItem().(NetworkItem); ok {
+				placeholder := "container name or ID"
+				if endpoints := n.Endpoints(); len(endpoints) > 0 {
+					names := make([]string, len(endpoints))
+					for i, ep := range endpoints {
+						names[i] = ep.Name
+					}
+					placeholder = strings.Join(names, ", ")
+				}
+				return m, m.openPrompt(promptDisconnect, fmt.Sprintf("Disconnect from %s: ", n.Name), placeholder)
+			}
+			return m, nil
+		}
+
+	case CreateNetworkMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.status = style.Success().Render("Network " + msg.Name + " created")
+		}
+
+	case DeleteNetworkMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.status = style.Success().Render("Network " + msg.Name + " deleted")
+		}
+
+	case ConnectNetworkMsg:
+		switch {
+		case msg.Err != nil:
+			m.status = style.Danger().Render(msg.Err.Error())
+		case msg.Disconnect:
+			m.status = style.Success().Render("Container " + msg.Container + " disconnected")
+		default:
+			m.status = style.Success().Render("Container " + msg.Container + " connected")
+		}
+
+	case commands.SelectHostMsg:
+		m.host = msg.Host
+		m.applyFilter()
+
+	case cache.Event:
+		switch msg.EventType {
+		case cache.NetworksLoadedEventType:
+			m.loaded = true
+			log.Printf("received networks loaded event: %+v", msg)
+			m.applyFilter()
+		case cache.NetworkEventType:
+			if m.loaded && m.visible(msg.Host) {
+				log.Printf("received network event: %+v", msg)
+				m.applyFilter()
+			}
+		}
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if !m.loaded {
+		return "Chargement des réseaux Docker..."
+	}
+
+	details := make([]string, 0, detailsHeight)
+	if n, ok := m.list.SelectedItem().(NetworkItem); ok {
+		for _, line := range n.Details(detailsHeight - 1) {
+			details = append(details, style.Subtitle().Render(line))
+		}
+	}
+	for len(details) < detailsHeight-1 {
+		details = append(details, "")
+	}
+
+	status := m.status
+	if m.action != promptNone {
+		status = m.input.View()
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.list.View(),
+		strings.Join(details, "\n"),
+		status,
+	)
+}
+
+// applyFilter rebuilds the list from the caches of the visible hosts.
+func (m *Model) applyFilter() {
+	itemList := make([]list.Item, 0, len(m.list.Items()))
+	for _, c := range m.caches {
+		if !m.visible(c.Host()) {
+			continue
+		}
+		for _, n := range c.Networks() {
+			item := NewNetworkItem(c.Host(), n)
+			item.showHost = len(m.caches) > 1
+			itemList = append(itemList, item)
+		}
+	}
+
+	m.list.SetItems(itemList)
+}
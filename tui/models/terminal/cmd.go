@@ -0,0 +1,144 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/moby/term"
+	"github.com/muesli/cancelreader"
+)
+
+// ShellDetectedMsg is sent once the shell of the container has been detected.
+type ShellDetectedMsg struct {
+	Shell string
+	Err   error
+}
+
+// ExecDoneMsg is sent once the command run in the container is over.
+type ExecDoneMsg struct {
+	Cmd []string
+	Err error
+}
+
+// ExitError is returned when the command run in the container exits with a non-zero code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exited with code %d", e.Code)
+}
+
+// DetectShellCmd looks for the shell the user can run in the container.
+func DetectShellCmd(cli client.Client, id, user string) tea.Cmd {
+	return func() tea.Msg {
+		shell, err := client.DetectShell(context.Background(), cli, id, user)
+		return ShellDetectedMsg{Shell: shell, Err: err}
+	}
+}
+
+// ExecCmd suspends the TUI and runs the command in the container, attached to the terminal.
+func ExecCmd(cli client.Client, id string, config client.ExecConfig) tea.Cmd {
+	c := &execCommand{cli: cli, id: id, config: config}
+	return tea.Exec(c, func(err error) tea.Msg {
+		return ExecDoneMsg{Cmd: config.Cmd, Err: err}
+	})
+}
+
+// execCommand is a tea.ExecCommand running a command in a container
+// through a connection hijacked from the daemon.
+type execCommand struct {
+	cli    client.Client
+	id     string
+	config client.ExecConfig
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (c *execCommand) SetStdin(r io.Reader)  { c.stdin = r }
+func (c *execCommand) SetStdout(w io.Writer) { c.stdout = w }
+func (c *execCommand) SetStderr(w io.Writer) { c.stderr = w }
+
+// Run runs the command until it exits. A TTY is allocated when the standard
+// input is a terminal: the terminal is then put in raw mode and its size
+// follows the size of the local terminal.
+func (c *execCommand) Run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inFd, tty := term.GetFdInfo(c.stdin)
+	outFd, _ := term.GetFdInfo(c.stdout)
+
+	config := c.config
+	config.Tty = tty
+	if tty {
+		if ws, err := term.GetWinsize(outFd); err == nil {
+			config.Height, config.Width = uint(ws.Height), uint(ws.Width)
+		}
+	}
+
+	session, err := c.cli.Exec(ctx, c.id, config)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if tty {
+		state, err := term.MakeRaw(inFd)
+		if err != nil {
+			return err
+		}
+		defer term.RestoreTerminal(inFd, state)
+		go followSize(ctx, outFd, session)
+	}
+
+	stdin, err := cancelreader.NewReader(c.stdin)
+	if err != nil {
+		return err
+	}
+	defer stdin.Close()
+	go func() {
+		io.Copy(session, stdin)
+		session.CloseWrite()
+	}()
+
+	if tty {
+		_, err = io.Copy(c.stdout, session)
+	} else {
+		_, err = stdcopy.StdCopy(c.stdout, c.stderr, session)
+	}
+	stdin.Cancel()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	code, err := session.ExitCode(ctx)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
+
+// resize sets the size of the terminal of the session to the size of the local terminal.
+func resize(ctx context.Context, fd uintptr, session client.ExecSession) {
+	ws, err := term.GetWinsize(fd)
+	if err != nil || ws.Height == 0 || ws.Width == 0 {
+		return
+	}
+	session.Resize(ctx, uint(ws.Height), uint(ws.Width))
+}
+
+// commandString returns the command as typed by the user.
+func commandString(cmd []string) string {
+	return strings.Join(cmd, " ")
+}
@@ -0,0 +1,145 @@
+// Package terminal provides the page opening a terminal in a container.
+package terminal
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/kernaxis/gmd/tui/commands"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// Model prompts for the user and the command to run in a container, then runs it
+// attached to the terminal. An empty command runs the first shell found in the container.
+type Model struct {
+	cli     client.Client
+	id      string
+	name    string
+	user    textinput.Model
+	command textinput.Model
+	running bool
+	status  string
+}
+
+// New returns the prompt to run a command in the container with the given ID and name.
+func New(cli client.Client, id, name string) Model {
+	user := textinput.New()
+	user.Prompt = "User:    "
+	user.Placeholder = "user of the container"
+	user.CharLimit = 64
+	user.Focus()
+
+	command := textinput.New()
+	command.Prompt = "Command: "
+	command.Placeholder = strings.Join(client.Shells, " → ")
+	command.CharLimit = 256
+
+	return Model{
+		cli:     cli,
+		id:      id,
+		name:    name,
+		user:    user,
+		command: command,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// IsSearching reports that keys must be sent to the prompt.
+func (m Model) IsSearching() bool {
+	return true
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.user.Width = msg.Width - 20
+		m.command.Width = msg.Width - 20
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.running {
+			return m, nil
+		}
+		switch msg.String() {
+		case "esc":
+			return m, commands.SwitchPageCmd(nil)
+		case "tab", "shift+tab", "up", "down":
+			if m.user.Focused() {
+				m.user.Blur()
+				return m, m.command.Focus()
+			}
+			m.command.Blur()
+			return m, m.user.Focus()
+		case "enter":
+			return m, m.run()
+		}
+
+	case ShellDetectedMsg:
+		if msg.Err != nil {
+			m.running = false
+			m.status = style.Danger().Render(msg.Err.Error())
+			return m, nil
+		}
+		return m, m.exec([]string{msg.Shell})
+
+	case ExecDoneMsg:
+		m.running = false
+		var exit *ExitError
+		switch {
+		case msg.Err == nil:
+			return m, commands.SwitchPageCmd(nil)
+		case errors.As(msg.Err, &exit):
+			m.status = style.Warning().Render(commandString(msg.Cmd) + " " + exit.Error())
+		default:
+			m.status = style.Danger().Render(msg.Err.Error())
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	if m.user.Focused() {
+		m.user, cmd = m.user.Update(msg)
+	} else {
+		m.command, cmd = m.command.Update(msg)
+	}
+	return m, cmd
+}
+
+// run runs the entered command, or detects the shell of the container first.
+func (m *Model) run() tea.Cmd {
+	m.running = true
+	cmd := strings.Fields(m.command.Value())
+	if len(cmd) == 0 {
+		m.status = style.StatusBar().Render("Looking for a shell in " + m.name)
+		return DetectShellCmd(m.cli, m.id, strings.TrimSpace(m.user.Value()))
+	}
+	return m.exec(cmd)
+}
+
+// exec suspends the TUI and runs cmd in the container.
+func (m *Model) exec(cmd []string) tea.Cmd {
+	m.status = style.StatusBar().Render("Running " + commandString(cmd) + " in " + m.name)
+	return ExecCmd(m.cli, m.id, client.ExecConfig{Cmd: cmd, User: strings.TrimSpace(m.user.Value())})
+}
+
+func (m Model) View() string {
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		style.Title().Render("Exec · "+m.name),
+		"",
+		m.user.View(),
+		m.command.View(),
+		"",
+		m.status,
+		"",
+		style.Subtitle().Render("tab switch field • enter run • esc back"),
+	)
+}
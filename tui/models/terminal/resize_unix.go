@@ -0,0 +1,29 @@
+//go:build !windows
+
+package terminal
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/kernaxis/gmd/docker/client"
+)
+
+// followSize resizes the terminal of the session each time the local terminal
+// is resized, until ctx is done.
+func followSize(ctx context.Context, fd uintptr, session client.ExecSession) {
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-winch:
+			resize(ctx, fd, session)
+		}
+	}
+}
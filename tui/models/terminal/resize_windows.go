@@ -0,0 +1,39 @@
+//go:build windows
+
+package terminal
+
+import (
+	"context"
+	"time"
+
+	"github.com/kernaxis/gmd/docker/client"
+	"github.com/moby/term"
+)
+
+// resizePollInterval is the delay between two reads of the size of the console.
+const resizePollInterval = 250 * time.Millisecond
+
+// followSize resizes the terminal of the session each time the local console
+// is resized, until ctx is done. Windows has no SIGWINCH, the size is polled.
+func followSize(ctx context.Context, fd uintptr, session client.ExecSession) {
+	var height, width uint16
+	if ws, err := term.GetWinsize(fd); err == nil {
+		height, width = ws.Height, ws.Width
+	}
+
+	ticker := time.NewTicker(resizePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ws, err := term.GetWinsize(fd)
+			if err != nil || (ws.Height == height && ws.Width == width) {
+				continue
+			}
+			height, width = ws.Height, ws.Width
+			resize(ctx, fd, session)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package updatehistory
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kernaxis/gmd/history"
+	"github.com/kernaxis/gmd/tui/commands"
+	"github.com/kernaxis/gmd/tui/controllers/containerupdate"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// Model browses the history of the container updates, newest first.
+type Model struct {
+	store     *history.Store
+	host      string // host is the host of the container whose updates are shown.
+	container string // container is the name of the container whose updates are shown, empty for all.
+	all       bool   // all is set to show the updates of all the containers.
+
+	records  []history.Record // records are the shown records, newest first.
+	err      error
+	cursor   int
+	expanded bool // expanded is set to show the steps of the selected update.
+	width    int
+	height   int
+}
+
+type keyMapping struct {
+	back   key.Binding
+	up     key.Binding
+	down   key.Binding
+	expand key.Binding
+	all    key.Binding
+	reload key.Binding
+}
+
+var keyMap = &keyMapping{
+	back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	up: key.NewBinding(
+		key.WithKeys("up", "k"),
+	),
+	down: key.NewBinding(
+		key.WithKeys("down", "j"),
+	),
+	expand: key.NewBinding(
+		key.WithKeys("enter", " "),
+		key.WithHelp("enter", "show steps"),
+	),
+	all: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "all containers"),
+	),
+	reload: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reload"),
+	),
+}
+
+// New returns the history of the updates of the container with the given
+// name on host, or of all the containers when the name is empty.
+// The updates are read from the history of the update pipeline.
+func New(host, container string) Model {
+	m := Model{
+		store:     containerupdate.History,
+		host:      host,
+		container: container,
+		all:       container == "",
+	}
+	m.load()
+	return m
+}
+
+// load reads the records of the store.
+func (m *Model) load() {
+	m.records, m.err = nil, nil
+	if m.store == nil {
+		m.err = fmt.Errorf("no update history")
+		return
+	}
+	records, err := m.store.Load()
+	if err != nil {
+		m.err = err
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if !m.all && (r.Container != m.container || r.Host != m.host) {
+			continue
+		}
+		m.records = append(m.records, r)
+	}
+	m.cursor = min(m.cursor, max(len(m.records)-1, 0))
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height - 3
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keyMap.back):
+			return m, commands.SwitchPageCmd(nil)
+		case key.Matches(msg, keyMap.up):
+			m.cursor = max(m.cursor-1, 0)
+		case key.Matches(msg, keyMap.down):
+			m.cursor = min(m.cursor+1, max(len(m.records)-1, 0))
+		case key.Matches(msg, keyMap.expand):
+			m.expanded = !m.expanded
+		case key.Matches(msg, keyMap.all):
+			if m.container != "" {
+				m.all = !m.all
+				m.cursor = 0
+				m.load()
+			}
+		case key.Matches(msg, keyMap.reload):
+			m.load()
+		}
+	}
+	return m, nil
+}
+
+func (m Model) View() string {
+	body := ""
+	switch {
+	case m.err != nil && len(m.records) == 0:
+		body = style.Danger().Render(m.err.Error())
+	case len(m.records) == 0:
+		body = style.Inactive().Render("no update recorded")
+	default:
+		body = m.viewRecords()
+	}
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.viewHeader(),
+		body,
+		m.viewFooter(),
+	)
+}
+
+func (m Model) viewHeader() string {
+	title := "Update history · all containers"
+	if !m.all {
+		title = "Update history · " + m.container
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Left,
+		style.Title().Render(title), "  ",
+		style.Subtitle().Render(fmt.Sprintf("%d update(s)", len(m.records))))
+}
+
+// viewRecords renders the records around the cursor, with the steps of the
+// selected one when expanded.
+func (m Model) viewRecords() string {
+	lines := []string{}
+	cursorLine := 0
+	for i, r := range m.records {
+		line := m.recordLine(r)
+		if i == m.cursor {
+			cursorLine = len(lines)
+			line = style.ListSelectedLine().Render(line)
+		}
+		lines = append(lines, line)
+		if i == m.cursor && m.expanded {
+			lines = append(lines, recordDetails(r)...)
+		}
+	}
+
+	// the view scrolls to keep the selected update and its steps visible
+	height := max(m.height, 1)
+	offset := 0
+	if m.expanded {
+		offset = max(cursorLine+len(recordDetails(m.records[m.cursor]))+1-height, 0)
+	} else {
+		offset = max(cursorLine+1-height, 0)
+	}
+	offset = min(offset, cursorLine)
+	end := min(offset+height, len(lines))
+	out := lines[offset:end]
+	for len(out) < height {
+		out = append(out, "")
+	}
+	return strings.Join(out, "\n")
+}
+
+// recordLine renders the summary of a record.
+func (m Model) recordLine(r history.Record) string {
+	name := r.Container
+	if m.all {
+		name = r.Host + "/" + r.Container
+	}
+	line := fmt.Sprintf("%s  %-30s %s → %s  %s  %s",
+		r.Started.Local().Format("2006-01-02 15:04"), name,
+		imageLabel(r.Old), imageLabel(r.New),
+		resultLabel(r.Result), r.Duration().Round(100*time.Millisecond))
+	line += style.Inactive().Render(fmt.Sprintf("  %s · %s", r.Operator, r.Source))
+	if m.width > 0 {
+		line = lipgloss.NewStyle().MaxWidth(m.width - 1).Render(line)
+	}
+	return line
+}
+
+// recordDetails renders the steps and the error of a record.
+func recordDetails(r history.Record) []string {
+	container := fmt.Sprintf("    container %s → %s", shortID(r.OldContainerID), shortID(r.NewContainerID))
+	if r.Strategy != "" {
+		container += " · " + r.Strategy
+	}
+	if r.Result == history.Updated {
+		container += fmt.Sprintf(" · downtime %s", r.Downtime.Round(time.Millisecond))
+	}
+	lines := []string{style.Inactive().Render(container)}
+	for _, s := range r.Steps {
+		mark := style.Success().Render("✓")
+		text := fmt.Sprintf("%s: %s (%s)", s.Label, s.Target, s.Finished.Sub(s.Started).Round(10*time.Millisecond))
+		if s.Error != "" {
+			mark = style.Danger().Render("✗")
+			text += ": " + s.Error
+		}
+		lines = append(lines, "    "+mark+" "+text)
+	}
+	if r.Error != "" {
+		lines = append(lines, "    "+style.Danger().Render(r.Error))
+	}
+	return lines
+}
+
+func resultLabel(r history.Result) string {
+	switch r {
+	case history.Updated:
+		return style.Success().Render(string(r))
+	case history.RolledBack:
+		return style.Warning().Render(string(r))
+	default:
+		return style.Danger().Render(string(r))
+	}
+}
+
+// imageLabel returns the reference and the short digest of an image of the history.
+func imageLabel(v history.ImageVersion) string {
+	if v.Ref == "" {
+		return "-"
+	}
+	digest := v.Digest
+	if digest == "" {
+		digest = v.ID
+	}
+	digest = strings.TrimPrefix(digest, "sha256:")
+	if digest == "" {
+		return v.Ref
+	}
+	return fmt.Sprintf("%s (%s)", v.Ref, digest[:min(len(digest), 12)])
+}
+
+// shortID returns the first 12 characters of an ID, or "-".
+func shortID(id string) string {
+	if id == "" {
+		return "-"
+	}
+	return id[:min(len(id), 12)]
+}
+
+func (m Model) viewFooter() string {
+	bindings := []key.Binding{keyMap.back, keyMap.expand, keyMap.reload}
+	if m.container != "" {
+		bindings = append(bindings, keyMap.all)
+	}
+	help := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		help = append(help, b.Help().Key+" "+b.Help().Desc)
+	}
+	return style.Subtitle().Render(strings.Join(help, " • "))
+}
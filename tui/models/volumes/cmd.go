@@ -0,0 +1,49 @@
+package volumes
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/kernaxis/gmd/docker/cache"
+)
+
+type DeleteVolumeMsg struct {
+	Host string
+	Name string
+	Err  error
+}
+
+type PruneVolumesMsg struct {
+	Host   string
+	All    bool // All is set when the named volumes were pruned too.
+	Report volume.PruneReport
+	Err    error
+}
+
+type RefreshVolumesMsg struct {
+	Host string
+	Err  error
+}
+
+func DeleteVolumeCmd(c *cache.Cache, name string) tea.Cmd {
+	return func() tea.Msg {
+		err := c.Client().DeleteVolume(context.Background(), name)
+		return DeleteVolumeMsg{Host: c.Host(), Name: name, Err: err}
+	}
+}
+
+// PruneVolumesCmd deletes the unused anonymous volumes of the host of the cache, the named ones too when all is set.
+func PruneVolumesCmd(c *cache.Cache, all bool) tea.Cmd {
+	return func() tea.Msg {
+		report, err := c.Client().PruneVolumes(context.Background(), all)
+		return PruneVolumesMsg{Host: c.Host(), All: all, Report: report, Err: err}
+	}
+}
+
+func RefreshVolumesCmd(c *cache.Cache) tea.Cmd {
+	return func() tea.Msg {
+		err := c.RefreshVolumes()
+		return RefreshVolumesMsg{Host: c.Host(), Err: err}
+	}
+}
@@ -0,0 +1,44 @@
+package volumes
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+type ItemDelegate struct {
+	list.DefaultDelegate
+}
+
+func newItemDelegate() list.ItemDelegate {
+	d := list.NewDefaultDelegate()
+	return ItemDelegate{d}
+}
+
+func (d ItemDelegate) Height() int  { return 2 }
+func (d ItemDelegate) Spacing() int { return 0 }
+func (d ItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	v, ok := item.(VolumeItem)
+	if !ok {
+		return
+	}
+
+	title := style.Title().Render(v.Title())
+	desc := style.Subtitle().Render(v.Description())
+
+	content := lipgloss.JoinVertical(lipgloss.Left, title, desc)
+
+	if index == m.Index() {
+		content = style.ListSelectedLine().Inherit(style.Bold()).Render(content)
+	}
+
+	fmt.Fprint(w, content)
+}
@@ -0,0 +1,69 @@
+package volumes
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/kernaxis/gmd/docker/types"
+)
+
+type VolumeItem struct {
+	types.Volume
+	host     string
+	showHost bool
+	users    []string
+}
+
+func NewVolumeItem(host string, v types.Volume, users []string) VolumeItem {
+	return VolumeItem{Volume: v, host: host, users: users}
+}
+
+func (i VolumeItem) Title() string { return i.Name }
+
+func (i VolumeItem) Description() string {
+	parts := []string{i.Driver, i.SizeString(), i.UsedBy()}
+	if i.showHost {
+		parts = append(parts, i.host)
+	}
+	return strings.Join(parts, " - ")
+}
+
+// SizeString returns the human readable disk usage of the volume.
+func (i VolumeItem) SizeString() string {
+	if i.Size() < 0 {
+		return "size unknown"
+	}
+	return humanize.Bytes(uint64(i.Size()))
+}
+
+// UsedBy returns the containers mounting the volume.
+func (i VolumeItem) UsedBy() string {
+	if len(i.users) == 0 {
+		return "unused"
+	}
+	return "used by " + strings.Join(i.users, ", ")
+}
+
+// Details returns the inspect lines shown for the selected volume.
+func (i VolumeItem) Details() []string {
+	lines := []string{
+		fmt.Sprintf("Mountpoint: %s", i.Mountpoint),
+		fmt.Sprintf("Driver: %s   Scope: %s   Created: %s", i.Driver, i.Scope, i.CreatedAt),
+	}
+
+	if len(i.Labels) == 0 {
+		lines = append(lines, "Labels: -")
+	} else {
+		labels := make([]string, 0, len(i.Labels))
+		for _, k := range slices.Sorted(maps.Keys(i.Labels)) {
+			labels = append(labels, k+"="+i.Labels[k])
+		}
+		lines = append(lines, "Labels: "+strings.Join(labels, ", "))
+	}
+	return lines
+}
+
+func (i VolumeItem) FilterValue() string { return i.Name }
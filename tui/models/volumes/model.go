@@ -0,0 +1,314 @@
+package volumes
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dustin/go-humanize"
+	"github.com/kernaxis/gmd/docker/cache"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/tui/commands"
+	style "github.com/kernaxis/gmd/tui/styles"
+)
+
+// detailsHeight is the number of lines used below the list to show the selected volume.
+const detailsHeight = 4
+
+type Model struct {
+	caches []*cache.Cache
+	host   string // host is the name of the selected host, empty when all hosts are shown.
+	list   list.Model
+	loaded bool
+	unused bool
+	status string
+	prune  *pruneRequest // prune is the prune waiting for its confirmation, nil when none.
+}
+
+// pruneRequest is a prune of the unused volumes of a host.
+type pruneRequest struct {
+	host  string
+	all   bool // all is set to prune the named volumes too.
+	count int  // count is the number of volumes the prune deletes.
+}
+
+type listKeyMap struct {
+	toggleUnused key.Binding
+	delete       key.Binding
+	prune        key.Binding
+	pruneAll     key.Binding
+	refresh      key.Binding
+	confirm      key.Binding
+	cancel       key.Binding
+}
+
+var keyMap = &listKeyMap{
+	delete: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete selection"),
+	),
+	prune: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "prune unused anonymous volumes"),
+	),
+	pruneAll: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "prune all unused volumes"),
+	),
+	toggleUnused: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "toggle unused only"),
+	),
+	refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh sizes"),
+	),
+	confirm: key.NewBinding(
+		key.WithKeys("y", "enter"),
+		key.WithHelp("y", "confirm"),
+	),
+	cancel: key.NewBinding(
+		key.WithKeys("n", "esc"),
+		key.WithHelp("n", "cancel"),
+	),
+}
+
+func New(caches []*cache.Cache) Model {
+
+	items := []list.Item{}
+
+	l := list.New(items, newItemDelegate(), 0, 0)
+	l.Title = "Volumes"
+	l.AdditionalFullHelpKeys = func() []key.Binding {
+		return []key.Binding{
+			keyMap.delete,
+			keyMap.prune,
+			keyMap.pruneAll,
+			keyMap.toggleUnused,
+			keyMap.refresh,
+		}
+	}
+
+	return Model{
+		caches: caches,
+		list:   l,
+	}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// IsSearching reports whether keys must be sent to the list filter or to the prune confirmation.
+func (m Model) IsSearching() bool {
+	return m.list.SettingFilter() || m.prune != nil
+}
+
+// cache returns the cache of the given host.
+func (m Model) cache(host string) *cache.Cache {
+	for _, c := range m.caches {
+		if c.Host() == host {
+			return c
+		}
+	}
+	return nil
+}
+
+// visible reports whether the volumes of the given host are shown.
+func (m Model) visible(host string) bool {
+	return m.host == "" || m.host == host
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+
+	switch msg := msg.(type) {
+
+	case tea.WindowSizeMsg:
+		m.list.SetSize(msg.Width, msg.Height-4-detailsHeight)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.prune != nil {
+			p := m.prune
+			switch {
+			case key.Matches(msg, keyMap.confirm):
+				m.prune = nil
+				m.status = style.StatusBar().Render("Pruning unused volumes on " + p.host)
+				return m, PruneVolumesCmd(m.cache(p.host), p.all)
+			case key.Matches(msg, keyMap.cancel):
+				m.prune = nil
+				m.status = ""
+			}
+			return m, nil
+		}
+		if m.IsSearching() {
+			break
+		}
+		switch {
+		case key.Matches(msg, keyMap.toggleUnused):
+			m.unused = !m.unused
+			m.applyFilter()
+			return m, nil
+
+		case key.Matches(msg, keyMap.delete):
+			if v, ok := m.list.SelectedItem().(VolumeItem); ok {
+				if len(v.users) > 0 {
+					m.status = style.Danger().Render(fmt.Sprintf("Volume %s is %s", v.Name, v.UsedBy()))
+					return m, nil
+				}
+				m.status = style.StatusBar().Render("Deleting volume " + v.Name)
+				return m, DeleteVolumeCmd(m.cache(v.host), v.Name)
+			}
+			return m, nil
+
+		case key.Matches(msg, keyMap.prune), key.Matches(msg, keyMap.pruneAll):
+			m.askPrune(key.Matches(msg, keyMap.pruneAll))
+			return m, nil
+
+		case key.Matches(msg, keyMap.refresh):
+			var cmds []tea.Cmd
+			for _, c := range m.caches {
+				if m.visible(c.Host()) {
+					cmds = append(cmds, RefreshVolumesCmd(c))
+				}
+			}
+			m.status = style.StatusBar().Render("Refreshing volumes")
+			return m, tea.Batch(cmds...)
+		}
+
+	case DeleteVolumeMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.status = style.Success().Render("Volume " + msg.Name + " deleted")
+		}
+
+	case PruneVolumesMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.status = style.Success().Render(fmt.Sprintf("%d volume(s) deleted on %s, %s reclaimed",
+				len(msg.Report.VolumesDeleted), msg.Host, humanize.Bytes(msg.Report.SpaceReclaimed)))
+		}
+
+	case RefreshVolumesMsg:
+		if msg.Err != nil {
+			m.status = style.Danger().Render(msg.Err.Error())
+		} else {
+			m.status = ""
+		}
+
+	case commands.SelectHostMsg:
+		m.host = msg.Host
+		m.applyFilter()
+
+	case cache.Event:
+		switch msg.EventType {
+		case cache.VolumesLoadedEventType:
+			m.loaded = true
+			log.Printf("received volumes loaded event: %+v", msg)
+			m.applyFilter()
+		case cache.VolumeEventType, cache.ContainerEventType, cache.ContainersLoadedEventType:
+			if m.loaded && m.visible(msg.Host) {
+				log.Printf("received volume event: %+v", msg)
+				m.applyFilter()
+			}
+		}
+	}
+
+	newList, cmd := m.list.Update(msg)
+	m.list = newList
+	return m, cmd
+}
+
+func (m Model) View() string {
+	if !m.loaded {
+		return "Chargement des volumes Docker..."
+	}
+
+	details := make([]string, 0, detailsHeight)
+	if v, ok := m.list.SelectedItem().(VolumeItem); ok {
+		for _, line := range v.Details() {
+			details = append(details, style.Subtitle().Render(line))
+		}
+	}
+	for len(details) < detailsHeight-1 {
+		details = append(details, "")
+	}
+
+	status := m.status
+	if p := m.prune; p != nil {
+		kind := "anonymous"
+		if p.all {
+			kind = "anonymous and named"
+		}
+		status = style.Warning().Render(fmt.Sprintf("Delete %d unused %s volume(s) on %s? y confirm • n cancel", p.count, kind, p.host))
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		m.list.View(),
+		strings.Join(details, "\n"),
+		status,
+	)
+}
+
+// askPrune asks to confirm the prune of the unused volumes of the selected
+// host, or of the host of the selected volume when all the hosts are shown.
+// Only the anonymous volumes are pruned, unless all is set.
+func (m *Model) askPrune(all bool) {
+	host := m.host
+	if host == "" {
+		if v, ok := m.list.SelectedItem().(VolumeItem); ok {
+			host = v.host
+		} else if len(m.caches) == 1 {
+			host = m.caches[0].Host()
+		}
+	}
+	c := m.cache(host)
+	if c == nil {
+		m.status = style.Danger().Render("Select a host to prune its volumes")
+		return
+	}
+
+	count := 0
+	for _, v := range c.VolumesUnused() {
+		if all || v.Anonymous() {
+			count++
+		}
+	}
+	if count == 0 {
+		m.status = style.StatusBar().Render("No unused volume to prune on " + host)
+		return
+	}
+	m.prune = &pruneRequest{host: host, all: all, count: count}
+}
+
+// applyFilter rebuilds the list from the caches of the visible hosts.
+func (m *Model) applyFilter() {
+	itemList := make([]list.Item, 0, len(m.list.Items()))
+	for _, c := range m.caches {
+		if !m.visible(c.Host()) {
+			continue
+		}
+
+		var volumes []types.Volume
+		if m.unused {
+			volumes = c.VolumesUnused()
+		} else {
+			volumes = c.Volumes()
+		}
+
+		for _, v := range volumes {
+			item := NewVolumeItem(c.Host(), v, c.VolumeUsers(v.Name))
+			item.showHost = len(m.caches) > 1
+			itemList = append(itemList, item)
+		}
+	}
+
+	m.list.SetItems(itemList)
+}
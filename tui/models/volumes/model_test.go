@@ -0,0 +1,100 @@
+package volumes
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kernaxis/gmd/docker/cache"
+	"github.com/kernaxis/gmd/docker/client/fake"
+	"github.com/kernaxis/gmd/docker/types"
+	"github.com/kernaxis/gmd/tui/commands"
+)
+
+// load returns the loaded cache of the engine.
+func load(t *testing.T, e *fake.Engine) *cache.Cache {
+	t.Helper()
+	c := cache.NewCache(e)
+	go func() {
+		for range c.Events() {
+		}
+	}()
+	if err := c.LoadAndStart(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.StopEvents)
+	return c
+}
+
+func keyMsg(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestPrune(t *testing.T) {
+	anonymous := map[string]string{types.AnonymousVolumeLabel: ""}
+	tests := []struct {
+		key     string
+		count   int
+		deleted []string
+	}{
+		{"P", 2, []string{"3f1c", "9ab2"}},
+		{"X", 3, []string{"3f1c", "9ab2", "cache"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			e := fake.New()
+			e.AddVolume("3f1c", anonymous, 10)
+			e.AddVolume("9ab2", anonymous, 20)
+			e.AddVolume("cache", nil, 30)
+			id := e.AddContainer("db", "postgres:16", true)
+			if err := e.MountVolume(id, "data", "/var/lib/postgresql/data"); err != nil {
+				t.Fatal(err)
+			}
+			c := load(t, e)
+
+			var m tea.Model = New([]*cache.Cache{c})
+			m, _ = m.Update(cache.Event{Host: "fake", EventType: cache.VolumesLoadedEventType})
+			m, _ = m.Update(commands.SelectHostMsg{Host: "fake"})
+			m, _ = m.Update(keyMsg(tt.key))
+			prune := m.(Model).prune
+			if prune == nil || prune.host != "fake" || prune.count != tt.count {
+				t.Fatalf("prune %+v, want %d volumes of fake to confirm", prune, tt.count)
+			}
+			if view := m.View(); !strings.Contains(view, "on fake?") {
+				t.Errorf("the confirmation does not name the host:\n%s", view)
+			}
+
+			// nothing is deleted until confirmed
+			m, cmd := m.Update(keyMsg("n"))
+			if m.(Model).prune != nil || cmd != nil {
+				t.Fatal("the prune is not canceled")
+			}
+			if vols, _ := e.VolumeList(); len(vols) != 4 {
+				t.Fatalf("%d volumes left after the cancel, want 4", len(vols))
+			}
+
+			m, _ = m.Update(keyMsg(tt.key))
+			m, cmd = m.Update(keyMsg("y"))
+			if cmd == nil {
+				t.Fatal("no prune after the confirmation")
+			}
+			msg, ok := cmd().(PruneVolumesMsg)
+			if !ok || msg.Err != nil || !slices.Equal(msg.Report.VolumesDeleted, tt.deleted) {
+				t.Fatalf("prune = %+v, want %v deleted", msg, tt.deleted)
+			}
+		})
+	}
+}
+
+func TestPruneNothing(t *testing.T) {
+	e := fake.New()
+	e.AddVolume("cache", nil, 30)
+	c := load(t, e)
+
+	var m tea.Model = New([]*cache.Cache{c})
+	m, _ = m.Update(keyMsg("P"))
+	if m.(Model).prune != nil {
+		t.Error("confirmation asked without anonymous volume to prune")
+	}
+}
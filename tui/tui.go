@@ -5,9 +5,10 @@ import (
 	"log"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kernaxis/gmd/docker/client"
 )
 
-func Start(debugFile string) (err error) {
+func Start(debugFile string, endpoints []client.Endpoint) (err error) {
 
 	if debugFile != "" {
 		f, err := tea.LogToFile(debugFile, "debug")
@@ -20,7 +21,7 @@ func Start(debugFile string) (err error) {
 		log.SetOutput(io.Discard)
 	}
 
-	model, err := NewModel()
+	model, err := NewModel(endpoints)
 
 	if err != nil {
 		return err